@@ -1,14 +1,22 @@
 package app
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/dennis/hello_go/blobs"
 	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/graphqlapi"
+	"github.com/dennis/hello_go/grpcapi"
 	"github.com/dennis/hello_go/handlers"
+	"github.com/dennis/hello_go/i18n"
+	"github.com/dennis/hello_go/markdown"
+	"github.com/dennis/hello_go/models"
 	"github.com/dennis/hello_go/repositories"
 	"github.com/dennis/hello_go/services"
 )
@@ -28,41 +36,224 @@ func (l *loggingResponseWriter) WriteHeader(code int) {
 	l.ResponseWriter.WriteHeader(code)
 }
 
+// Streaming responses (ie GraphQL subscriptions) needs to flush
+func (l *loggingResponseWriter) Flush() {
+	if flusher, ok := l.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 type App struct {
 	Router  *mux.Router
 	Context context.Context
+	Config  Config
+
+	messageRepository *repositories.MessageRepository
+	userRepository    *repositories.UserRepository
+	channelRepository *repositories.ChannelRepository
+	draftRepository   *repositories.DraftRepository
 }
 
-func (a *App) Initialize() {
+func (a *App) Initialize() error {
+	a.Config = a.Config.withDefaults()
+	models.CurrentLimits = a.Config.Limits
+	graphqlapi.CurrentLimits = a.Config.GraphQLLimits
+
 	a.setupRoutes()
-	a.populateData()
+
+	return a.populateData()
 }
 
 func (a *App) setupRoutes() {
 	a.Router = mux.NewRouter()
+	a.Router.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	a.Router.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
 
 	a.Router.HandleFunc("/api/messages", a.handleRequest(handlers.GetMessages)).Methods("GET")
 	a.Router.HandleFunc("/api/messages/{id}", a.handleRequest(handlers.GetMessage)).Methods("GET")
 	a.Router.HandleFunc("/api/messages", a.handleRequest(handlers.CreateMessage)).Methods("POST")
 	a.Router.HandleFunc("/api/messages/{id}", a.handleRequest(handlers.UpdateMessage)).Methods("PUT")
 	a.Router.HandleFunc("/api/messages/{id}", a.handleRequest(handlers.DeleteMessage)).Methods("DELETE")
+	a.Router.HandleFunc("/api/messages/read", a.handleRequest(handlers.MarkAllRead)).Methods("POST")
+	a.Router.HandleFunc("/api/messages/{id}/read", a.handleRequest(handlers.MarkMessageRead)).Methods("POST")
+	a.Router.HandleFunc("/api/messages/{id}/thread/read", a.handleRequest(handlers.MarkThreadRead)).Methods("POST")
+	a.Router.HandleFunc("/api/messages/{id}/restore", a.handleRequest(handlers.RestoreMessage)).Methods("POST")
+	a.Router.HandleFunc("/api/messages/{id}/reactions/{emoji}", a.handleRequest(handlers.AddReaction)).Methods("PUT")
+	a.Router.HandleFunc("/api/messages/{id}/reactions/{emoji}", a.handleRequest(handlers.RemoveReaction)).Methods("DELETE")
+	a.Router.HandleFunc("/api/messages/{id}/pin", a.handleRequest(handlers.PinMessage)).Methods("PUT")
+	a.Router.HandleFunc("/api/messages/{id}/pin", a.handleRequest(handlers.UnpinMessage)).Methods("DELETE")
+	a.Router.HandleFunc("/api/messages/{id}/lock", a.handleRequest(handlers.LockMessage)).Methods("PUT")
+	a.Router.HandleFunc("/api/messages/{id}/lock", a.handleRequest(handlers.UnlockMessage)).Methods("DELETE")
+	a.Router.HandleFunc("/api/messages/{id}/attachments", a.handleRequest(handlers.AddAttachment)).Methods("POST")
+	a.Router.HandleFunc("/api/messages/{id}/attachments/{attachment_id}", a.handleRequest(handlers.GetAttachment)).Methods("GET")
+	a.Router.HandleFunc("/api/messages/{id}/attachments/{attachment_id}", a.handleRequest(handlers.DeleteAttachment)).Methods("DELETE")
+
+	a.Router.HandleFunc("/api/drafts", a.handleRequest(handlers.GetDrafts)).Methods("GET")
+	a.Router.HandleFunc("/api/drafts", a.handleRequest(handlers.CreateDraft)).Methods("POST")
+	a.Router.HandleFunc("/api/drafts/{id}", a.handleRequest(handlers.GetDraft)).Methods("GET")
+	a.Router.HandleFunc("/api/drafts/{id}", a.handleRequest(handlers.UpdateDraft)).Methods("PUT")
+	a.Router.HandleFunc("/api/drafts/{id}", a.handleRequest(handlers.DeleteDraft)).Methods("DELETE")
+	a.Router.HandleFunc("/api/drafts/{id}/publish", a.handleRequest(handlers.PublishDraft)).Methods("POST")
+
+	a.Router.HandleFunc("/api/trash", a.handleRequest(handlers.GetTrash)).Methods("GET")
+	a.Router.HandleFunc("/api/tags", a.handleRequest(handlers.GetTags)).Methods("GET")
+
+	a.Router.HandleFunc("/api/inbox", a.handleRequest(handlers.GetInbox)).Methods("GET")
+
+	a.Router.HandleFunc("/api/notifications", a.handleRequest(handlers.GetNotifications)).Methods("GET")
+	a.Router.HandleFunc("/api/notifications/read", a.handleRequest(handlers.MarkAllNotificationsRead)).Methods("POST")
+	a.Router.HandleFunc("/api/notifications/{id}/read", a.handleRequest(handlers.MarkNotificationRead)).Methods("POST")
+
+	a.Router.HandleFunc("/api/muted", a.handleRequest(handlers.GetMuted)).Methods("GET")
+	a.Router.HandleFunc("/api/muted/{username}", a.handleRequest(handlers.Mute)).Methods("PUT")
+	a.Router.HandleFunc("/api/muted/{username}", a.handleRequest(handlers.Unmute)).Methods("DELETE")
+	a.Router.HandleFunc("/api/blocked", a.handleRequest(handlers.GetBlocked)).Methods("GET")
+	a.Router.HandleFunc("/api/blocked/{username}", a.handleRequest(handlers.Block)).Methods("PUT")
+	a.Router.HandleFunc("/api/blocked/{username}", a.handleRequest(handlers.Unblock)).Methods("DELETE")
+
+	a.Router.HandleFunc("/api/channels", a.handleRequest(handlers.GetChannels)).Methods("GET")
+	a.Router.HandleFunc("/api/channels", a.handleRequest(handlers.CreateChannel)).Methods("POST")
+	a.Router.HandleFunc("/api/channels/{id}", a.handleRequest(handlers.GetChannel)).Methods("GET")
+	a.Router.HandleFunc("/api/channels/{id}/messages", a.handleRequest(handlers.GetChannelMessages)).Methods("GET")
+	a.Router.HandleFunc("/api/channels/{id}/messages", a.handleRequest(handlers.CreateChannelMessage)).Methods("POST")
+	a.Router.HandleFunc("/api/channels/{id}/members/{username}", a.handleRequest(handlers.AddChannelMember)).Methods("PUT")
+	a.Router.HandleFunc("/api/channels/{id}/members/{username}", a.handleRequest(handlers.RemoveChannelMember)).Methods("DELETE")
+
+	a.Router.HandleFunc("/api/graphql", a.handleRequest(handlers.GraphQL)).Methods("GET", "POST")
+
+	a.Router.HandleFunc("/api/moderation/queue", a.handleRequest(handlers.GetHeldMessages)).Methods("GET")
+	a.Router.HandleFunc("/api/moderation/queue/{id}/approve", a.handleRequest(handlers.ApproveHeldMessage)).Methods("POST")
+	a.Router.HandleFunc("/api/moderation/queue/{id}/reject", a.handleRequest(handlers.RejectHeldMessage)).Methods("POST")
+
+	a.Router.HandleFunc("/api/admin/messages/import", a.handleRequest(handlers.ImportMessages)).Methods("POST")
+	a.Router.HandleFunc("/api/admin/messages/export", a.handleRequest(handlers.ExportMessages)).Methods("GET")
+	a.Router.HandleFunc("/api/admin/messages/{id}", a.handleRequest(handlers.PurgeMessage)).Methods("DELETE")
+	a.Router.HandleFunc("/api/admin/users/import", a.handleRequest(handlers.ImportUsers)).Methods("POST")
+	a.Router.HandleFunc("/api/admin/users/export", a.handleRequest(handlers.ExportUsers)).Methods("GET")
+
+	// Documentation is available without authentication
+	a.Router.HandleFunc("/api/openapi.json", handlers.OpenAPI).Methods("GET")
+	a.Router.HandleFunc("/api/docs", handlers.Docs).Methods("GET")
 }
 
-func (a *App) populateData() {
-	messageRepository := repositories.MessageRepository{}
-	userRepository := repositories.UserRepository{}
+// Loads the data files and wires up the services. This is shared by all
+// subcommands, so they see the data exactly as the server would
+func (a *App) populateData() error {
+	a.messageRepository = &repositories.MessageRepository{}
+	a.userRepository = &repositories.UserRepository{}
+	a.channelRepository = &repositories.ChannelRepository{}
+	a.draftRepository = &repositories.DraftRepository{}
 
-	PopulateMessages(&messageRepository)
-	PopulateUsers(&userRepository)
+	// Other filters, ie a classifier, can be added to the MessageService
+	// once it is initialized
+	pipeline, err := a.Config.Moderation.Pipeline()
+	if err != nil {
+		return fmt.Errorf("moderation: %v", err)
+	}
 
 	a.Context = context.Context{
-		AuthenticationService: services.AuthenticationService{UserRepository: &userRepository},
-		MessageService:        services.MessageService{MessageRepository: &messageRepository},
+		AuthenticationService: services.AuthenticationService{UserRepository: a.userRepository},
+		UserService:           services.UserService{UserRepository: a.userRepository},
+		ChannelService:        services.ChannelService{ChannelRepository: a.channelRepository, UserRepository: a.userRepository},
+		MessageService:        services.MessageService{MessageRepository: a.messageRepository, ChannelRepository: a.channelRepository, UserRepository: a.userRepository, ReceiptRepository: &repositories.ReceiptRepository{}, ReactionRepository: &repositories.ReactionRepository{}, NotificationRepository: &repositories.NotificationRepository{}, DraftRepository: a.draftRepository, Moderation: pipeline, HeldMessageRepository: &repositories.HeldMessageRepository{}, BlockRepository: &repositories.BlockRepository{}, Blobs: &blobs.FileStore{Dir: a.Config.AttachmentsDir}, Markdown: &markdown.Cache{}, Events: &services.EventBus{}},
+		BulkService:           services.BulkService{MessageRepository: a.messageRepository, UserRepository: a.userRepository, ChannelRepository: a.channelRepository},
+	}
+	a.Context.BulkService.ReactionRepository = a.Context.MessageService.ReactionRepository
+
+	if err := PopulateChannels(a.channelRepository, a.Config.ChannelsFile); err != nil {
+		return err
+	}
+
+	if err := PopulateMessages(a.messageRepository, a.Config.MessagesFile); err != nil {
+		return err
 	}
+
+	if err := PopulateDrafts(a.draftRepository, a.Config.DraftsFile); err != nil {
+		return err
+	}
+
+	// Unlike the other data, drafts are saved as they change, so scheduled
+	// drafts aren't lost, or published twice, when the server restarts
+	a.draftRepository.OnChange = func(drafts []models.Draft) {
+		if err := writeDrafts(drafts, a.Config.DraftsFile); err != nil {
+			log.Printf("Saving %s failed: %v", a.Config.DraftsFile, err)
+		}
+	}
+
+	return PopulateUsers(a.userRepository, a.Config.UsersFile)
 }
 
+// Writes the data back to the data files. The server itself keeps changes in
+// memory only, except for drafts, this is used by the subcommands modifying
+// data
+func (a *App) SaveData() error {
+	if err := SaveChannels(a.channelRepository, a.Config.ChannelsFile); err != nil {
+		return err
+	}
+
+	if err := SaveMessages(a.messageRepository, a.Config.MessagesFile); err != nil {
+		return err
+	}
+
+	if err := SaveDrafts(a.draftRepository, a.Config.DraftsFile); err != nil {
+		return err
+	}
+
+	return SaveUsers(a.userRepository, a.Config.UsersFile)
+}
+
+// Serves the REST API on Config.Listen and the gRPC API on Config.GRPCListen,
+// purges the trash every Config.PurgeInterval, and publishes scheduled
+// drafts every Config.PublishInterval
 func (a *App) Run() {
-	log.Fatal(http.ListenAndServe(":8080", a.Router))
+	go a.runGRPC()
+	go a.runPurger()
+	go a.runScheduler()
+
+	log.Printf("Listening on %s", a.Config.Listen)
+	log.Fatal(http.ListenAndServe(a.Config.Listen, a.Router))
+}
+
+func (a *App) runGRPC() {
+	listener, err := net.Listen("tcp", a.Config.GRPCListen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("gRPC listening on %s", a.Config.GRPCListen)
+	log.Fatal(grpcapi.NewServer(&a.Context).Serve(listener))
+}
+
+// Removes the messages that have been in the trash for longer than
+// Config.TrashRetention
+func (a *App) runPurger() {
+	ticker := time.NewTicker(time.Duration(a.Config.PurgeInterval))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := a.Context.MessageService.PurgeTrash(time.Duration(a.Config.TrashRetention))
+
+		if purged > 0 {
+			log.Printf("Purged %d messages from the trash", purged)
+		}
+
+		if err != nil {
+			log.Printf("Deleting the attachments of purged messages failed: %v", err)
+		}
+	}
+}
+
+// Publishes the scheduled drafts that are due, starting with those that
+// were due while the server was down
+func (a *App) runScheduler() {
+	ticker := time.NewTicker(time.Duration(a.Config.PublishInterval))
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		if published := a.Context.MessageService.PublishDueDrafts(); published > 0 {
+			log.Printf("Published %d scheduled drafts", published)
+		}
+	}
 }
 
 // This dispatches a request to a Handler as configured in setupRoutes.
@@ -84,13 +275,16 @@ func (a *App) handleRequest(handler func(ctx *context.Context, session *context.
 		username := "unknown"
 
 		if user := handlers.Authenticate(&a.Context, r); user != nil {
-			session := context.Session{CurrentUser: *user}
+			session := context.Session{
+				CurrentUser: *user,
+				Locale:      i18n.Negotiate(user.Locale, r.Header.Get("Accept-Language")),
+			}
 
 			username = user.Username
 
 			handler(&a.Context, &session, w, r, vars)
 		} else {
-			w.WriteHeader(http.StatusUnauthorized)
+			handlers.Unauthenticated(w, r)
 		}
 
 		log.Printf(
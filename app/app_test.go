@@ -0,0 +1,58 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+// Starts the app with its data files in dir
+func startApp(t *testing.T, dir string) *App {
+	a := App{Config: Config{
+		MessagesFile:   filepath.Join(dir, "messages.json"),
+		UsersFile:      filepath.Join(dir, "users.json"),
+		ChannelsFile:   filepath.Join(dir, "channels.json"),
+		DraftsFile:     filepath.Join(dir, "drafts.json"),
+		AttachmentsDir: dir,
+	}}
+
+	if err := a.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &a
+}
+
+func TestDraftsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	dennis := models.User{Username: "dennis"}
+
+	service := &startApp(t, dir).Context.MessageService
+
+	ids := []string{}
+	for _, topic := range []string{"Kept", "Changed", "Deleted", "Published"} {
+		draft, err := service.CreateDraft(models.Draft{Message: models.Message{Topic: topic, Body: "Body"}}, dennis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, draft.ID)
+	}
+
+	if _, err := service.UpdateDraft(models.Draft{Message: models.Message{ID: ids[1], Topic: "Updated", Body: "Body"}}, dennis); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.DeleteDraft(ids[2], dennis); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PublishDraft(ids[3], dennis); err != nil {
+		t.Fatal(err)
+	}
+
+	drafts, total := startApp(t, dir).Context.MessageService.GetDrafts(services.Page{}, dennis)
+
+	if total != 2 || drafts[0].Topic != "Kept" || drafts[1].Topic != "Updated" {
+		t.Errorf("Expected the drafts as they were before the restart, got %+v", drafts)
+	}
+}
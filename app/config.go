@@ -0,0 +1,240 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dennis/hello_go/graphqlapi"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
+)
+
+// Configuration shared by all the subcommands. It is read from a JSON file,
+// where any setting left out keeps its default
+type Config struct {
+	// Address the HTTP server listens on, ie ":8080"
+	Listen string `json:"listen"`
+	// Address the gRPC server listens on, ie ":9090"
+	GRPCListen string `json:"grpc_listen"`
+	// The data files. They are read at startup, and written by the
+	// subcommands modifying data (import, users create...). Drafts that were
+	// due while the server was down are published when it starts
+	MessagesFile string `json:"messages_file"`
+	UsersFile    string `json:"users_file"`
+	ChannelsFile string `json:"channels_file"`
+	DraftsFile   string `json:"drafts_file"`
+	// The directory keeping the content of attachments. It is created when
+	// the first file is attached
+	AttachmentsDir string `json:"attachments_dir"`
+	// Used for validating models, see models.Limits
+	Limits models.Limits `json:"limits"`
+	// Bounds on GraphQL queries, see graphqlapi.Limits
+	GraphQLLimits graphqlapi.Limits `json:"graphql_limits"`
+	// How long deleted messages stay in the trash before they are purged,
+	// and how often the server looks for them, ie "720h"
+	TrashRetention Duration `json:"trash_retention"`
+	PurgeInterval  Duration `json:"purge_interval"`
+	// How often the server looks for scheduled drafts that are due, ie
+	// "10s". Drafts are published up to this late
+	PublishInterval Duration `json:"publish_interval"`
+	// Which messages are rejected or held for review, see moderation.Config.
+	// Nothing is moderated by default
+	Moderation moderation.Config `json:"moderation"`
+}
+
+// A time.Duration written as a string, ie "1h30m", in the config file
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Listen:          ":8080",
+		GRPCListen:      ":9090",
+		MessagesFile:    "messages.json",
+		UsersFile:       "users.json",
+		ChannelsFile:    "channels.json",
+		DraftsFile:      "drafts.json",
+		AttachmentsDir:  "attachments",
+		Limits:          models.DefaultLimits,
+		GraphQLLimits:   graphqlapi.DefaultLimits,
+		TrashRetention:  Duration(30 * 24 * time.Hour),
+		PurgeInterval:   Duration(time.Hour),
+		PublishInterval: Duration(10 * time.Second),
+	}
+}
+
+// Reads the config from path. An empty path gives the DefaultConfig
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	if len(path) == 0 {
+		return config, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return config, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// Fills in defaults for settings that are left empty. This allows App{} to
+// be used without any configuration
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+
+	if len(c.Listen) == 0 {
+		c.Listen = defaults.Listen
+	}
+	if len(c.GRPCListen) == 0 {
+		c.GRPCListen = defaults.GRPCListen
+	}
+	if len(c.MessagesFile) == 0 {
+		c.MessagesFile = defaults.MessagesFile
+	}
+	if len(c.UsersFile) == 0 {
+		c.UsersFile = defaults.UsersFile
+	}
+	if len(c.ChannelsFile) == 0 {
+		c.ChannelsFile = defaults.ChannelsFile
+	}
+	if len(c.DraftsFile) == 0 {
+		c.DraftsFile = defaults.DraftsFile
+	}
+	if len(c.AttachmentsDir) == 0 {
+		c.AttachmentsDir = defaults.AttachmentsDir
+	}
+	if c.Limits == (models.Limits{}) {
+		c.Limits = defaults.Limits
+	}
+	if c.GraphQLLimits == (graphqlapi.Limits{}) {
+		c.GraphQLLimits = defaults.GraphQLLimits
+	}
+	if c.TrashRetention == 0 {
+		c.TrashRetention = defaults.TrashRetention
+	}
+	if c.PurgeInterval == 0 {
+		c.PurgeInterval = defaults.PurgeInterval
+	}
+	if c.PublishInterval == 0 {
+		c.PublishInterval = defaults.PublishInterval
+	}
+
+	return c
+}
+
+// Returns the problems found with the config, if any. Missing data files are
+// fine (we start without data), but their directories must exist, so we can
+// write them
+func (c Config) Check() []string {
+	problems := []string{}
+
+	for name, address := range map[string]string{"listen": c.Listen, "grpc_listen": c.GRPCListen} {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if c.Listen == c.GRPCListen {
+		problems = append(problems, "grpc_listen: must differ from listen")
+	}
+
+	for name, path := range map[string]string{"messages_file": c.MessagesFile, "users_file": c.UsersFile, "channels_file": c.ChannelsFile, "drafts_file": c.DraftsFile} {
+		if len(path) == 0 {
+			problems = append(problems, name+": must be set")
+		} else if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("%s: directory of %s doesn't exist", name, path))
+		} else if info, err := os.Stat(path); err == nil && info.IsDir() {
+			problems = append(problems, fmt.Sprintf("%s: %s is a directory", name, path))
+		}
+	}
+
+	if len(c.AttachmentsDir) == 0 {
+		problems = append(problems, "attachments_dir: must be set")
+	} else if info, err := os.Stat(c.AttachmentsDir); err == nil && !info.IsDir() {
+		problems = append(problems, fmt.Sprintf("attachments_dir: %s isn't a directory", c.AttachmentsDir))
+	} else if info, err := os.Stat(filepath.Dir(c.AttachmentsDir)); err != nil || !info.IsDir() {
+		problems = append(problems, fmt.Sprintf("attachments_dir: directory of %s doesn't exist", c.AttachmentsDir))
+	}
+
+	limits := map[string]int{
+		"limits.topic_max_length":               c.Limits.TopicMaxLength,
+		"limits.body_max_length":                c.Limits.BodyMaxLength,
+		"limits.username_max_length":            c.Limits.UsernameMaxLength,
+		"limits.auth_token_min_length":          c.Limits.AuthTokenMinLength,
+		"limits.channel_name_max_length":        c.Limits.ChannelNameMaxLength,
+		"limits.channel_description_max_length": c.Limits.ChannelDescriptionMaxLength,
+		"limits.tag_max_length":                 c.Limits.TagMaxLength,
+		"limits.max_tags":                       c.Limits.MaxTags,
+		"limits.max_reaction_emoji":             c.Limits.MaxReactionEmoji,
+		"limits.max_reactions_per_user":         c.Limits.MaxReactionsPerUser,
+		"limits.attachment_max_size":            c.Limits.AttachmentMaxSize,
+		"limits.max_attachments":                c.Limits.MaxAttachments,
+	}
+
+	for name, value := range limits {
+		if value < 0 {
+			problems = append(problems, name+": must not be negative")
+		}
+	}
+
+	graphQLLimits := map[string]int{
+		"graphql_limits.max_depth":      c.GraphQLLimits.MaxDepth,
+		"graphql_limits.max_complexity": c.GraphQLLimits.MaxComplexity,
+	}
+
+	for name, value := range graphQLLimits {
+		if value <= 0 {
+			problems = append(problems, name+": must be positive")
+		}
+	}
+
+	problems = append(problems, c.Moderation.Check("moderation")...)
+
+	for name, value := range map[string]Duration{"trash_retention": c.TrashRetention, "purge_interval": c.PurgeInterval, "publish_interval": c.PublishInterval} {
+		if value <= 0 {
+			problems = append(problems, name+": must be positive")
+		}
+	}
+
+	sort.Strings(problems)
+
+	return problems
+}
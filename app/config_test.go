@@ -0,0 +1,70 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/moderation"
+)
+
+func TestLoadConfig_KeepsDefaultsForMissingSettings(t *testing.T) {
+	file, _ := ioutil.TempFile("", "config")
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"listen": ":9090", "limits": {"topic_max_length": 50}, "trash_retention": "48h"}`)
+	file.Close()
+
+	config, err := LoadConfig(file.Name())
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Listen != ":9090" || config.MessagesFile != "messages.json" {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+	if config.Limits.TopicMaxLength != 50 || config.Limits.BodyMaxLength != DefaultConfig().Limits.BodyMaxLength {
+		t.Errorf("Unexpected limits: %+v", config.Limits)
+	}
+	if config.TrashRetention != Duration(48*time.Hour) || config.PurgeInterval != DefaultConfig().PurgeInterval {
+		t.Errorf("Unexpected trash settings: %s, %s", config.TrashRetention, config.PurgeInterval)
+	}
+}
+
+func TestLoadConfig_RejectsUnknownSettings(t *testing.T) {
+	file, _ := ioutil.TempFile("", "config")
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"lisen": ":9090"}`)
+	file.Close()
+
+	if _, err := LoadConfig(file.Name()); err == nil {
+		t.Error("Expected misspelled setting to be rejected")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if problems := DefaultConfig().Check(); len(problems) > 0 {
+		t.Errorf("Expected default config to be valid, got %v", problems)
+	}
+
+	config := DefaultConfig()
+	config.Listen = "8080"
+	config.UsersFile = filepath.Join("does", "not", "exist", "users.json")
+	config.Limits.BodyMaxLength = -1
+	config.PurgeInterval = Duration(-time.Minute)
+	config.Moderation.Rules = []moderation.RuleConfig{{Pattern: "(", Action: moderation.Hold}}
+
+	if problems := config.Check(); len(problems) != 5 {
+		t.Errorf("Expected 5 problems, got %v", problems)
+	}
+
+	config = DefaultConfig()
+	config.GRPCListen = config.Listen
+
+	if problems := config.Check(); len(problems) != 1 {
+		t.Errorf("Expected grpc_listen to conflict with listen, got %v", problems)
+	}
+}
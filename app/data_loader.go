@@ -2,73 +2,186 @@ package app
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/dennis/hello_go/bulk"
 	"github.com/dennis/hello_go/models"
 	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
 )
 
-func PopulateMessages(r *repositories.MessageRepository) {
-	file, err := os.Open("messages.json")
-
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Silently ignore this
-			log.Println("no messages.json found - no data prepopulated")
-			return
-		} else {
-			panic(err)
+// The data files are loaded like any other import, keeping the IDs. Anything
+// wrong with them is reported, as starting with half the data is worse than
+// not starting at all
+var loadOptions = services.ImportOptions{PreserveIDs: true, OnConflict: services.ConflictFail}
+
+// Describes the first problem in a report from loading path
+func loadError(path string, report *services.ImportReport) error {
+	for _, line := range report.Lines {
+		switch line.Status {
+		case services.ImportStatusInvalid:
+			return fmt.Errorf("%s record %d: %v", path, line.Line, &services.NotValidError{Errors: line.Errors})
+		case services.ImportStatusConflict:
+			return fmt.Errorf("%s record %d: duplicate %q", path, line.Line, line.ID)
 		}
-		return
 	}
 
-	log.Println("Loading messages.json")
+	return nil
+}
+
+// Opens path for loading. Returns nil if it doesn't exist, as we can
+// start without data
+func openDataFile(path string) (*os.File, error) {
+	file, err := os.Open(path)
 
+	if os.IsNotExist(err) {
+		log.Printf("no %s found - no data prepopulated", path)
+		return nil, nil
+	}
+
+	if err == nil {
+		log.Printf("Loading %s", path)
+	}
+
+	return file, err
+}
+
+func PopulateMessages(r *repositories.MessageRepository, path string) error {
+	file, err := openDataFile(path)
+	if file == nil {
+		return err
+	}
 	defer file.Close()
 
-	var messages []models.Message
+	records, err := bulk.ReadMessages(file, bulk.JSON)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
 
-	json_err := json.NewDecoder(file).Decode(&messages)
+	// Without the users, which are loaded last, so only the messages
+	// themselves are checked. Their authors may have left a channel since
+	service := services.BulkService{MessageRepository: r}
 
-	if json_err != nil {
-		log.Printf("Error parsing messages.json: %v", json_err)
-		return
+	return loadError(path, service.ImportMessages(records, loadOptions))
+}
+
+// Loads the channels, and creates the default channel if it isn't there
+func PopulateChannels(r *repositories.ChannelRepository, path string) error {
+	if err := populateChannels(r, path); err != nil {
+		return err
 	}
 
-	for _, m := range messages {
-		r.Insert(m)
+	if r.FindByID(models.DefaultChannelID) == nil {
+		r.InsertWithID(models.DefaultChannel)
 	}
+
+	return nil
 }
 
-func PopulateUsers(r *repositories.UserRepository) {
-	file, err := os.Open("users.json")
+func populateChannels(r *repositories.ChannelRepository, path string) error {
+	file, err := openDataFile(path)
+	if file == nil {
+		return err
+	}
+	defer file.Close()
 
+	records, err := bulk.ReadChannels(file, bulk.JSON)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Silently ignore this
-			log.Println("no users.json found - no data prepopulated")
-			return
-		} else {
-			panic(err)
-		}
-		return
+		return fmt.Errorf("parsing %s: %v", path, err)
 	}
 
-	log.Println("Loading users.json")
+	service := services.BulkService{ChannelRepository: r}
 
+	return loadError(path, service.ImportChannels(records, loadOptions))
+}
+
+func PopulateUsers(r *repositories.UserRepository, path string) error {
+	file, err := openDataFile(path)
+	if file == nil {
+		return err
+	}
 	defer file.Close()
 
-	var users []models.User
+	records, err := bulk.ReadUsers(file, bulk.JSON)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
 
-	json_err := json.NewDecoder(file).Decode(&users)
+	service := services.BulkService{UserRepository: r}
 
-	if json_err != nil {
-		log.Printf("Error parsing users.json: %v", json_err)
-		return
+	return loadError(path, service.ImportUsers(records, loadOptions))
+}
+
+// Drafts are only kept in this file, so they are read as they were written,
+// without the checks of an import
+func PopulateDrafts(r *repositories.DraftRepository, path string) error {
+	file, err := openDataFile(path)
+	if file == nil {
+		return err
+	}
+	defer file.Close()
+
+	var drafts []models.Draft
+	if err := json.NewDecoder(file).Decode(&drafts); err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	for _, d := range drafts {
+		r.InsertWithID(d)
+	}
+
+	return nil
+}
+
+// Writes path using write. It is written to a temporary file first, so
+// path is never left half written
+func writeDataFile(path string, write func(f *os.File) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
 	}
 
-	for _, u := range users {
-		r.Insert(u)
+	if err := tmp.Close(); err != nil {
+		return err
 	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func SaveMessages(r *repositories.MessageRepository, path string) error {
+	return writeDataFile(path, func(f *os.File) error {
+		return bulk.WriteMessages(f, bulk.JSON, r.GetAll())
+	})
+}
+
+func SaveChannels(r *repositories.ChannelRepository, path string) error {
+	return writeDataFile(path, func(f *os.File) error {
+		return bulk.WriteChannels(f, bulk.JSON, r.GetAll())
+	})
+}
+
+func SaveUsers(r *repositories.UserRepository, path string) error {
+	return writeDataFile(path, func(f *os.File) error {
+		return bulk.WriteUsers(f, bulk.JSON, r.GetAll())
+	})
+}
+
+func SaveDrafts(r *repositories.DraftRepository, path string) error {
+	return writeDrafts(r.GetAll(), path)
+}
+
+func writeDrafts(drafts []models.Draft, path string) error {
+	return writeDataFile(path, func(f *os.File) error {
+		return json.NewEncoder(f).Encode(drafts)
+	})
 }
@@ -0,0 +1,601 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/dennis/hello_go/blobs"
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
+	"github.com/dennis/hello_go/msgpack"
+	"github.com/dennis/hello_go/openapi"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
+)
+
+type object = map[string]interface{}
+
+func loadSpec(t *testing.T) object {
+	var spec object
+
+	if err := json.Unmarshal([]byte(openapi.Document), &spec); err != nil {
+		t.Fatalf("OpenAPI document isn't valid JSON: %v", err)
+	}
+
+	return spec
+}
+
+// Follows $ref until we reach something that isn't a reference
+func resolve(spec object, node object) object {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+
+		node = spec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node, _ = node[part].(object)
+		}
+	}
+}
+
+// Validates value against a subset of JSON Schema: type, nullable,
+// properties, required, additionalProperties=false, items and enum. It is
+// enough for the schemas we use in the document
+func validateSchema(spec, schema object, value interface{}, path string) []string {
+	schema = resolve(spec, schema)
+	problems := []string{}
+
+	if value == nil && schema["nullable"] == true {
+		return problems
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if e == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v not in enum %v", path, value, enum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		o, ok := value.(object)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected object, got %T", path, value))
+		}
+
+		properties, _ := schema["properties"].(object)
+
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := o[name.(string)]; !ok {
+					problems = append(problems, fmt.Sprintf("%s: missing required property %v", path, name))
+				}
+			}
+		}
+
+		for name, v := range o {
+			if p, ok := properties[name].(object); ok {
+				problems = append(problems, validateSchema(spec, p, v, path+"."+name)...)
+			} else if schema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: undocumented property %v", path, name))
+			}
+		}
+	case "array":
+		a, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected array, got %T", path, value))
+		}
+
+		if items, ok := schema["items"].(object); ok {
+			for i, v := range a {
+				problems = append(problems, validateSchema(spec, items, v, path+"["+strconv.Itoa(i)+"]")...)
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected string, got %T", path, value))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			problems = append(problems, fmt.Sprintf("%s: expected integer, got %v", path, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected number, got %T", path, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected boolean, got %T", path, value))
+		}
+	}
+
+	return problems
+}
+
+func newTestApp() *App {
+	a := App{}
+	a.setupRoutes()
+
+	userRepository := repositories.UserRepository{}
+	userRepository.Insert(models.User{Username: "dennis", AuthToken: "authtokendennis", Admin: true})
+	userRepository.Insert(models.User{Username: "marianne", AuthToken: "authtokenmarianne"})
+
+	// Only dennis is a member of the private channel
+	channelRepository := repositories.ChannelRepository{}
+	channelRepository.InsertWithID(models.DefaultChannel)
+	channelRepository.InsertWithID(models.Channel{ID: "private", Name: "Private", Owner: "dennis", Members: []string{"dennis"}})
+
+	messageRepository := repositories.MessageRepository{}
+	messageRepository.Insert(models.Message{Topic: "Hello", Body: "World", Author: "dennis", ChannelID: models.DefaultChannelID, Tags: []string{"go"}})
+	messageRepository.Insert(models.Message{Topic: "re: Hello", Body: "Really?", Author: "marianne", ChannelID: models.DefaultChannelID})
+	messageRepository.Insert(models.Message{Topic: "Secret", Body: "Psst", Author: "dennis", ChannelID: "private"})
+	messageRepository.Insert(models.Message{Topic: "Direct", Body: "Hi", Author: "dennis", Recipients: []string{"marianne"}})
+
+	a.Context = context.Context{
+		AuthenticationService: services.AuthenticationService{UserRepository: &userRepository},
+		ChannelService:        services.ChannelService{ChannelRepository: &channelRepository, UserRepository: &userRepository},
+		MessageService:        servicestest.NewMessageService(&messageRepository, &channelRepository, &userRepository),
+		BulkService:           services.BulkService{MessageRepository: &messageRepository, UserRepository: &userRepository, ChannelRepository: &channelRepository},
+	}
+
+	// Messages mentioning casinos are held for review
+	a.Context.MessageService.Moderation = moderation.Pipeline{moderation.NewWordList([]string{"casino"}, moderation.Hold)}
+
+	return &a
+}
+
+func TestOpenAPI_EveryRouteIsDocumented(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(object)
+	routed := map[string]bool{}
+
+	newTestApp().Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, _ := route.GetMethods()
+
+		for _, method := range methods {
+			method = strings.ToLower(method)
+			routed[template+" "+method] = true
+
+			if item, ok := paths[template].(object); !ok {
+				t.Errorf("Route %s isn't documented", template)
+			} else if _, ok := item[method]; !ok {
+				t.Errorf("Route %s %s isn't documented", method, template)
+			}
+		}
+
+		return nil
+	})
+
+	for path, item := range paths {
+		for method := range item.(object) {
+			if method != "parameters" && !routed[path+" "+method] {
+				t.Errorf("Documented operation %s %s has no route", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	cases := []struct {
+		method, path, body, token string
+		status                    int
+	}{
+		{"GET", "/api/messages", "", "authtokendennis", 200},
+		{"GET", "/api/messages?limit=1&offset=1", "", "authtokendennis", 200},
+		{"GET", "/api/messages?limit=x", "", "authtokendennis", 400},
+		{"GET", "/api/messages?tag=go&tag=release&tag_match=any", "", "authtokendennis", 200},
+		{"GET", "/api/messages?tag=go&tag_match=some", "", "authtokendennis", 400},
+		{"GET", "/api/messages?author=dennis&q=hello&parent=&created_after=2020-01-01T00:00:00Z&sort=-created_at,id", "", "authtokendennis", 200},
+		{"GET", "/api/messages?sort=color", "", "authtokendennis", 400},
+		{"GET", "/api/messages?color=red", "", "authtokendennis", 400},
+		{"GET", "/api/tags", "", "authtokendennis", 200},
+		{"PUT", "/api/messages/1/reactions/%F0%9F%91%8D", "", "authtokendennis", 200},
+		{"PUT", "/api/messages/1/reactions/%F0%9F%8E%89", "", "authtokenmarianne", 200},
+		{"DELETE", "/api/messages/1/reactions/%F0%9F%8E%89", "", "authtokenmarianne", 200},
+		{"PUT", "/api/messages/1/reactions/ok", "", "authtokendennis", 422},
+		{"PUT", "/api/messages/3/reactions/%F0%9F%91%8D", "", "authtokenmarianne", 403},
+		{"DELETE", "/api/messages/42/reactions/%F0%9F%91%8D", "", "authtokendennis", 404},
+		{"GET", "/api/messages?tag=go", "", "authtokendennis", 200},
+		{"GET", "/api/messages", "", "", 401},
+		{"GET", "/api/messages/1", "", "authtokendennis", 200},
+		{"GET", "/api/messages/42", "", "authtokendennis", 404},
+		{"GET", "/api/messages/3", "", "authtokenmarianne", 403},
+		{"GET", "/api/messages/4", "", "authtokenmarianne", 200},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","recipients":["marianne"]}`, "authtokendennis", 200},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","recipients":["nobody"]}`, "authtokendennis", 422},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","tags":["#Go","release"]}`, "authtokendennis", 200},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","tags":["not ok"]}`, "authtokendennis", 422},
+		{"GET", "/api/inbox", "", "authtokenmarianne", 200},
+		{"GET", "/api/inbox?limit=x", "", "authtokenmarianne", 400},
+		{"GET", "/api/inbox", "", "", 401},
+		{"POST", "/api/messages/2/read", "", "authtokendennis", 200},
+		{"POST", "/api/messages/3/read", "", "authtokenmarianne", 403},
+		{"POST", "/api/messages/42/read", "", "authtokendennis", 404},
+		{"POST", "/api/messages/1/thread/read", "", "authtokenmarianne", 200},
+		{"POST", "/api/messages/3/thread/read", "", "authtokenmarianne", 403},
+		{"POST", "/api/messages/42/thread/read", "", "authtokendennis", 404},
+		{"POST", "/api/messages/read", "", "authtokenmarianne", 200},
+		{"POST", "/api/messages/read", "", "", 401},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","channel_id":"private"}`, "authtokenmarianne", 403},
+		{"POST", "/api/messages", `{"topic":"t","body":"b"}`, "authtokendennis", 200},
+		{"POST", "/api/messages", `{"topic":""}`, "authtokendennis", 422},
+		{"POST", "/api/messages", `not json`, "authtokendennis", 400},
+		{"PUT", "/api/messages/1", `{"topic":"t","body":"b"}`, "authtokendennis", 200},
+		{"PUT", "/api/messages/2", `{"topic":"t","body":"b"}`, "authtokendennis", 401},
+		{"PUT", "/api/messages/42", `{"topic":"t","body":"b"}`, "authtokendennis", 404},
+		{"PUT", "/api/messages/1", `{}`, "authtokendennis", 422},
+		{"PUT", "/api/messages/1", `[`, "authtokendennis", 400},
+		{"DELETE", "/api/messages/2", "", "authtokendennis", 401},
+		{"DELETE", "/api/messages/1", "", "authtokendennis", 200},
+		{"DELETE", "/api/messages/1", "", "authtokendennis", 404},
+		{"GET", "/api/trash", "", "authtokendennis", 200},
+		{"GET", "/api/trash?offset=x", "", "authtokendennis", 400},
+		{"POST", "/api/messages/1/restore", "", "authtokenmarianne", 401},
+		{"POST", "/api/messages/1/restore", "", "authtokendennis", 200},
+		{"POST", "/api/messages/1/restore", "", "authtokendennis", 404},
+		{"GET", "/api/channels", "", "authtokendennis", 200},
+		{"POST", "/api/channels", `{"name":"Go","members":["marianne"]}`, "authtokendennis", 200},
+		{"POST", "/api/channels", `{"name":"","members":["nobody"]}`, "authtokendennis", 422},
+		{"POST", "/api/channels", `[`, "authtokendennis", 400},
+		{"GET", "/api/channels/private", "", "authtokendennis", 200},
+		{"GET", "/api/channels/private", "", "authtokenmarianne", 403},
+		{"GET", "/api/channels/nope", "", "authtokendennis", 404},
+		{"GET", "/api/channels/private/messages", "", "authtokendennis", 200},
+		{"GET", "/api/channels/private/messages?limit=x", "", "authtokendennis", 400},
+		{"GET", "/api/channels/private/messages", "", "authtokenmarianne", 403},
+		{"GET", "/api/channels/nope/messages", "", "authtokendennis", 404},
+		{"POST", "/api/channels/private/messages", `{"topic":"t","body":"b"}`, "authtokendennis", 200},
+		{"POST", "/api/channels/private/messages", `{"topic":"t","body":"b"}`, "authtokenmarianne", 403},
+		{"POST", "/api/channels/private/messages", `{}`, "authtokendennis", 422},
+		{"POST", "/api/channels/nope/messages", `{"topic":"t","body":"b"}`, "authtokendennis", 404},
+		{"PUT", "/api/channels/private/members/marianne", "", "authtokenmarianne", 401},
+		{"PUT", "/api/channels/private/members/nobody", "", "authtokendennis", 404},
+		{"PUT", "/api/channels/private/members/marianne", "", "authtokendennis", 200},
+		{"DELETE", "/api/channels/private/members/dennis", "", "authtokenmarianne", 401},
+		{"DELETE", "/api/channels/private/members/dennis", "", "authtokendennis", 403},
+		{"DELETE", "/api/channels/private/members/marianne", "", "authtokenmarianne", 200},
+		{"DELETE", "/api/channels/nope/members/marianne", "", "authtokendennis", 404},
+		{"GET", "/api/muted", "", "authtokenmarianne", 200},
+		{"PUT", "/api/muted/dennis", "", "authtokenmarianne", 200},
+		{"PUT", "/api/muted/nobody", "", "authtokenmarianne", 404},
+		{"PUT", "/api/muted/marianne", "", "authtokenmarianne", 422},
+		{"DELETE", "/api/muted/dennis", "", "authtokenmarianne", 200},
+		{"PUT", "/api/blocked/dennis", "", "authtokenmarianne", 200},
+		{"GET", "/api/blocked", "", "authtokenmarianne", 200},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","recipients":["marianne"]}`, "authtokendennis", 403},
+		{"POST", "/api/messages", `{"topic":"t","body":"Hi @marianne"}`, "authtokendennis", 422},
+		{"DELETE", "/api/blocked/dennis", "", "authtokenmarianne", 200},
+		{"POST", "/api/admin/messages/import?dry_run=true", `{"topic":"t","body":"b"}` + "\n{}", "authtokendennis", 200},
+		{"POST", "/api/admin/messages/import?format=csv", "id,topic,body\n7,t,b\n", "authtokendennis", 200},
+		{"POST", "/api/admin/messages/import?on_conflict=maybe", "", "authtokendennis", 400},
+		{"POST", "/api/admin/messages/import", "", "authtokenmarianne", 403},
+		{"GET", "/api/admin/messages/export?format=csv", "", "authtokendennis", 200},
+		{"GET", "/api/admin/messages/export?format=xml", "", "authtokendennis", 400},
+		{"GET", "/api/admin/messages/export", "", "authtokenmarianne", 403},
+		{"DELETE", "/api/admin/messages/1", "", "authtokenmarianne", 403},
+		{"DELETE", "/api/admin/messages/1", "", "authtokendennis", 200},
+		{"DELETE", "/api/admin/messages/1", "", "authtokendennis", 404},
+		{"POST", "/api/admin/users/import?format=json", `[{"username":"new"}]`, "authtokendennis", 200},
+		{"POST", "/api/admin/users/import", "", "authtokenmarianne", 403},
+		{"GET", "/api/admin/users/export", "", "authtokendennis", 200},
+		{"GET", "/api/admin/users/export", "", "authtokenmarianne", 403},
+		{"GET", "/api/graphql?query=%7Bmessages%7Bid%20topic%7D%7D", "", "authtokendennis", 200},
+		{"GET", "/api/graphql?query=%7Bmessages%7Bid%7D%7D&variables=%7B", "", "authtokendennis", 400},
+		{"GET", "/api/graphql?query=mutation%7BdeleteMessage(id:%221%22)%7D", "", "authtokendennis", 405},
+		{"POST", "/api/graphql", `{"query":"{ threads { id replyCount author { username } } }"}`, "authtokendennis", 200},
+		{"POST", "/api/graphql", `{"query":"{ unknown }"}`, "authtokendennis", 200},
+		{"POST", "/api/graphql", `{"query":"mutation { createMessage(topic: \"t\", body: \"\") { id } }"}`, "authtokendennis", 200},
+		{"POST", "/api/graphql", `{"query":"subscription { messageCreated { id } }"}`, "authtokendennis", 406},
+		{"POST", "/api/graphql", `{`, "authtokendennis", 400},
+		{"POST", "/api/messages", `{"topic":"t","body":"Hi @marianne"}`, "authtokendennis", 200},
+		{"GET", "/api/notifications", "", "authtokenmarianne", 200},
+		{"GET", "/api/notifications?limit=1&offset=0", "", "authtokenmarianne", 200},
+		{"GET", "/api/notifications?limit=x", "", "authtokenmarianne", 400},
+		{"GET", "/api/notifications", "", "", 401},
+		{"POST", "/api/notifications/1/read", "", "authtokenmarianne", 200},
+		{"POST", "/api/notifications/1/read", "", "authtokendennis", 404},
+		{"POST", "/api/notifications/read", "", "authtokenmarianne", 200},
+		{"POST", "/api/notifications/read", "", "", 401},
+		{"POST", "/api/drafts", `{"topic":"t","body":"b"}`, "authtokendennis", 200},
+		{"POST", "/api/drafts", `{"topic":"t","body":"b","publish_at":"2000-01-01T00:00:00Z"}`, "authtokendennis", 422},
+		{"POST", "/api/drafts", `[`, "authtokendennis", 400},
+		{"POST", "/api/drafts", `{"topic":"t","body":"b"}`, "", 401},
+		{"GET", "/api/drafts", "", "authtokendennis", 200},
+		{"GET", "/api/drafts?limit=x", "", "authtokendennis", 400},
+		{"GET", "/api/drafts/1", "", "authtokendennis", 200},
+		{"GET", "/api/drafts/1", "", "authtokenmarianne", 404},
+		{"PUT", "/api/drafts/1", `{"topic":"t","body":"b","publish_at":"2100-01-01T00:00:00Z"}`, "authtokendennis", 200},
+		{"PUT", "/api/drafts/1", `{"topic":"t","body":"b","publish_at":"2000-01-01T00:00:00Z"}`, "authtokendennis", 422},
+		{"PUT", "/api/drafts/42", `{"topic":"t","body":"b"}`, "authtokendennis", 404},
+		{"POST", "/api/drafts/1/publish", "", "authtokendennis", 200},
+		{"POST", "/api/drafts/1/publish", "", "authtokendennis", 404},
+		{"POST", "/api/drafts", `{"topic":"t","body":"b","channel_id":"private"}`, "authtokenmarianne", 200},
+		{"POST", "/api/drafts/2/publish", "", "authtokenmarianne", 403},
+		{"DELETE", "/api/drafts/2", "", "authtokenmarianne", 200},
+		{"DELETE", "/api/drafts/2", "", "authtokenmarianne", 404},
+		{"PUT", "/api/messages/2/pin", "", "authtokendennis", 200},
+		{"PUT", "/api/messages/2/pin", "", "authtokenmarianne", 403},
+		{"PUT", "/api/messages/42/pin", "", "authtokendennis", 404},
+		{"PUT", "/api/messages/2/pin", "", "", 401},
+		{"DELETE", "/api/messages/2/pin", "", "authtokendennis", 200},
+		{"DELETE", "/api/messages/2/pin", "", "authtokenmarianne", 403},
+		{"PUT", "/api/messages/2/lock", "", "authtokenmarianne", 403},
+		{"PUT", "/api/messages/42/lock", "", "authtokendennis", 404},
+		{"PUT", "/api/messages/2/lock", "", "authtokendennis", 200},
+		{"PUT", "/api/messages/2", `{"topic":"t","body":"b"}`, "authtokenmarianne", 423},
+		{"POST", "/api/messages", `{"topic":"t","body":"b","parent_id":"2"}`, "authtokendennis", 423},
+		{"POST", "/api/channels/general/messages", `{"topic":"t","body":"b","parent_id":"2"}`, "authtokendennis", 423},
+		{"POST", "/api/drafts", `{"topic":"t","body":"b","parent_id":"2"}`, "authtokendennis", 200},
+		{"POST", "/api/drafts/3/publish", "", "authtokendennis", 423},
+		{"DELETE", "/api/messages/2/lock", "", "authtokenmarianne", 403},
+		{"DELETE", "/api/messages/2/lock", "", "authtokendennis", 200},
+		{"PUT", "/api/messages/2", `{"topic":"t","body":"b"}`, "authtokenmarianne", 200},
+		{"POST", "/api/messages", `{"topic":"t","body":"casino"}`, "authtokenmarianne", 202},
+		{"PUT", "/api/messages/2", `{"topic":"t","body":"casino"}`, "authtokenmarianne", 202},
+		{"POST", "/api/channels/general/messages", `{"topic":"t","body":"casino"}`, "authtokenmarianne", 202},
+		{"POST", "/api/drafts", `{"topic":"t","body":"casino"}`, "authtokenmarianne", 200},
+		{"POST", "/api/drafts/4/publish", "", "authtokenmarianne", 202},
+		{"GET", "/api/moderation/queue", "", "authtokendennis", 200},
+		{"GET", "/api/moderation/queue?limit=1&offset=1", "", "authtokendennis", 200},
+		{"GET", "/api/moderation/queue?limit=x", "", "authtokendennis", 400},
+		{"GET", "/api/moderation/queue", "", "authtokenmarianne", 403},
+		{"GET", "/api/moderation/queue", "", "", 401},
+		{"POST", "/api/moderation/queue/1/approve", "", "authtokenmarianne", 403},
+		{"POST", "/api/moderation/queue/1/approve", "", "authtokendennis", 200},
+		{"POST", "/api/moderation/queue/1/approve", "", "authtokendennis", 404},
+		{"PUT", "/api/messages/2/lock", "", "authtokendennis", 200},
+		{"POST", "/api/moderation/queue/2/approve", "", "authtokendennis", 423},
+		{"DELETE", "/api/messages/2/lock", "", "authtokendennis", 200},
+		{"POST", "/api/moderation/queue/2/reject", "", "authtokenmarianne", 403},
+		{"POST", "/api/moderation/queue/2/reject", "", "authtokendennis", 200},
+		{"POST", "/api/moderation/queue/2/reject", "", "authtokendennis", 404},
+		{"GET", "/api/openapi.json", "", "", 200},
+		{"GET", "/api/docs", "", "", 200},
+	}
+
+	spec := loadSpec(t)
+	a := newTestApp()
+
+	for _, c := range cases {
+		var body io.Reader
+		if len(c.body) > 0 {
+			body = strings.NewReader(c.body)
+		}
+
+		r := httptest.NewRequest(c.method, c.path, body)
+		if len(c.token) > 0 {
+			r.SetBasicAuth(c.token, "")
+		}
+
+		checkResponse(t, spec, a, r, c.status)
+	}
+}
+
+// Requests in the other formats we support, see handlers/representation.go
+func TestOpenAPI_NegotiatedResponsesMatchSchemas(t *testing.T) {
+	input, _ := msgpack.Marshal(object{"topic": "t", "body": "b"})
+
+	cases := []struct {
+		method, path, contentType, accept string
+		body                              []byte
+		status                            int
+	}{
+		{"GET", "/api/messages", "", "application/xml", nil, 200},
+		{"GET", "/api/messages", "", "text/csv", nil, 200},
+		{"GET", "/api/messages", "", "application/msgpack", nil, 200},
+		{"GET", "/api/messages", "", "text/html", nil, 406},
+		{"GET", "/api/messages/1", "", "application/msgpack", nil, 200},
+		{"GET", "/api/messages/1", "", "text/csv", nil, 406},
+		{"GET", "/api/channels", "", "application/xml", nil, 200},
+		{"GET", "/api/inbox", "", "application/xml", nil, 200},
+		{"GET", "/api/inbox", "", "application/msgpack", nil, 200},
+		{"GET", "/api/channels/general", "", "application/msgpack", nil, 200},
+		{"GET", "/api/channels/general/messages", "", "text/csv", nil, 200},
+		{"POST", "/api/channels", "application/xml", "", []byte("<channel><name>Go</name></channel>"), 200},
+		{"POST", "/api/messages", "application/xml", "", []byte("<message><topic>t</topic><body>b</body></message>"), 200},
+		{"POST", "/api/messages", "application/msgpack", "application/msgpack", input, 200},
+		{"POST", "/api/messages", "text/plain", "", []byte("t"), 415},
+		{"POST", "/api/messages", "", "text/html", []byte(`{"topic":"t","body":"b"}`), 406},
+		{"PUT", "/api/messages/1", "application/msgpack", "application/xml", input, 200},
+		{"PUT", "/api/messages/1", "text/plain", "", []byte("t"), 415},
+		{"PUT", "/api/messages/1", "", "text/html", []byte(`{"topic":"t","body":"b"}`), 406},
+	}
+
+	spec := loadSpec(t)
+	a := newTestApp()
+
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, bytes.NewReader(c.body))
+		r.SetBasicAuth("authtokendennis", "")
+
+		if len(c.contentType) > 0 {
+			r.Header.Set("Content-Type", c.contentType)
+		}
+		if len(c.accept) > 0 {
+			r.Header.Set("Accept", c.accept)
+		}
+
+		checkResponse(t, spec, a, r, c.status)
+	}
+}
+
+// Returns a multipart/form-data body with content in the file field, and
+// its content type
+func multipartFile(t *testing.T, name, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("Error creating form file: %v", err)
+	}
+
+	part.Write([]byte(content))
+	writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+func TestOpenAPI_AttachmentResponsesMatchSchemas(t *testing.T) {
+	defer func(limits models.Limits) { models.CurrentLimits = limits }(models.CurrentLimits)
+
+	spec := loadSpec(t)
+	a := newTestApp()
+	a.Context.MessageService.Blobs = &blobs.FileStore{Dir: t.TempDir()}
+
+	upload := func(path, token, name, content string, status int) {
+		body, contentType := multipartFile(t, name, content)
+
+		r := httptest.NewRequest("POST", path, body)
+		r.Header.Set("Content-Type", contentType)
+		r.SetBasicAuth(token, "")
+
+		checkResponse(t, spec, a, r, status)
+	}
+
+	upload("/api/messages/1/attachments", "authtokendennis", "hello.txt", "Hello World", 200)
+	upload("/api/messages/3/attachments", "authtokendennis", "secret.txt", "Psst", 200)
+	upload("/api/messages/1/attachments", "authtokenmarianne", "hello.txt", "Hello World", 401)
+	upload("/api/messages/42/attachments", "authtokendennis", "hello.txt", "Hello World", 404)
+
+	// Message 3 is in a locked thread from now on
+	a.Context.MessageService.LockMessage("3", models.User{Username: "dennis", Admin: true})
+	upload("/api/messages/3/attachments", "authtokendennis", "secret.txt", "Psst", 423)
+
+	models.CurrentLimits.AttachmentMaxSize = 4
+	upload("/api/messages/1/attachments", "authtokendennis", "hello.txt", "Hello World", 422)
+
+	cases := []struct {
+		method, path, token, header string
+		status                      int
+	}{
+		{"POST", "/api/messages/1/attachments", "authtokendennis", "", 400},
+		{"GET", "/api/messages/1", "authtokendennis", "", 200},
+		{"GET", "/api/messages/1/attachments/1", "authtokenmarianne", "", 200},
+		{"GET", "/api/messages/1/attachments/1", "authtokenmarianne", "bytes=0-4", 206},
+		{"GET", "/api/messages/1/attachments/1", "authtokenmarianne", "bytes=100-", 416},
+		{"GET", "/api/messages/1/attachments/2", "authtokendennis", "", 404},
+		{"GET", "/api/messages/3/attachments/1", "authtokenmarianne", "", 403},
+		{"DELETE", "/api/messages/1/attachments/1", "authtokenmarianne", "", 401},
+		{"DELETE", "/api/messages/1/attachments/1", "authtokendennis", "", 200},
+		{"DELETE", "/api/messages/1/attachments/1", "authtokendennis", "", 404},
+		{"DELETE", "/api/messages/3/attachments/1", "authtokendennis", "", 423},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		r.SetBasicAuth(c.token, "")
+
+		if len(c.header) > 0 {
+			r.Header.Set("Range", c.header)
+		}
+
+		checkResponse(t, spec, a, r, c.status)
+	}
+}
+
+// Sends r and checks that the response has the expected status, and that
+// its content type and body is documented for the operation. JSON and
+// MessagePack bodies are validated against the schema
+func checkResponse(t *testing.T, spec object, a *App, r *http.Request, status int) {
+	name := fmt.Sprintf("%s %s (Content-Type %q, Accept %q) -> %d", r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"), r.Header.Get("Accept"), status)
+
+	var match mux.RouteMatch
+	if !a.Router.Match(r, &match) || match.Route == nil {
+		t.Errorf("%s: no route", name)
+		return
+	}
+	template, _ := match.Route.GetPathTemplate()
+
+	w := httptest.NewRecorder()
+	a.Router.ServeHTTP(w, r)
+	resp := w.Result()
+
+	if resp.StatusCode != status {
+		t.Errorf("%s: got status %d", name, resp.StatusCode)
+		return
+	}
+
+	operation := spec["paths"].(object)[template].(object)[strings.ToLower(r.Method)].(object)
+	responses := operation["responses"].(object)
+
+	response, ok := responses[strconv.Itoa(resp.StatusCode)].(object)
+	if !ok {
+		t.Errorf("%s: status isn't documented", name)
+		return
+	}
+	response = resolve(spec, response)
+
+	content, _ := response["content"].(object)
+	raw, _ := ioutil.ReadAll(resp.Body)
+
+	if content == nil {
+		if len(raw) > 0 {
+			t.Errorf("%s: undocumented body %s", name, raw)
+		}
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	media, ok := content[mediaType].(object)
+	if !ok {
+		// Downloads may have any content type
+		media, ok = content["*/*"].(object)
+	}
+	if !ok {
+		t.Errorf("%s: undocumented content type %s", name, mediaType)
+		return
+	}
+
+	var value interface{} = string(raw)
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := json.Unmarshal(raw, &value); err != nil {
+			t.Errorf("%s: invalid JSON: %v", name, err)
+			return
+		}
+	case mediaType == "application/msgpack":
+		if err := msgpack.Unmarshal(raw, &value); err != nil {
+			t.Errorf("%s: invalid MessagePack: %v", name, err)
+			return
+		}
+	case mediaType == "application/xml":
+		// Our schema validation only understands the JSON shape
+		if err := xml.Unmarshal(raw, new(interface{})); err != nil {
+			t.Errorf("%s: invalid XML: %v", name, err)
+		}
+		return
+	}
+
+	for _, problem := range validateSchema(spec, media["schema"].(object), value, "$") {
+		t.Errorf("%s: %s", name, problem)
+	}
+}
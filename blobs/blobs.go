@@ -0,0 +1,106 @@
+// Package blobs stores the content of attachments. Blobs are written once
+// and addressed by a key, which is the hex encoded SHA-256 of their content,
+// so identical uploads share a blob.
+package blobs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Returned by Store.Open for keys without a blob
+var ErrNotFound = errors.New("blob not found")
+
+// Keys are hex encoded SHA-256 digests
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Storage for blobs. Implementations must be safe for concurrent use
+type Store interface {
+	// Stores the content read from r under key. If there is a blob with key
+	// already, it is kept and r isn't read
+	Put(key string, r io.Reader) error
+	// Opens the blob with key for reading. Returns ErrNotFound if there is
+	// none
+	Open(key string) (io.ReadSeekCloser, error)
+	// Removes the blob with key. Removing a blob that isn't there does
+	// nothing
+	Delete(key string) error
+}
+
+// Stores blobs as files below Dir, which is created when needed. Blobs are
+// spread over subdirectories named by the first two characters of their key
+type FileStore struct {
+	Dir string
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.Dir, key[:2], key), nil
+}
+
+// Writes to a temporary file first, so a blob is never seen half written
+func (s *FileStore) Put(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FileStore) Open(key string) (io.ReadSeekCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return file, err
+}
+
+func (s *FileStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
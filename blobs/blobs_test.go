@@ -0,0 +1,60 @@
+package blobs
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const key = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+func TestFileStore(t *testing.T) {
+	store := FileStore{Dir: t.TempDir()}
+
+	if _, err := store.Open(key); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Put(key, strings.NewReader("hello world")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// The blob is kept when put again
+	if err := store.Put(key, strings.NewReader("something else")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	blob, err := store.Open(key)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	content, _ := io.ReadAll(blob)
+	blob.Close()
+
+	if string(content) != "hello world" {
+		t.Errorf("Unexpected content %q", content)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Errorf("Expected deleting again to do nothing, got %v", err)
+	}
+
+	if _, err := store.Open(key); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after deleting, got %v", err)
+	}
+}
+
+func TestFileStore_RejectsInvalidKeys(t *testing.T) {
+	store := FileStore{Dir: t.TempDir()}
+
+	for _, invalid := range []string{"", "../../etc/passwd", strings.ToUpper(key)} {
+		if err := store.Put(invalid, strings.NewReader("x")); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
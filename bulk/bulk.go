@@ -0,0 +1,233 @@
+// Package bulk reads and writes messages, users and channels in the formats
+// supported for import and export: a JSON array, JSON Lines and CSV.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type Format string
+
+const (
+	JSON      Format = "json"
+	JSONLines Format = "jsonl"
+	CSV       Format = "csv"
+)
+
+// Parses the name of a format, as given by users
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return JSON, nil
+	case "jsonl", "ndjson":
+		return JSONLines, nil
+	case "csv":
+		return CSV, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, use json, jsonl or csv", name)
+	}
+}
+
+// Guesses the format from the extension of filename. Defaults to JSON, as
+// that is what our data files use
+func FormatFromFilename(filename string) Format {
+	if format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(filename), ".")); err == nil {
+		return format
+	}
+
+	return JSON
+}
+
+// The MIME type to use when serving the format over HTTP
+func (f Format) ContentType() string {
+	switch f {
+	case JSONLines:
+		return "application/x-ndjson"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// A single record read from an import. Line is the line number for JSON
+// Lines and CSV, and the position in the array for JSON. Err is set if the
+// record couldn't be parsed, so the rest of the import can still be checked
+type record struct {
+	Line  int
+	Value interface{}
+	Err   error
+}
+
+// Describes how a kind of value maps to CSV columns
+type codec struct {
+	columns  []string
+	required []string
+	newValue func() interface{}
+	fromCSV  func(fields map[string]string) (interface{}, error)
+	toCSV    func(value interface{}) []string
+}
+
+// JSON Lines may contain long bodies, so allow lines larger than the
+// default of bufio.Scanner
+const maxLineLength = 1024 * 1024
+
+// Reads all records from r. Errors in single records are reported in the
+// record, only errors making the whole input unreadable are returned
+func readRecords(r io.Reader, format Format, c codec) ([]record, error) {
+	records := []record{}
+
+	decode := func(line int, data []byte) record {
+		value := c.newValue()
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(value); err != nil {
+			return record{Line: line, Err: err}
+		}
+
+		return record{Line: line, Value: value}
+	}
+
+	switch format {
+	case JSON:
+		var raw []json.RawMessage
+
+		if err := json.NewDecoder(r).Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		for i, data := range raw {
+			records = append(records, decode(i+1, data))
+		}
+	case JSONLines:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+		line := 0
+
+		for scanner.Scan() {
+			line++
+
+			if data := bytes.TrimSpace(scanner.Bytes()); len(data) > 0 {
+				records = append(records, decode(line, data))
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV header: %v", err)
+		}
+
+		if err := checkHeader(header, c); err != nil {
+			return nil, err
+		}
+
+		// Line numbers are counted in records, which differs from the
+		// lines in the file only for fields containing newlines
+		for line := 2; ; line++ {
+			row, err := reader.Read()
+
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				if _, ok := err.(*csv.ParseError); ok {
+					records = append(records, record{Line: line, Err: err})
+					continue
+				}
+				return nil, err
+			}
+
+			if len(row) != len(header) {
+				records = append(records, record{Line: line, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(row))})
+				continue
+			}
+
+			fields := map[string]string{}
+			for i, name := range header {
+				fields[name] = row[i]
+			}
+
+			value, err := c.fromCSV(fields)
+			records = append(records, record{Line: line, Value: value, Err: err})
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	return records, nil
+}
+
+func checkHeader(header []string, c codec) error {
+	known := map[string]bool{}
+	for _, column := range c.columns {
+		known[column] = true
+	}
+
+	present := map[string]bool{}
+	for _, column := range header {
+		if !known[column] {
+			return fmt.Errorf("unknown CSV column %q, expected %s", column, strings.Join(c.columns, ", "))
+		}
+		present[column] = true
+	}
+
+	for _, column := range c.required {
+		if !present[column] {
+			return fmt.Errorf("missing CSV column %q", column)
+		}
+	}
+
+	return nil
+}
+
+// Writes values in format. JSON Lines and CSV are written a value at a time,
+// so exports don't have to be built in memory first
+func writeRecords(w io.Writer, format Format, values []interface{}, c codec) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(values)
+	case JSONLines:
+		encoder := json.NewEncoder(w)
+
+		for _, value := range values {
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case CSV:
+		writer := csv.NewWriter(w)
+
+		if err := writer.Write(c.columns); err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			if err := writer.Write(c.toCSV(value)); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
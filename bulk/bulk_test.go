@@ -0,0 +1,114 @@
+package bulk
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+var messages = []models.Message{
+	{ID: "1", Topic: "Hello, World", Body: "Line 1\nLine 2", Author: "Dennis"},
+	{ID: "2", Topic: "re: Hello", Body: `"Really?"`, Author: "Marianne"},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{JSON, JSONLines, CSV} {
+		var b bytes.Buffer
+
+		if err := WriteMessages(&b, format, messages); err != nil {
+			t.Fatalf("%s: error writing: %v", format, err)
+		}
+
+		records, err := ReadMessages(&b, format)
+		if err != nil {
+			t.Fatalf("%s: error reading: %v", format, err)
+		}
+
+		if len(records) != 2 || !reflect.DeepEqual(records[0].Message, messages[0]) || !reflect.DeepEqual(records[1].Message, messages[1]) {
+			t.Errorf("%s: unexpected records %v", format, records)
+		}
+	}
+}
+
+func TestJSONLinesReportsBadLines(t *testing.T) {
+	input := `{"topic":"a","body":"b"}
+
+not json
+{"topic":"a","unknown":"field"}
+`
+
+	records, err := ReadMessages(strings.NewReader(input), JSONLines)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %v", records)
+	}
+	if records[0].Err != nil || records[1].Line != 3 || records[1].Err == nil || records[2].Err == nil {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	if _, err := ReadMessages(strings.NewReader("topic,colour\n"), CSV); err == nil {
+		t.Error("Expected unknown column to be rejected")
+	}
+
+	if _, err := ReadMessages(strings.NewReader("id,topic\n"), CSV); err == nil {
+		t.Error("Expected missing body column to be rejected")
+	}
+
+	records, err := ReadMessages(strings.NewReader("body,topic\nb,t\nonly one field\n"), CSV)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Unexpected result: %v, %v", records, err)
+	}
+	if records[0].Message.Topic != "t" || records[0].Message.Body != "b" {
+		t.Errorf("Columns mapped incorrectly: %v", records[0].Message)
+	}
+	if records[1].Line != 3 || records[1].Err == nil {
+		t.Errorf("Expected error for line 3, got %+v", records[1])
+	}
+}
+
+func TestUsersCSV(t *testing.T) {
+	records, err := ReadUsers(strings.NewReader("username,admin,moderator\ndennis,true,\nmarianne,maybe,\nbob,,true\n"), CSV)
+
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Unexpected result: %v, %v", records, err)
+	}
+	if !records[0].User.Admin || records[1].Err == nil || !records[2].User.Moderator || records[2].User.Admin {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+}
+
+func TestChannelsCSV(t *testing.T) {
+	records, err := ReadChannels(strings.NewReader("id,name,open,members\n1,Go,false,dennis marianne\n"), CSV)
+
+	if err != nil || len(records) != 1 || records[0].Err != nil {
+		t.Fatalf("Unexpected result: %v, %v", records, err)
+	}
+	if c := records[0].Channel; c.Name != "Go" || len(c.Members) != 2 || c.Members[1] != "marianne" {
+		t.Errorf("Unexpected channel: %+v", c)
+	}
+
+	var out strings.Builder
+	WriteChannels(&out, CSV, []models.Channel{records[0].Channel})
+
+	if !strings.HasSuffix(out.String(), "1,Go,,,false,dennis marianne\n") {
+		t.Errorf("Unexpected CSV: %q", out.String())
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	cases := map[string]Format{"a.csv": CSV, "a.jsonl": JSONLines, "a.ndjson": JSONLines, "a.json": JSON, "": JSON}
+
+	for name, expected := range cases {
+		if actual := FormatFromFilename(name); actual != expected {
+			t.Errorf("%q: expected %s, got %s", name, expected, actual)
+		}
+	}
+}
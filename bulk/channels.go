@@ -0,0 +1,77 @@
+package bulk
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// A channel read from an import. See record
+type ChannelRecord struct {
+	Line    int
+	Channel models.Channel
+	Err     error
+}
+
+// Members are a single CSV column, separated by spaces. Usernames can't
+// contain spaces
+var channelCodec = codec{
+	columns:  []string{"id", "name", "description", "owner", "open", "members"},
+	required: []string{"id", "name"},
+	newValue: func() interface{} { return &models.Channel{} },
+	fromCSV: func(fields map[string]string) (interface{}, error) {
+		channel := &models.Channel{
+			ID:          fields["id"],
+			Name:        fields["name"],
+			Description: fields["description"],
+			Owner:       fields["owner"],
+			Members:     strings.Fields(fields["members"]),
+		}
+
+		if open := fields["open"]; len(open) > 0 {
+			b, err := strconv.ParseBool(open)
+			if err != nil {
+				return nil, fmt.Errorf("open must be true or false, got %q", open)
+			}
+			channel.Open = b
+		}
+
+		return channel, nil
+	},
+	toCSV: func(value interface{}) []string {
+		c := value.(models.Channel)
+		return []string{c.ID, c.Name, c.Description, c.Owner, strconv.FormatBool(c.Open), strings.Join(c.Members, " ")}
+	},
+}
+
+func ReadChannels(r io.Reader, format Format) ([]ChannelRecord, error) {
+	records, err := readRecords(r, format, channelCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]ChannelRecord, 0, len(records))
+
+	for _, rec := range records {
+		c := ChannelRecord{Line: rec.Line, Err: rec.Err}
+		if rec.Value != nil {
+			c.Channel = *rec.Value.(*models.Channel)
+		}
+		channels = append(channels, c)
+	}
+
+	return channels, nil
+}
+
+func WriteChannels(w io.Writer, format Format, channels []models.Channel) error {
+	values := make([]interface{}, 0, len(channels))
+
+	for _, c := range channels {
+		values = append(values, c)
+	}
+
+	return writeRecords(w, format, values, channelCodec)
+}
@@ -0,0 +1,106 @@
+package bulk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// A message read from an import. See record
+type MessageRecord struct {
+	Line    int
+	Message models.Message
+	Err     error
+}
+
+var messageCodec = codec{
+	columns:  []string{"id", "topic", "body", "author", "parent_id", "channel_id", "recipients", "tags", "created_at", "updated_at", "deleted_at"},
+	required: []string{"topic", "body"},
+	newValue: func() interface{} { return &models.Message{} },
+	fromCSV: func(fields map[string]string) (interface{}, error) {
+		// Usernames and tags separated by spaces, like the members of
+		// channels. Left nil when empty, like in JSON
+		var recipients []string
+		if r := strings.Fields(fields["recipients"]); len(r) > 0 {
+			recipients = r
+		}
+
+		var tags []string
+		if t := strings.Fields(fields["tags"]); len(t) > 0 {
+			tags = t
+		}
+
+		// RFC 3339, like in JSON. Empty when unknown, and for deleted_at
+		// unless the message is in the trash
+		times := map[string]*time.Time{}
+		for _, name := range []string{"created_at", "updated_at", "deleted_at"} {
+			if raw := fields[name]; len(raw) > 0 {
+				t, err := time.Parse(time.RFC3339, raw)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", name, err)
+				}
+				times[name] = &t
+			}
+		}
+
+		return &models.Message{
+			ID:         fields["id"],
+			Topic:      fields["topic"],
+			Body:       fields["body"],
+			Author:     fields["author"],
+			ParentID:   fields["parent_id"],
+			ChannelID:  fields["channel_id"],
+			Recipients: recipients,
+			Tags:       tags,
+			CreatedAt:  times["created_at"],
+			UpdatedAt:  times["updated_at"],
+			DeletedAt:  times["deleted_at"],
+		}, nil
+	},
+	toCSV: func(value interface{}) []string {
+		m := value.(models.Message)
+		return []string{m.ID, m.Topic, m.Body, m.Author, m.ParentID, m.ChannelID, strings.Join(m.Recipients, " "), strings.Join(m.Tags, " "),
+			formatTime(m.CreatedAt), formatTime(m.UpdatedAt), formatTime(m.DeletedAt)}
+	},
+}
+
+// Times are written like in JSON, and left empty when unknown
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+func ReadMessages(r io.Reader, format Format) ([]MessageRecord, error) {
+	records, err := readRecords(r, format, messageCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]MessageRecord, 0, len(records))
+
+	for _, rec := range records {
+		m := MessageRecord{Line: rec.Line, Err: rec.Err}
+		if rec.Value != nil {
+			m.Message = *rec.Value.(*models.Message)
+		}
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
+
+func WriteMessages(w io.Writer, format Format, messages []models.Message) error {
+	values := make([]interface{}, 0, len(messages))
+
+	for _, m := range messages {
+		values = append(values, m)
+	}
+
+	return writeRecords(w, format, values, messageCodec)
+}
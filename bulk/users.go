@@ -0,0 +1,74 @@
+package bulk
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// A user read from an import. See record
+type UserRecord struct {
+	Line int
+	User models.User
+	Err  error
+}
+
+var userCodec = codec{
+	columns:  []string{"username", "auth_token", "locale", "admin", "moderator"},
+	required: []string{"username"},
+	newValue: func() interface{} { return &models.User{} },
+	fromCSV: func(fields map[string]string) (interface{}, error) {
+		user := &models.User{
+			Username:  fields["username"],
+			AuthToken: fields["auth_token"],
+			Locale:    fields["locale"],
+		}
+
+		for name, value := range map[string]*bool{"admin": &user.Admin, "moderator": &user.Moderator} {
+			if raw := fields[name]; len(raw) > 0 {
+				b, err := strconv.ParseBool(raw)
+				if err != nil {
+					return nil, fmt.Errorf("%s must be true or false, got %q", name, raw)
+				}
+				*value = b
+			}
+		}
+
+		return user, nil
+	},
+	toCSV: func(value interface{}) []string {
+		u := value.(models.User)
+		return []string{u.Username, u.AuthToken, u.Locale, strconv.FormatBool(u.Admin), strconv.FormatBool(u.Moderator)}
+	},
+}
+
+func ReadUsers(r io.Reader, format Format) ([]UserRecord, error) {
+	records, err := readRecords(r, format, userCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]UserRecord, 0, len(records))
+
+	for _, rec := range records {
+		u := UserRecord{Line: rec.Line, Err: rec.Err}
+		if rec.Value != nil {
+			u.User = *rec.Value.(*models.User)
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+func WriteUsers(w io.Writer, format Format, users []models.User) error {
+	values := make([]interface{}, 0, len(users))
+
+	for _, u := range users {
+		values = append(values, u)
+	}
+
+	return writeRecords(w, format, values, userCodec)
+}
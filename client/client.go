@@ -0,0 +1,217 @@
+// Package client is a Go client for the messages API. It takes care of
+// authentication, decoding problem responses into typed errors and retrying
+// requests that failed for transient reasons.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+type Client struct {
+	// ie "http://localhost:8080"
+	BaseURL string
+	// Auth token of the user the requests are made on behalf of
+	Token string
+	// Used for making requests. Defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// How many times a failed idempotent request is retried
+	MaxRetries int
+	// Delay before the first retry. It is doubled for each following retry
+	Backoff time.Duration
+}
+
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+		MaxRetries: 3,
+		Backoff:    100 * time.Millisecond,
+	}
+}
+
+// Selects a page of messages. A Limit of 0 means no limit
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+func (o ListOptions) query() url.Values {
+	values := url.Values{}
+
+	if o.Limit > 0 {
+		values.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		values.Set("offset", strconv.Itoa(o.Offset))
+	}
+
+	return values
+}
+
+// Only requests that can safely be repeated are retried
+func idempotent(method string) bool {
+	return method == "GET" || method == "PUT" || method == "DELETE"
+}
+
+// Tells if a response is worth retrying. Everything else is the callers
+// fault, and retrying won't change it
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// Sends a request, retrying it if allowed, and decodes the response into
+// out (if not nil). Responses with a status other than 2xx are returned as
+// errors, see errors.go
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) (*http.Response, error) {
+	var payload []byte
+
+	if in != nil {
+		var err error
+
+		if payload, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	attempts := 1
+	if idempotent(method) {
+		attempts += c.MaxRetries
+	}
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Backoff << uint(attempt-1)):
+			}
+		}
+
+		var body io.Reader
+		if payload != nil {
+			body = bytes.NewReader(payload)
+		}
+
+		req, reqErr := http.NewRequest(method, target, body)
+		if reqErr != nil {
+			return nil, reqErr
+		}
+
+		req = req.WithContext(ctx)
+		req.SetBasicAuth(c.Token, "")
+		req.Header.Set("Accept", "application/json")
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err = httpClient.Do(req)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		if !retryable(resp.StatusCode) || attempt == attempts-1 {
+			break
+		}
+
+		resp.Body.Close()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	// Messages held for review are accepted with a problem instead of the
+	// message
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		return resp, decodeError(resp)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("decoding response: %v", err)
+		}
+	} else {
+		io.Copy(ioutil.Discard, resp.Body)
+	}
+
+	return resp, nil
+}
+
+// Returns a single page of messages
+func (c *Client) ListMessages(ctx context.Context, opts ListOptions) ([]models.Message, error) {
+	var messages []models.Message
+
+	_, err := c.do(ctx, "GET", "/api/messages", opts.query(), nil, &messages)
+
+	return messages, err
+}
+
+func (c *Client) GetMessage(ctx context.Context, id string) (*models.Message, error) {
+	var message models.Message
+
+	if _, err := c.do(ctx, "GET", "/api/messages/"+url.PathEscape(id), nil, nil, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// Creates a message authored by the user owning the token. Returns the
+// message as stored, with its ID assigned
+func (c *Client) CreateMessage(ctx context.Context, message models.Message) (*models.Message, error) {
+	var stored models.Message
+
+	if _, err := c.do(ctx, "POST", "/api/messages", nil, message, &stored); err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func (c *Client) UpdateMessage(ctx context.Context, message models.Message) (*models.Message, error) {
+	var stored models.Message
+
+	if _, err := c.do(ctx, "PUT", "/api/messages/"+url.PathEscape(message.ID), nil, message, &stored); err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, id string) error {
+	_, err := c.do(ctx, "DELETE", "/api/messages/"+url.PathEscape(id), nil, nil, nil)
+
+	return err
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/app"
+	appcontext "github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
+)
+
+// Starts the real application with two users and no messages
+func setupServer(t *testing.T) *httptest.Server {
+	a := app.App{}
+	if err := a.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := repositories.UserRepository{}
+	userRepository.Insert(models.User{Username: "dennis", AuthToken: "authtokendennis"})
+	userRepository.Insert(models.User{Username: "marianne", AuthToken: "authtokenmarianne"})
+
+	channelRepository := repositories.ChannelRepository{}
+	channelRepository.InsertWithID(models.DefaultChannel)
+
+	a.Context = appcontext.Context{
+		AuthenticationService: services.AuthenticationService{UserRepository: &userRepository},
+		MessageService:        servicestest.NewMessageService(nil, &channelRepository, &userRepository),
+	}
+	a.Context.MessageService.Moderation = moderation.Pipeline{moderation.NewWordList([]string{"spam"}, moderation.Reject), moderation.NewWordList([]string{"casino"}, moderation.Hold)}
+
+	server := httptest.NewServer(a.Router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCRUD(t *testing.T) {
+	server := setupServer(t)
+	c := New(server.URL, "authtokendennis")
+	ctx := context.Background()
+
+	created, err := c.CreateMessage(ctx, models.Message{Topic: "Hello", Body: "World"})
+	if err != nil {
+		t.Fatalf("CreateMessage failed: %v", err)
+	}
+	if created.ID == "" || created.Author != "dennis" {
+		t.Errorf("Unexpected message: %v", created)
+	}
+
+	created.Body = "Changed"
+	if updated, err := c.UpdateMessage(ctx, *created); err != nil || updated.Body != "Changed" {
+		t.Errorf("UpdateMessage failed: %v, %v", updated, err)
+	}
+
+	if fetched, err := c.GetMessage(ctx, created.ID); err != nil || fetched.Body != "Changed" {
+		t.Errorf("GetMessage failed: %v, %v", fetched, err)
+	}
+
+	if err := c.DeleteMessage(ctx, created.ID); err != nil {
+		t.Errorf("DeleteMessage failed: %v", err)
+	}
+
+	if _, err := c.GetMessage(ctx, created.ID); err == nil {
+		t.Error("Expected deleted message to be gone")
+	} else if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("Expected NotFoundError, got %T: %v", err, err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	server := setupServer(t)
+	ctx := context.Background()
+
+	dennis := New(server.URL, "authtokendennis")
+	marianne := New(server.URL, "authtokenmarianne")
+
+	created, _ := dennis.CreateMessage(ctx, models.Message{Topic: "Hello", Body: "World"})
+
+	if err := marianne.DeleteMessage(ctx, created.ID); err == nil {
+		t.Error("Expected error deleting another users message")
+	} else if _, ok := err.(*NotOwnerError); !ok {
+		t.Errorf("Expected NotOwnerError, got %T: %v", err, err)
+	}
+
+	_, err := dennis.CreateMessage(ctx, models.Message{Topic: "No body"})
+	if notValid, ok := err.(*NotValidError); !ok {
+		t.Errorf("Expected NotValidError, got %T: %v", err, err)
+	} else if len(notValid.Errors) != 1 || notValid.Errors[0].Field != "body" {
+		t.Errorf("Unexpected validation errors: %v", notValid.Errors)
+	}
+
+	_, err = dennis.CreateMessage(ctx, models.Message{Topic: "Offer", Body: "Spam"})
+	if rejected, ok := err.(*RejectedError); !ok {
+		t.Errorf("Expected RejectedError, got %T: %v", err, err)
+	} else if rejected.Detail != `Contains the word "Spam"` {
+		t.Errorf("Expected the reason, got %v", rejected)
+	}
+
+	if _, err := dennis.CreateMessage(ctx, models.Message{Topic: "Offer", Body: "Casino"}); err == nil {
+		t.Error("Expected error for a held message")
+	} else if _, ok := err.(*HeldError); !ok {
+		t.Errorf("Expected HeldError, got %T: %v", err, err)
+	}
+
+	_, err = New(server.URL, "badtoken").ListMessages(ctx, ListOptions{})
+	if _, ok := err.(*UnauthenticatedError); !ok {
+		t.Errorf("Expected UnauthenticatedError, got %T: %v", err, err)
+	}
+}
+
+func TestMessageIterator(t *testing.T) {
+	server := setupServer(t)
+	c := New(server.URL, "authtokendennis")
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		c.CreateMessage(ctx, models.Message{Topic: "Topic " + strconv.Itoa(i), Body: "Body"})
+	}
+
+	it := c.Messages(2)
+	topics := []string{}
+
+	for it.Next(ctx) {
+		topics = append(topics, it.Message().Topic)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(topics) != 5 || topics[0] != "Topic 0" || topics[4] != "Topic 4" {
+		t.Errorf("Unexpected topics: %v", topics)
+	}
+}
+
+// A server failing the first failures requests with 503
+func flakyServer(t *testing.T, failures int32, calls *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","topic":"t","body":"b","author":"dennis"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	c := New(flakyServer(t, 2, &calls).URL, "authtokendennis")
+	c.Backoff = time.Millisecond
+
+	if _, err := c.GetMessage(context.Background(), "1"); err != nil {
+		t.Errorf("Expected request to succeed after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDoesNotRetryCreate(t *testing.T) {
+	var calls int32
+	c := New(flakyServer(t, 1, &calls).URL, "authtokendennis")
+	c.Backoff = time.Millisecond
+
+	_, err := c.CreateMessage(context.Background(), models.Message{Topic: "t", Body: "b"})
+
+	if e, ok := err.(*Error); !ok || e.Status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 error, got %T: %v", err, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	c := New(server.URL, "authtokendennis")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.GetMessage(ctx, "1"); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+// The problem+json body the API responds with on errors
+type Problem struct {
+	Type     string             `json:"type"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"`
+	Detail   string             `json:"detail"`
+	Instance string             `json:"instance"`
+	Errors   []validation.Error `json:"errors,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if len(p.Detail) > 0 {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// The message doesn't exist. Mirrors services.NotFoundError
+type NotFoundError struct{ Problem }
+
+// The message belongs to another user. Mirrors services.NotOwnerError
+type NotOwnerError struct{ Problem }
+
+// The message was rejected. Mirrors services.NotValidError, Errors contains
+// the problem with each field
+type NotValidError struct{ Problem }
+
+// The user isn't an admin
+type ForbiddenError struct{ Problem }
+
+// The message is in a thread locked by a moderator. Mirrors
+// services.LockedError
+type LockedError struct{ Problem }
+
+// Someone the message would reach has blocked the user. Mirrors
+// services.BlockedError
+type BlockedError struct{ Problem }
+
+// The message was rejected by moderation. Detail tells why. Mirrors
+// services.RejectedError
+type RejectedError struct{ Problem }
+
+// The message was held for review by a moderator, and is published once
+// approved. Mirrors services.HeldError
+type HeldError struct{ Problem }
+
+// The token wasn't accepted
+type UnauthenticatedError struct{ Problem }
+
+// Any other error response. Check Status and Type for details
+type Error struct{ Problem }
+
+// Turns an error response into one of the error types above. We look at
+// the problem type rather than the status, as 401 is used for both
+// unauthenticated requests and for modifying other users messages
+func decodeError(resp *http.Response) error {
+	problem := Problem{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+
+	// Responses without a problem body (ie from a proxy) are still reported
+	// with their status
+	json.NewDecoder(resp.Body).Decode(&problem)
+
+	switch problem.Type {
+	case "/problems/not-found":
+		return &NotFoundError{problem}
+	case "/problems/not-owner":
+		return &NotOwnerError{problem}
+	case "/problems/not-valid":
+		return &NotValidError{problem}
+	case "/problems/forbidden":
+		return &ForbiddenError{problem}
+	case "/problems/locked":
+		return &LockedError{problem}
+	case "/problems/blocked":
+		return &BlockedError{problem}
+	case "/problems/rejected":
+		return &RejectedError{problem}
+	case "/problems/held":
+		return &HeldError{problem}
+	case "/problems/unauthenticated":
+		return &UnauthenticatedError{problem}
+	default:
+		return &Error{problem}
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Iterates over all messages, fetching them a page at a time:
+//
+//	it := c.Messages(50)
+//	for it.Next(ctx) {
+//	  fmt.Println(it.Message().Topic)
+//	}
+//	if err := it.Err(); err != nil {
+//	  ...
+//	}
+type MessageIterator struct {
+	client   *Client
+	pageSize int
+	offset   int
+	page     []models.Message
+	index    int
+	done     bool
+	err      error
+}
+
+// Returns an iterator fetching pageSize messages per request
+func (c *Client) Messages(pageSize int) *MessageIterator {
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	return &MessageIterator{client: c, pageSize: pageSize, index: -1}
+}
+
+// Advances to the next message, fetching the next page when needed. Returns
+// false when there are no more messages or an error occurred
+func (it *MessageIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+
+	if it.done {
+		return false
+	}
+
+	page, err := it.client.ListMessages(ctx, ListOptions{Limit: it.pageSize, Offset: it.offset})
+
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = page
+	it.index = 0
+	it.offset += len(page)
+	it.done = len(page) < it.pageSize
+
+	return len(page) > 0
+}
+
+// The current message. Only valid after Next returned true
+func (it *MessageIterator) Message() models.Message {
+	return it.page[it.index]
+}
+
+// The error that stopped the iteration, if any
+func (it *MessageIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dennis/hello_go/client"
+	"github.com/dennis/hello_go/models"
+)
+
+// Returns a client for the server and user in the saved config
+func loggedInClient(configPath string) (*client.Client, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.URL) == 0 || len(config.Token) == 0 {
+		return nil, errNotLoggedIn
+	}
+
+	return client.New(config.URL, config.Token), nil
+}
+
+// Parses flags and requires exactly one positional argument, the message ID
+func parseWithID(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+
+	if flags.NArg() != 1 {
+		return "", errors.New("expected a message ID")
+	}
+
+	return flags.Arg(0), nil
+}
+
+func runLogin(configPath string, args []string) error {
+	flags := flag.NewFlagSet("login", flag.ContinueOnError)
+	url := flags.String("url", "http://localhost:8080", "URL of the server")
+	token := flags.String("token", "", "auth token. Prompted for if not given")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(*token) == 0 {
+		fmt.Fprint(os.Stderr, "Auth token: ")
+
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && len(line) == 0 {
+			return err
+		}
+
+		*token = strings.TrimSpace(line)
+	}
+
+	// Make sure the token works before saving it
+	c := client.New(*url, *token)
+	if _, err := c.ListMessages(context.Background(), client.ListOptions{Limit: 1}); err != nil {
+		return err
+	}
+
+	if err := saveConfig(configPath, &Config{URL: c.BaseURL, Token: *token}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Logged in to %s\n", c.BaseURL)
+
+	return nil
+}
+
+func runList(configPath string, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table, json or yaml")
+	limit := flags.Int("limit", 0, "maximum number of messages. 0 lists all")
+	offset := flags.Int("offset", 0, "number of messages to skip")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := loggedInClient(configPath)
+	if err != nil {
+		return err
+	}
+
+	messages, err := c.ListMessages(context.Background(), client.ListOptions{Limit: *limit, Offset: *offset})
+	if err != nil {
+		return err
+	}
+
+	return printMessages(os.Stdout, *format, messages)
+}
+
+func runShow(configPath string, args []string) error {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table, json or yaml")
+
+	id, err := parseWithID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := loggedInClient(configPath)
+	if err != nil {
+		return err
+	}
+
+	message, err := c.GetMessage(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	return printMessage(os.Stdout, *format, message)
+}
+
+func runPost(configPath string, args []string) error {
+	flags := flag.NewFlagSet("post", flag.ContinueOnError)
+	topic := flags.String("topic", "", "topic of the message")
+	body := flags.String("body", "", "body of the message. Read from stdin or $EDITOR if not given")
+	useEditor := flags.Bool("e", false, "open $EDITOR even if stdin isn't a terminal")
+	format := flags.String("o", "table", "output format: table, json or yaml")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	c, err := loggedInClient(configPath)
+	if err != nil {
+		return err
+	}
+
+	text, err := readBody(*body, *useEditor, "")
+	if err != nil {
+		return err
+	}
+
+	message, err := c.CreateMessage(context.Background(), models.Message{Topic: *topic, Body: text})
+	if err != nil {
+		return err
+	}
+
+	return printMessage(os.Stdout, *format, message)
+}
+
+// Without -body, the current body is opened in $EDITOR (or read from stdin)
+func runEdit(configPath string, args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ContinueOnError)
+	topic := flags.String("topic", "", "new topic. Keeps the current if not given")
+	body := flags.String("body", "", "new body. Read from stdin or $EDITOR if not given")
+	useEditor := flags.Bool("e", false, "open $EDITOR even if stdin isn't a terminal")
+	format := flags.String("o", "table", "output format: table, json or yaml")
+
+	id, err := parseWithID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := loggedInClient(configPath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	message, err := c.GetMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if len(*topic) > 0 {
+		message.Topic = *topic
+	}
+
+	if message.Body, err = readBody(*body, *useEditor, message.Body); err != nil {
+		return err
+	}
+
+	if message, err = c.UpdateMessage(ctx, *message); err != nil {
+		return err
+	}
+
+	return printMessage(os.Stdout, *format, message)
+}
+
+func runDelete(configPath string, args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+
+	id, err := parseWithID(flags, args)
+	if err != nil {
+		return err
+	}
+
+	c, err := loggedInClient(configPath)
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteMessage(context.Background(), id)
+}
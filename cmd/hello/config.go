@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Stored in the users config directory by `hello login`
+type Config struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+var errNotLoggedIn = errors.New("not logged in - run `hello login` first")
+
+// $HELLO_CONFIG overrides the default location, which is handy for tests and
+// for switching between servers
+func configPath() (string, error) {
+	if path := os.Getenv("HELLO_CONFIG"); len(path) > 0 {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hello", "config.json"), nil
+}
+
+// Returns an empty Config if none has been saved yet
+func loadConfig(path string) (*Config, error) {
+	config := Config{}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// The file contains a token, so only the user may read it
+func saveConfig(path string, config *Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "hello")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "config.json")
+
+	if config, err := loadConfig(path); err != nil || *config != (Config{}) {
+		t.Errorf("Expected empty config before saving, got %v, %v", config, err)
+	}
+
+	if err := saveConfig(path, &Config{URL: "http://localhost:8080", Token: "secret"}); err != nil {
+		t.Fatalf("Error saving config: %v", err)
+	}
+
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected config to be private, got %v", info.Mode())
+	}
+
+	config, err := loadConfig(path)
+	if err != nil || config.Token != "secret" || config.URL != "http://localhost:8080" {
+		t.Errorf("Unexpected config %v, %v", config, err)
+	}
+}
+
+func TestNotLoggedIn(t *testing.T) {
+	if _, err := loggedInClient(filepath.Join(os.TempDir(), "does-not-exist.json")); err != errNotLoggedIn {
+		t.Errorf("Expected errNotLoggedIn, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Reads a message body. An explicit body wins, then stdin if something is
+// piped into us, otherwise $EDITOR is opened with initial as its content
+func readBody(body string, forceEditor bool, initial string) (string, error) {
+	if len(body) > 0 {
+		return body, nil
+	}
+
+	if !forceEditor && !isTerminal(os.Stdin) {
+		data, err := ioutil.ReadAll(os.Stdin)
+		return strings.TrimRight(string(data), "\n"), err
+	}
+
+	return editText(initial)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func editor() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(name); len(e) > 0 {
+			return e
+		}
+	}
+
+	return "vi"
+}
+
+// Opens the users editor on a temporary file containing initial, and returns
+// what it contains when the editor exits
+func editText(initial string) (string, error) {
+	file, err := ioutil.TempFile("", "hello-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(initial); err != nil {
+		file.Close()
+		return "", err
+	}
+	file.Close()
+
+	// $EDITOR may contain arguments, ie "code --wait"
+	args := append(strings.Fields(editor()), file.Name())
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running editor: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(file.Name())
+
+	return strings.TrimRight(string(data), "\n"), err
+}
@@ -0,0 +1,71 @@
+// Command hello is a command line client for the messages API.
+//
+//	hello login -url http://localhost:8080 -token authtokendennis
+//	hello list
+//	hello show 1
+//	echo "Lorem lipsum" | hello post -topic "Hello World"
+//	hello edit 1
+//	hello delete 1
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(configPath string, args []string) error
+}
+
+var commands = map[string]command{
+	"login":  {"login [-url URL] [-token TOKEN]", runLogin},
+	"list":   {"list [-o table|json|yaml] [-limit N] [-offset N]", runList},
+	"show":   {"show [-o table|json|yaml] ID", runShow},
+	"post":   {"post -topic TOPIC [-body BODY] [-e] [-o table|json|yaml]", runPost},
+	"edit":   {"edit [-topic TOPIC] [-body BODY] [-o table|json|yaml] ID", runEdit},
+	"delete": {"delete ID", runDelete},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: hello <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  hello %s\n", commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Bodies are read from -body, stdin or $EDITOR.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	path, err := configPath()
+	if err == nil {
+		err = cmd.run(path, os.Args[2:])
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hello %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dennis/hello_go/models"
+)
+
+var formats = []string{"table", "json", "yaml"}
+
+// Writes messages in format
+func printMessages(w io.Writer, format string, messages []models.Message) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tAUTHOR\tTOPIC")
+		for _, m := range messages {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", m.ID, m.Author, m.Topic)
+		}
+		return tw.Flush()
+	default:
+		return printValue(w, format, messages)
+	}
+}
+
+// Writes a single message. The table format shows the body too, as that is
+// what you want when looking at a single message
+func printMessage(w io.Writer, format string, message *models.Message) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(tw, "ID:\t%s\n", message.ID)
+		fmt.Fprintf(tw, "Author:\t%s\n", message.Author)
+		fmt.Fprintf(tw, "Topic:\t%s\n", message.Topic)
+		tw.Flush()
+		_, err := fmt.Fprintf(w, "\n%s\n", message.Body)
+		return err
+	default:
+		return printValue(w, format, message)
+	}
+}
+
+func printValue(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "yaml":
+		return writeYAML(w, v)
+	default:
+		return fmt.Errorf("unknown output format %q, use one of %s", format, strings.Join(formats, ", "))
+	}
+}
+
+// Writes v as YAML. The value goes through JSON first, so the keys match the
+// API and we only have to deal with maps, slices and scalars. Strings are
+// always double quoted, which YAML reads the same way as JSON
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	yamlNode(&b, generic, 0)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func yamlScalar(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(s)
+	default:
+		return fmt.Sprint(s)
+	}
+}
+
+func yamlNode(b *strings.Builder, v interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	switch n := v.(type) {
+	case map[string]interface{}:
+		if len(n) == 0 {
+			b.WriteString(pad + "{}\n")
+			return
+		}
+
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if isCollection(n[k]) {
+				b.WriteString(pad + k + ":\n")
+				yamlNode(b, n[k], indent+1)
+			} else {
+				b.WriteString(pad + k + ": " + yamlScalar(n[k]) + "\n")
+			}
+		}
+	case []interface{}:
+		if len(n) == 0 {
+			b.WriteString(pad + "[]\n")
+			return
+		}
+
+		for _, e := range n {
+			var item strings.Builder
+			yamlNode(&item, e, indent+1)
+
+			// The first line of the item goes on the same line as the dash
+			b.WriteString(pad + "- " + strings.TrimPrefix(item.String(), pad+"  "))
+		}
+	default:
+		b.WriteString(pad + yamlScalar(n) + "\n")
+	}
+}
+
+func isCollection(v interface{}) bool {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		return len(n) > 0
+	case []interface{}:
+		return len(n) > 0
+	}
+
+	return false
+}
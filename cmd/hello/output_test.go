@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+var messages = []models.Message{
+	{ID: "1", Topic: "Hello: World", Body: "Line 1\nLine 2", Author: "Dennis", ChannelID: "general"},
+	{ID: "2", Topic: "re: Hello", Body: "Really?", Author: "Marianne", ChannelID: "general"},
+}
+
+func TestPrintMessagesAsYAML(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := printMessages(&b, "yaml", messages); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `- author: "Dennis"
+  body: "Line 1\nLine 2"
+  channel_id: "general"
+  id: "1"
+  topic: "Hello: World"
+- author: "Marianne"
+  body: "Really?"
+  channel_id: "general"
+  id: "2"
+  topic: "re: Hello"
+`
+
+	if b.String() != expected {
+		t.Errorf("Unexpected YAML:\n%s", b.String())
+	}
+}
+
+func TestNestedYAML(t *testing.T) {
+	var b bytes.Buffer
+
+	writeYAML(&b, map[string]interface{}{"list": []string{"a"}, "empty": []string{}, "n": 1})
+
+	expected := "empty: []\nlist:\n  - \"a\"\nn: 1\n"
+
+	if b.String() != expected {
+		t.Errorf("Unexpected YAML:\n%s", b.String())
+	}
+}
+
+func TestPrintMessagesAsTable(t *testing.T) {
+	var b bytes.Buffer
+
+	printMessages(&b, "table", messages)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[2], "Marianne") {
+		t.Errorf("Unexpected table:\n%s", b.String())
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	var b bytes.Buffer
+
+	if err := printMessages(&b, "xml", messages); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,205 @@
+// Package commands implements the subcommands of the server binary. They all
+// share the configuration and the data loading of app.App.
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dennis/hello_go/app"
+)
+
+type command struct {
+	usage       string
+	description string
+	run         func(env *environment, args []string) error
+}
+
+// What the commands need to do their work. Output goes to stdout, which
+// tests can capture
+type environment struct {
+	config app.Config
+	stdout io.Writer
+}
+
+// Returns an App with the data files loaded, ready for reading and modifying
+// data
+func (e *environment) newApp() (*app.App, error) {
+	a := app.App{Config: e.config}
+
+	if err := a.Initialize(); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve":        {"serve [-listen ADDR] [-grpc-listen ADDR]", "Starts the HTTP and gRPC servers (default)", runServe},
+		"migrate":      {"migrate", "Rewrites the data files in the current format", runMigrate},
+		"import":       {"import [-users] [-format F] [-dry-run] [-preserve-ids] [-on-conflict S] FILE", "Adds messages (or users) from FILE to the data files", runImport},
+		"export":       {"export [-users] [-format F] [-out FILE]", "Writes all messages (or users) as json, jsonl or csv", runExport},
+		"users":        {"users create|list|rotate-token", "Manages users", runUsers},
+		"check-config": {"check-config", "Validates the configuration and prints it", runCheckConfig},
+		"help":         {"help", "Shows this help", runHelp},
+	}
+}
+
+var errUsage = errors.New("invalid usage")
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: main [-config FILE] <command> [arguments]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n      %s\n", commands[name].usage, commands[name].description)
+	}
+}
+
+// Runs the command named by args, with the arguments following it. Without
+// a command the server is started, as that is what the binary used to do
+func Run(args []string) error {
+	if err := run(args, os.Stdout); err != flag.ErrHelp {
+		return err
+	}
+
+	return nil
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("main", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to a JSON config file. Defaults are used if not given")
+	flags.Usage = func() { printUsage(os.Stderr) }
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := app.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	name := "serve"
+	rest := flags.Args()
+
+	if len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return cmd.run(&environment{config: config, stdout: stdout}, rest)
+}
+
+func runHelp(env *environment, args []string) error {
+	printUsage(env.stdout)
+
+	return nil
+}
+
+func runServe(env *environment, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", env.config.Listen, "address to listen on")
+	grpcListen := flags.String("grpc-listen", env.config.GRPCListen, "address the gRPC server listens on")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	env.config.Listen = *listen
+	env.config.GRPCListen = *grpcListen
+
+	// Like check-config, so the server doesn't fail later, ie on a ticker
+	// with an interval that isn't positive
+	if err := checkConfig(env.config); err != nil {
+		return err
+	}
+
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	a.Run()
+
+	return nil
+}
+
+func checkConfig(config app.Config) error {
+	if problems := config.Check(); len(problems) > 0 {
+		return fmt.Errorf("configuration isn't valid:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+func runCheckConfig(env *environment, args []string) error {
+	limits, _ := json.Marshal(env.config.Limits)
+	graphqlLimits, _ := json.Marshal(env.config.GraphQLLimits)
+	moderationConfig, _ := json.Marshal(env.config.Moderation)
+
+	// Keeps the values in one column however long the keys get
+	w := tabwriter.NewWriter(env.stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(w, "listen:\t%s\n", env.config.Listen)
+	fmt.Fprintf(w, "grpc_listen:\t%s\n", env.config.GRPCListen)
+	fmt.Fprintf(w, "messages_file:\t%s\n", env.config.MessagesFile)
+	fmt.Fprintf(w, "users_file:\t%s\n", env.config.UsersFile)
+	fmt.Fprintf(w, "channels_file:\t%s\n", env.config.ChannelsFile)
+	fmt.Fprintf(w, "drafts_file:\t%s\n", env.config.DraftsFile)
+	fmt.Fprintf(w, "attachments_dir:\t%s\n", env.config.AttachmentsDir)
+	fmt.Fprintf(w, "limits:\t%s\n", limits)
+	fmt.Fprintf(w, "graphql_limits:\t%s\n", graphqlLimits)
+	fmt.Fprintf(w, "trash_retention:\t%s\n", env.config.TrashRetention)
+	fmt.Fprintf(w, "purge_interval:\t%s\n", env.config.PurgeInterval)
+	fmt.Fprintf(w, "publish_interval:\t%s\n", env.config.PublishInterval)
+	fmt.Fprintf(w, "moderation:\t%s\n", moderationConfig)
+	w.Flush()
+
+	if err := checkConfig(env.config); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(env.stdout, "configuration is valid")
+
+	return nil
+}
+
+// The data files are read leniently at startup. Rewriting them drops
+// anything we don't understand and adds fields introduced since they were
+// written, so the files match what the server would write
+func runMigrate(env *environment, args []string) error {
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	if err := a.SaveData(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "migrated %s, %s, %s and %s\n", env.config.MessagesFile, env.config.UsersFile, env.config.ChannelsFile, env.config.DraftsFile)
+
+	return nil
+}
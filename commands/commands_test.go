@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Creates a config file pointing at data files in a temporary directory, and
+// returns the arguments to use it
+func setupConfig(t *testing.T) (string, []string) {
+	dir, err := ioutil.TempDir("", "commands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	config := `{"messages_file": "` + filepath.Join(dir, "messages.json") + `", "users_file": "` + filepath.Join(dir, "users.json") + `", "channels_file": "` + filepath.Join(dir, "channels.json") + `", "drafts_file": "` + filepath.Join(dir, "drafts.json") + `"}`
+	path := filepath.Join(dir, "config.json")
+
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, []string{"-config", path}
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	var out bytes.Buffer
+
+	err := run(args, &out)
+
+	return out.String(), err
+}
+
+func createUser(t *testing.T, config []string, args ...string) {
+	if out, err := runCommand(t, append(append(config, "users", "create"), args...)...); err != nil {
+		t.Fatalf("users create failed: %q, %v", out, err)
+	}
+}
+
+func TestUsersCreateListAndRotateToken(t *testing.T) {
+	_, config := setupConfig(t)
+
+	out, err := runCommand(t, append(config, "users", "create", "-token", "secrettoken", "-locale", "da", "dennis")...)
+	if err != nil || !strings.Contains(out, "secrettoken") {
+		t.Fatalf("users create failed: %q, %v", out, err)
+	}
+
+	if _, err := runCommand(t, append(config, "users", "create", "dennis")...); err == nil {
+		t.Error("Expected creating a duplicate user to fail")
+	}
+
+	if _, err := runCommand(t, append(config, "users", "create", "-token", "secrettoken", "marianne")...); err == nil {
+		t.Error("Expected creating a user with the token of another to fail")
+	}
+
+	out, err = runCommand(t, append(config, "users", "list")...)
+	if err != nil || !strings.Contains(out, "dennis") || !strings.Contains(out, "da") || strings.Contains(out, "secrettoken") {
+		t.Errorf("users list failed: %q, %v", out, err)
+	}
+
+	out, err = runCommand(t, append(config, "users", "rotate-token", "dennis")...)
+	if err != nil || strings.Contains(out, "secrettoken") {
+		t.Errorf("users rotate-token failed: %q, %v", out, err)
+	}
+
+	out, _ = runCommand(t, append(config, "export", "-users")...)
+	if strings.Contains(out, "secrettoken") {
+		t.Errorf("Expected old token to be gone after rotation: %q", out)
+	}
+
+	createUser(t, config, "-admin", "root")
+
+	out, _ = runCommand(t, append(config, "export", "-users")...)
+	if strings.Count(out, `"admin": true`) != 1 {
+		t.Errorf("Expected only root to be an admin: %q", out)
+	}
+
+	if _, err := runCommand(t, append(config, "users", "rotate-token", "nobody")...); err == nil {
+		t.Error("Expected rotating the token of an unknown user to fail")
+	}
+}
+
+func TestImportAndExport(t *testing.T) {
+	dir, config := setupConfig(t)
+	createUser(t, config, "dennis")
+
+	source := filepath.Join(dir, "import.json")
+	ioutil.WriteFile(source, []byte(`[{"topic":"Imported","body":"Body","author":"dennis"}]`), 0600)
+
+	if out, err := runCommand(t, append(config, "import", source)...); err != nil || !strings.Contains(out, "imported 1") {
+		t.Fatalf("import failed: %q, %v", out, err)
+	}
+
+	out, err := runCommand(t, append(config, "export")...)
+	if err != nil || !strings.Contains(out, `"topic": "Imported"`) {
+		t.Errorf("export failed: %q, %v", out, err)
+	}
+}
+
+func TestImportReportsInvalidLines(t *testing.T) {
+	dir, config := setupConfig(t)
+	createUser(t, config, "dennis")
+
+	source := filepath.Join(dir, "import.jsonl")
+	ioutil.WriteFile(source, []byte("{\"topic\":\"Valid\",\"body\":\"Body\",\"author\":\"dennis\"}\n{\"topic\":\"\",\"body\":\"Body\"}\nnot json\n"), 0600)
+
+	out, err := runCommand(t, append(config, "import", source)...)
+	if err == nil {
+		t.Error("Expected import with invalid lines to fail")
+	}
+	if !strings.Contains(out, "line 2: invalid") || !strings.Contains(out, "line 3: invalid") || !strings.Contains(out, "imported 1") {
+		t.Errorf("Unexpected report: %q", out)
+	}
+
+	if out, _ := runCommand(t, append(config, "export", "-format", "csv")...); !strings.Contains(out, "Valid") {
+		t.Errorf("Expected valid line to be imported, got %q", out)
+	}
+}
+
+func TestImportDryRunAndConflicts(t *testing.T) {
+	dir, config := setupConfig(t)
+	createUser(t, config, "dennis")
+
+	source := filepath.Join(dir, "import.csv")
+	ioutil.WriteFile(source, []byte("id,topic,body,author\n10,First,Body,dennis\n"), 0600)
+
+	if out, err := runCommand(t, append(config, "import", "-dry-run", "-preserve-ids", source)...); err != nil || !strings.Contains(out, "dry run: imported 1") {
+		t.Fatalf("dry run failed: %q, %v", out, err)
+	}
+	if out, _ := runCommand(t, append(config, "export")...); strings.Contains(out, "First") {
+		t.Errorf("Expected dry run to import nothing, got %q", out)
+	}
+
+	if _, err := runCommand(t, append(config, "import", "-preserve-ids", source)...); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	// Importing again conflicts with ID 10
+	if out, err := runCommand(t, append(config, "import", "-preserve-ids", source)...); err == nil || !strings.Contains(out, "aborted") {
+		t.Errorf("Expected conflict to abort the import: %q, %v", out, err)
+	}
+
+	if out, err := runCommand(t, append(config, "import", "-preserve-ids", "-on-conflict", "skip", source)...); err != nil || !strings.Contains(out, "skipped 1") {
+		t.Errorf("Expected conflict to be skipped: %q, %v", out, err)
+	}
+
+	if out, err := runCommand(t, append(config, "import", source)...); err != nil || !strings.Contains(out, "10 remapped to 11") {
+		t.Errorf("Expected ID to be remapped: %q, %v", out, err)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	_, config := setupConfig(t)
+
+	out, err := runCommand(t, append(config, "check-config")...)
+	if err != nil || !strings.Contains(out, "configuration is valid") {
+		t.Errorf("check-config failed: %q, %v", out, err)
+	}
+
+	// The values line up, after the longest key
+	column := len("publish_interval: ")
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "configuration") {
+			continue
+		}
+
+		if len(line) <= column || line[column-1] != ' ' || line[column] == ' ' {
+			t.Errorf("Expected the value to start after the longest key: %q", line)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, config := setupConfig(t)
+
+	out, err := runCommand(t, append(config, "migrate")...)
+	if err != nil {
+		t.Fatalf("migrate failed: %q, %v", out, err)
+	}
+
+	for _, file := range []string{"messages.json", "users.json", "channels.json", "drafts.json"} {
+		if !strings.Contains(out, filepath.Join(dir, file)) {
+			t.Errorf("Expected %s to be listed: %q", file, out)
+		}
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	if _, err := runCommand(t, "frobnicate"); err == nil {
+		t.Error("Expected unknown command to fail")
+	}
+}
+
+func TestServeChecksConfig(t *testing.T) {
+	dir, _ := setupConfig(t)
+	path := filepath.Join(dir, "bad.json")
+
+	if err := ioutil.WriteFile(path, []byte(`{"purge_interval": "-1m", "publish_interval": "-1m"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := runCommand(t, "-config", path, "serve")
+	if err == nil || !strings.Contains(err.Error(), "purge_interval: must be positive") || !strings.Contains(err.Error(), "publish_interval: must be positive") {
+		t.Errorf("Expected serve to refuse the intervals, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dennis/hello_go/bulk"
+	"github.com/dennis/hello_go/services"
+)
+
+// Prints the lines of report that weren't imported as is, and a summary
+func printImportReport(w io.Writer, report *services.ImportReport) {
+	for _, line := range report.Lines {
+		switch line.Status {
+		case services.ImportStatusInvalid:
+			fmt.Fprintf(w, "line %d: invalid: %v\n", line.Line, &services.NotValidError{Errors: line.Errors})
+		case services.ImportStatusConflict, services.ImportStatusSkipped:
+			fmt.Fprintf(w, "line %d: %s: %s already exists\n", line.Line, line.Status, line.ID)
+		case services.ImportStatusOverwritten:
+			fmt.Fprintf(w, "line %d: %s: %s already exists\n", line.Line, line.Status, line.ID)
+
+			if len(line.Kept) > 0 {
+				fmt.Fprintf(w, "line %d: kept the %s of %s\n", line.Line, strings.Join(line.Kept, " and "), line.ID)
+			}
+		case services.ImportStatusImported:
+			if len(line.OriginalID) > 0 && line.OriginalID != line.ID {
+				fmt.Fprintf(w, "line %d: %s remapped to %s\n", line.Line, line.OriginalID, line.ID)
+			}
+		}
+	}
+
+	prefix := ""
+	if report.DryRun {
+		prefix = "dry run: "
+	}
+
+	if report.Aborted {
+		fmt.Fprintf(w, "%saborted due to conflicts - nothing imported\n", prefix)
+	} else {
+		fmt.Fprintf(w, "%simported %d, overwritten %d, skipped %d, invalid %d\n", prefix, report.Imported, report.Overwritten, report.Skipped, report.Invalid)
+	}
+}
+
+// Reads messages or users from the file named by the only argument and adds
+// them to the data files. Fails if any line was invalid or the import was
+// aborted, so it can be used in scripts
+func runImport(env *environment, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	users := flags.Bool("users", false, "import users instead of messages")
+	formatName := flags.String("format", "", "json, jsonl or csv. Guessed from the file extension if not given")
+	dryRun := flags.Bool("dry-run", false, "only report what would be imported")
+	preserveIDs := flags.Bool("preserve-ids", false, "keep the IDs of imported messages instead of assigning new ones")
+	onConflict := flags.String("on-conflict", "fail", "what to do with existing records: skip, overwrite or fail")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%v: expected import [flags] FILE", errUsage)
+	}
+
+	path := flags.Arg(0)
+	format := bulk.FormatFromFilename(path)
+
+	if len(*formatName) > 0 {
+		var err error
+		if format, err = bulk.ParseFormat(*formatName); err != nil {
+			return err
+		}
+	}
+
+	strategy, err := services.ParseConflictStrategy(*onConflict)
+	if err != nil {
+		return err
+	}
+
+	options := services.ImportOptions{DryRun: *dryRun, PreserveIDs: *preserveIDs, OnConflict: strategy}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	var report *services.ImportReport
+
+	if *users {
+		records, err := bulk.ReadUsers(file, format)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+
+		report = a.Context.BulkService.ImportUsers(records, options)
+	} else {
+		records, err := bulk.ReadMessages(file, format)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+
+		report = a.Context.BulkService.ImportMessages(records, options)
+	}
+
+	printImportReport(env.stdout, report)
+
+	if !report.DryRun && !report.Aborted {
+		if err := a.SaveData(); err != nil {
+			return err
+		}
+	}
+
+	if !report.Clean() {
+		return fmt.Errorf("%s wasn't imported cleanly", path)
+	}
+
+	return nil
+}
+
+func runExport(env *environment, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	users := flags.Bool("users", false, "export users instead of messages")
+	out := flags.String("out", "", "file to write to. Defaults to stdout")
+	formatName := flags.String("format", "", "json, jsonl or csv. Guessed from -out if not given")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format := bulk.FormatFromFilename(*out)
+
+	if len(*formatName) > 0 {
+		var err error
+		if format, err = bulk.ParseFormat(*formatName); err != nil {
+			return err
+		}
+	}
+
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	w := env.stdout
+
+	if len(*out) > 0 {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if *users {
+		return bulk.WriteUsers(w, format, a.Context.BulkService.ExportUsers())
+	}
+
+	return bulk.WriteMessages(w, format, a.Context.BulkService.ExportMessages())
+}
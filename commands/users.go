@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func runUsers(env *environment, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%v: expected users create, list or rotate-token", errUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		return runUsersCreate(env, args[1:])
+	case "list":
+		return runUsersList(env, args[1:])
+	case "rotate-token":
+		return runUsersRotateToken(env, args[1:])
+	default:
+		return fmt.Errorf("unknown users command %q", args[0])
+	}
+}
+
+// Creates a user and prints its token
+func runUsersCreate(env *environment, args []string) error {
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	token := flags.String("token", "", "auth token. Generated if not given")
+	locale := flags.String("locale", "", "preferred language, ie en or da")
+	admin := flags.Bool("admin", false, "may use the admin API")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%v: expected users create [-token TOKEN] [-locale LOCALE] [-admin] USERNAME", errUsage)
+	}
+
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	user, err := a.Context.UserService.CreateUser(models.User{Username: flags.Arg(0), AuthToken: *token, Locale: *locale, Admin: *admin})
+	if err != nil {
+		return err
+	}
+
+	if err := a.SaveData(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "created %s with token %s\n", user.Username, user.AuthToken)
+
+	return nil
+}
+
+// Tokens are left out, as they are credentials
+func runUsersList(env *environment, args []string) error {
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tLOCALE")
+
+	for _, user := range a.Context.UserService.GetUsers() {
+		fmt.Fprintf(w, "%s\t%s\n", user.Username, user.Locale)
+	}
+
+	return w.Flush()
+}
+
+func runUsersRotateToken(env *environment, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%v: expected users rotate-token USERNAME", errUsage)
+	}
+
+	a, err := env.newApp()
+	if err != nil {
+		return err
+	}
+
+	user, err := a.Context.UserService.RotateToken(args[0])
+	if err != nil {
+		return fmt.Errorf("user %q: %v", args[0], err)
+	}
+
+	if err := a.SaveData(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "new token for %s: %s\n", user.Username, user.AuthToken)
+
+	return nil
+}
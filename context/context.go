@@ -9,4 +9,7 @@ import (
 type Context struct {
 	MessageService        services.MessageService
 	AuthenticationService services.AuthenticationService
+	UserService           services.UserService
+	ChannelService        services.ChannelService
+	BulkService           services.BulkService
 }
@@ -6,5 +6,6 @@ import (
 
 type Session struct {
 	CurrentUser           models.User
+	// The locale responses should use. See i18n.Negotiate
+	Locale                string
 }
-
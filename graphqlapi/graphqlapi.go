@@ -0,0 +1,183 @@
+// Package graphqlapi is the GraphQL API served at /api/graphql. It resolves
+// queries, mutations and subscriptions against the same services as the REST
+// API, and rejects queries that are too deep or too expensive before running
+// them.
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	appcontext "github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/i18n"
+	"github.com/dennis/hello_go/services"
+)
+
+// Operation types, as returned by Operation.Type
+const (
+	OperationQuery        = "query"
+	OperationMutation     = "mutation"
+	OperationSubscription = "subscription"
+)
+
+// A GraphQL request, as sent in the body of a POST
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// A request that has been parsed, validated and checked against
+// CurrentLimits, ready to run
+type Operation struct {
+	request  Request
+	document *ast.Document
+	// One of the Operation constants
+	Type string
+}
+
+func errorResult(errs ...error) *graphql.Result {
+	return &graphql.Result{Errors: gqlerrors.FormatErrors(errs...)}
+}
+
+// Parses and validates req. If it can't be run, a result with the errors to
+// respond with is returned instead
+func Parse(req Request) (*Operation, *graphql.Result) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return nil, errorResult(err)
+	}
+
+	if validation := graphql.ValidateDocument(&Schema, document, nil); !validation.IsValid {
+		return nil, &graphql.Result{Errors: validation.Errors}
+	}
+
+	definition, err := findOperation(document, req.OperationName)
+	if err != nil {
+		return nil, errorResult(err)
+	}
+
+	if err := checkLimits(document, definition, req.Variables); err != nil {
+		return nil, errorResult(err)
+	}
+
+	return &Operation{request: req, document: document, Type: definition.Operation}, nil
+}
+
+// Finds the operation named name, or the only operation if name is empty
+func findOperation(document *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if len(name) == 0 {
+			if found != nil {
+				return nil, fmt.Errorf("operationName is required when the document has more than one operation")
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == name {
+			found = operation
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("unknown operation %q", name)
+	}
+
+	return found, nil
+}
+
+type contextKey struct{}
+
+// What resolvers need to reach the services, and to act on behalf of the
+// current user
+type requestContext struct {
+	app     *appcontext.Context
+	session *appcontext.Session
+}
+
+func fromContext(ctx context.Context) requestContext {
+	return ctx.Value(contextKey{}).(requestContext)
+}
+
+func (o *Operation) params(ctx context.Context, app *appcontext.Context, session *appcontext.Session) graphql.ExecuteParams {
+	return graphql.ExecuteParams{
+		Schema:        Schema,
+		AST:           o.document,
+		OperationName: o.request.OperationName,
+		Args:          o.request.Variables,
+		Context:       context.WithValue(ctx, contextKey{}, requestContext{app, session}),
+	}
+}
+
+// Runs a query or mutation on behalf of session
+func (o *Operation) Execute(ctx context.Context, app *appcontext.Context, session *appcontext.Session) *graphql.Result {
+	return graphql.Execute(o.params(ctx, app, session))
+}
+
+// Runs a subscription on behalf of session. A result is sent for every
+// event, until ctx is done. The channel must be read until it is closed
+func (o *Operation) Subscribe(ctx context.Context, app *appcontext.Context, session *appcontext.Session) chan *graphql.Result {
+	return graphql.ExecuteSubscription(o.params(ctx, app, session))
+}
+
+// An error returned by a service, translated into the language of the
+// session. The code in the extensions corresponds to the problem types of the
+// REST API
+type serviceError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *serviceError) Error() string                      { return e.message }
+func (e *serviceError) Extensions() map[string]interface{} { return e.extensions }
+
+// Maps an error returned by a service into a GraphQL error in the language of
+// locale. Validation errors are included in the extensions, like in the
+// errors member of our problem responses
+func toError(locale string, err error) error {
+	problem := func(code, key string) error {
+		return &serviceError{
+			message:    i18n.Translate(locale, "problem."+key+".detail", nil),
+			extensions: map[string]interface{}{"code": code},
+		}
+	}
+
+	switch e := err.(type) {
+	case *services.NotFoundError:
+		return problem("NOT_FOUND", "not_found")
+	case *services.NotValidError:
+		errors := i18n.ValidationErrors(locale, e.Errors)
+
+		return &serviceError{
+			message:    i18n.ValidationSummary(errors),
+			extensions: map[string]interface{}{"code": "NOT_VALID", "errors": errors},
+		}
+	case *services.NotOwnerError:
+		return problem("NOT_OWNER", "not_owner")
+	case *services.ForbiddenError:
+		return problem("FORBIDDEN", "forbidden")
+	case *services.LockedError:
+		return problem("LOCKED", "locked")
+	case *services.BlockedError:
+		return problem("BLOCKED", "blocked")
+	case *services.RejectedError:
+		return &serviceError{message: i18n.ModerationReason(locale, e.Code, e.Params, e.Reason), extensions: map[string]interface{}{"code": "REJECTED"}}
+	case *services.HeldError:
+		return problem("HELD", "held")
+	default:
+		return problem("INTERNAL", "internal")
+	}
+}
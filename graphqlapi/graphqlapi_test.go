@@ -0,0 +1,413 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	appcontext "github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
+)
+
+var dennis = models.User{Username: "dennis", AuthToken: "authtokendennis"}
+var marianne = models.User{Username: "marianne", AuthToken: "authtokenmarianne", Locale: "da"}
+
+// A thread started by dennis, with a reply from marianne and a reply to that
+// in the default channel. Message 4 is in a channel only dennis is a member of
+func setupContext() *appcontext.Context {
+	userRepository := repositories.UserRepository{}
+	userRepository.Insert(dennis)
+	userRepository.Insert(marianne)
+
+	channelRepository := repositories.ChannelRepository{}
+	channelRepository.InsertWithID(models.DefaultChannel)
+	channelRepository.InsertWithID(models.Channel{ID: "private", Name: "Private", Owner: "dennis", Members: []string{"dennis"}})
+
+	messageRepository := repositories.MessageRepository{}
+	messageRepository.Insert(models.Message{Topic: "Hello", Body: "World", Author: "dennis", ChannelID: models.DefaultChannelID})
+	messageRepository.Insert(models.Message{Topic: "re: Hello", Body: "Hi", Author: "marianne", ParentID: "1", ChannelID: models.DefaultChannelID})
+	messageRepository.Insert(models.Message{Topic: "re: re: Hello", Body: "Hi!", Author: "dennis", ParentID: "2", ChannelID: models.DefaultChannelID})
+	messageRepository.Insert(models.Message{Topic: "Secret", Body: "Psst", Author: "dennis", ChannelID: "private"})
+
+	return &appcontext.Context{
+		UserService:    services.UserService{UserRepository: &userRepository},
+		ChannelService: services.ChannelService{ChannelRepository: &channelRepository, UserRepository: &userRepository},
+		MessageService: servicestest.NewMessageService(&messageRepository, &channelRepository, &userRepository),
+	}
+}
+
+func execute(t *testing.T, ctx *appcontext.Context, user models.User, query string, variables map[string]interface{}) *graphql.Result {
+	t.Helper()
+
+	operation, result := Parse(Request{Query: query, Variables: variables})
+	if result != nil {
+		return result
+	}
+
+	return operation.Execute(context.Background(), ctx, &appcontext.Session{CurrentUser: user, Locale: user.Locale})
+}
+
+// Compares the JSON of the data of result with expected
+func assertData(t *testing.T, result *graphql.Result, expected string) {
+	t.Helper()
+
+	if result.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+
+	var want interface{}
+	json.Unmarshal([]byte(expected), &want)
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(result.Data)
+
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("Expected %s, got %s", wantJSON, gotJSON)
+	}
+}
+
+func assertErrorCode(t *testing.T, result *graphql.Result, code string) map[string]interface{} {
+	t.Helper()
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected a single error, got %v", result.Errors)
+	}
+
+	extensions := result.Errors[0].Extensions
+	if extensions["code"] != code {
+		t.Errorf("Expected code %s, got %v", code, extensions)
+	}
+
+	return extensions
+}
+
+func TestThreadsWithRepliesAndAuthors(t *testing.T) {
+	// Message 4 is in a channel marianne isn't a member of
+	result := execute(t, setupContext(), marianne, `{
+		threads(limit: 10) {
+			id
+			replyCount
+			author { username }
+			replies(limit: 10) { id author { username } replies(limit: 10) { id parent { id } } }
+		}
+	}`, nil)
+
+	assertData(t, result, `{"threads": [{
+		"id": "1",
+		"replyCount": 1,
+		"author": {"username": "dennis"},
+		"replies": [{"id": "2", "author": {"username": "marianne"}, "replies": [{"id": "3", "parent": {"id": "2"}}]}]
+	}]}`)
+}
+
+func TestListsWithoutLimitAreLimited(t *testing.T) {
+	ctx := setupContext()
+	for i := 0; i < assumedListSize; i++ {
+		ctx.MessageService.MessageRepository.Insert(models.Message{Topic: "t", Body: "b", Author: "dennis", ChannelID: models.DefaultChannelID})
+	}
+
+	result := execute(t, ctx, dennis, `{ messages { id } }`, nil)
+	if result.HasErrors() {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+
+	if messages := result.Data.(map[string]interface{})["messages"].([]interface{}); len(messages) != assumedListSize {
+		t.Errorf("Expected %d messages, got %d", assumedListSize, len(messages))
+	}
+}
+
+func TestMessagesOfUser(t *testing.T) {
+	result := execute(t, setupContext(), marianne, `query($name: String!) {
+		me { username }
+		user(username: $name) { messages(limit: 1, offset: 1) { id } }
+	}`, map[string]interface{}{"name": "dennis"})
+
+	assertData(t, result, `{"me": {"username": "marianne"}, "user": {"messages": [{"id": "3"}]}}`)
+}
+
+func TestChannels(t *testing.T) {
+	ctx := setupContext()
+	query := `{ channels { id messages(limit: 10) { id channel { name } } } }`
+
+	assertData(t, execute(t, ctx, marianne, query, nil), `{"channels": [{"id": "general", "messages": [
+		{"id": "1", "channel": {"name": "General"}},
+		{"id": "2", "channel": {"name": "General"}},
+		{"id": "3", "channel": {"name": "General"}}
+	]}]}`)
+
+	assertData(t, execute(t, ctx, dennis, `{ channel(id: "private") { messages(limit: 10) { topic } } }`, nil),
+		`{"channel": {"messages": [{"topic": "Secret"}]}}`)
+
+	assertErrorCode(t, execute(t, ctx, marianne, `{ channel(id: "private") { id } }`, nil), "FORBIDDEN")
+	assertErrorCode(t, execute(t, ctx, marianne, `{ message(id: "4") { id } }`, nil), "FORBIDDEN")
+}
+
+func TestDirectMessages(t *testing.T) {
+	ctx := setupContext()
+
+	result := execute(t, ctx, dennis, `mutation {
+		createMessage(topic: "t", body: "b", recipients: ["marianne"]) { id channel { id } recipients }
+	}`, nil)
+
+	assertData(t, result, `{"createMessage": {"id": "5", "channel": null, "recipients": ["marianne"]}}`)
+
+	assertData(t, execute(t, ctx, marianne, `{ message(id: "5") { author { username } } }`, nil),
+		`{"message": {"author": {"username": "dennis"}}}`)
+
+	if inbox := ctx.MessageService.GetInbox(services.Page{}, marianne); inbox.Total != 1 || inbox.Unread != 0 {
+		t.Errorf("Expected the message to be read, got %+v", inbox)
+	}
+
+	// Replies go to the others taking part
+	assertData(t, execute(t, ctx, marianne, `mutation { createMessage(topic: "t", body: "b", parentId: "5") { recipients } }`, nil),
+		`{"createMessage": {"recipients": ["dennis"]}}`)
+}
+
+func TestTagsAndCreatedAt(t *testing.T) {
+	ctx := setupContext()
+
+	ctx.MessageService.Clock = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	assertData(t, execute(t, ctx, dennis, `mutation { createMessage(topic: "t", body: "b", tags: ["Go", "#go"]) { tags createdAt } }`, nil),
+		`{"createMessage": {"tags": ["go"], "createdAt": "2020-01-02T03:04:05Z"}}`)
+
+	// Tags are kept unless given
+	assertData(t, execute(t, ctx, dennis, `mutation { updateMessage(id: "5", topic: "t", body: "changed") { tags } }`, nil),
+		`{"updateMessage": {"tags": ["go"]}}`)
+
+	assertData(t, execute(t, ctx, marianne, `{ tags { name count } }`, nil),
+		`{"tags": [{"name": "go", "count": 1}]}`)
+
+	assertData(t, execute(t, ctx, dennis, `mutation { updateMessage(id: "5", topic: "t", body: "b", tags: []) { tags } }`, nil),
+		`{"updateMessage": {"tags": []}}`)
+}
+
+func TestUnread(t *testing.T) {
+	ctx := setupContext()
+
+	assertData(t, execute(t, ctx, marianne, `{ unreadCount messages(limit: 10) { id unread } }`, nil),
+		`{"unreadCount": 2, "messages": [{"id": "1", "unread": true}, {"id": "2", "unread": false}, {"id": "3", "unread": true}]}`)
+
+	assertData(t, execute(t, ctx, marianne, `mutation { markRead(id: "1", thread: true) { unread } }`, nil),
+		`{"markRead": {"unread": false}}`)
+
+	assertData(t, execute(t, ctx, dennis, `{ channels { id unreadCount } }`, nil),
+		`{"channels": [{"id": "general", "unreadCount": 1}, {"id": "private", "unreadCount": 0}]}`)
+
+	assertData(t, execute(t, ctx, dennis, `mutation { markAllRead }`, nil), `{"markAllRead": true}`)
+	assertData(t, execute(t, ctx, dennis, `{ unreadCount }`, nil), `{"unreadCount": 0}`)
+}
+
+func TestAttachments(t *testing.T) {
+	ctx := setupContext()
+
+	message := ctx.MessageService.MessageRepository.FindByID("1")
+	message.Attachments = []models.Attachment{{ID: "1", Name: "hello.txt", ContentType: "text/plain; charset=utf-8", Size: 11, SHA256: "a591a6d4", CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}}
+	ctx.MessageService.MessageRepository.Update(*message)
+
+	assertData(t, execute(t, ctx, marianne, `{ message(id: "1") { attachments { id name contentType size sha256 createdAt } } }`, nil),
+		`{"message": {"attachments": [{"id": "1", "name": "hello.txt", "contentType": "text/plain; charset=utf-8", "size": 11, "sha256": "a591a6d4", "createdAt": "2020-01-02T03:04:05Z"}]}}`)
+
+	assertData(t, execute(t, ctx, marianne, `{ message(id: "2") { attachments { id } } }`, nil),
+		`{"message": {"attachments": []}}`)
+}
+
+func TestBodyHTML(t *testing.T) {
+	ctx := setupContext()
+
+	result := execute(t, ctx, marianne, `mutation {
+		createMessage(topic: "t", body: "**Hi** <script>alert(1)</script>") { body bodyHtml }
+	}`, nil)
+
+	assertData(t, result, `{"createMessage": {"body": "**Hi** <script>alert(1)</script>", "bodyHtml": "<p><strong>Hi</strong> </p>\n"}}`)
+}
+
+func TestReactions(t *testing.T) {
+	ctx := setupContext()
+
+	execute(t, ctx, dennis, `mutation { addReaction(id: "1", emoji: "👍") { id } }`, nil)
+
+	assertData(t, execute(t, ctx, marianne, `mutation { addReaction(id: "1", emoji: "👍") { reactions { emoji count me } } }`, nil),
+		`{"addReaction": {"reactions": [{"emoji": "👍", "count": 2, "me": true}]}}`)
+
+	assertData(t, execute(t, ctx, marianne, `mutation { removeReaction(id: "1", emoji: "👍") { reactions { emoji count me } } }`, nil),
+		`{"removeReaction": {"reactions": [{"emoji": "👍", "count": 1, "me": false}]}}`)
+
+	assertErrorCode(t, execute(t, ctx, marianne, `mutation { addReaction(id: "1", emoji: "no") { id } }`, nil), "NOT_VALID")
+}
+
+func TestMessageNotFound(t *testing.T) {
+	result := execute(t, setupContext(), dennis, `{ message(id: "42") { id } }`, nil)
+
+	assertErrorCode(t, result, "NOT_FOUND")
+}
+
+func TestCreateReply(t *testing.T) {
+	ctx := setupContext()
+
+	result := execute(t, ctx, marianne, `mutation {
+		createMessage(topic: "t", body: "b", parentId: "1") { id author { username } parent { id } }
+	}`, nil)
+
+	assertData(t, result, `{"createMessage": {"id": "5", "author": {"username": "marianne"}, "parent": {"id": "1"}}}`)
+
+	_, total, _ := ctx.MessageService.GetReplies("1", services.Page{}, marianne)
+	if total != 2 {
+		t.Errorf("Expected 2 replies, got %d", total)
+	}
+}
+
+func TestCreateMessage_NotValid(t *testing.T) {
+	result := execute(t, setupContext(), marianne, `mutation { createMessage(topic: "t", body: "", parentId: "42") { id } }`, nil)
+
+	extensions := assertErrorCode(t, result, "NOT_VALID")
+
+	errors, _ := json.Marshal(extensions["errors"])
+	if !strings.Contains(string(errors), `"field":"parent_id","code":"not_found"`) || !strings.Contains(string(errors), "Tekst skal udfyldes") {
+		t.Errorf("Expected localized errors for body and parent_id, got %s", errors)
+	}
+}
+
+func TestUpdateAndDeleteRequiresOwner(t *testing.T) {
+	ctx := setupContext()
+
+	result := execute(t, ctx, marianne, `mutation { updateMessage(id: "1", topic: "t", body: "b") { id } }`, nil)
+	assertErrorCode(t, result, "NOT_OWNER")
+
+	result = execute(t, ctx, dennis, `mutation { deleteMessage(id: "1") }`, nil)
+	assertData(t, result, `{"deleteMessage": "1"}`)
+}
+
+func TestLockedThreads(t *testing.T) {
+	ctx := setupContext()
+
+	if _, err := ctx.MessageService.LockMessage("1", models.User{Username: "dennis", Moderator: true}); err != nil {
+		t.Fatalf("Unexpected error locking: %v", err)
+	}
+
+	assertData(t, execute(t, ctx, dennis, `{ message(id: "2") { locked parent { locked } } }`, nil), `{"message": {"locked": false, "parent": {"locked": true}}}`)
+
+	result := execute(t, ctx, dennis, `mutation { createMessage(topic: "t", body: "b", parentId: "3") { id } }`, nil)
+	assertErrorCode(t, result, "LOCKED")
+
+	result = execute(t, ctx, marianne, `mutation { updateMessage(id: "2", topic: "t", body: "b") { id } }`, nil)
+	assertErrorCode(t, result, "LOCKED")
+}
+
+func TestParse_RejectsInvalidQueries(t *testing.T) {
+	for _, query := range []string{`{ messages {`, `{ unknown }`, `query a { me { username } } query b { me { username } }`} {
+		if _, result := Parse(Request{Query: query}); result == nil || !result.HasErrors() {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+}
+
+func TestParse_Depth(t *testing.T) {
+	CurrentLimits = Limits{MaxDepth: 3, MaxComplexity: 10000}
+	defer func() { CurrentLimits = DefaultLimits }()
+
+	if _, result := Parse(Request{Query: `{ threads { author { username } } }`}); result != nil {
+		t.Errorf("Expected depth 3 to be allowed, got %v", result.Errors)
+	}
+
+	_, result := Parse(Request{Query: `{ threads { replies { author { username } } } }`})
+	if result == nil || !strings.Contains(result.Errors[0].Message, "depth 4") {
+		t.Errorf("Expected depth 4 to be rejected, got %v", result)
+	}
+
+	// Fragments count where they are used
+	_, result = Parse(Request{Query: `{ threads { ...replies } } fragment replies on Message { replies { author { username } } }`})
+	if result == nil {
+		t.Errorf("Expected depth 4 to be rejected when using fragments")
+	}
+}
+
+func TestParse_Complexity(t *testing.T) {
+	CurrentLimits = Limits{MaxDepth: 10, MaxComplexity: 100}
+	defer func() { CurrentLimits = DefaultLimits }()
+
+	// 1 + 10 * (id + replies(1 + 5 * id)) = 71
+	query := `query($limit: Int) { threads(limit: 10) { id replies(limit: $limit) { id } } }`
+
+	if _, result := Parse(Request{Query: query, Variables: map[string]interface{}{"limit": 5.0}}); result != nil {
+		t.Errorf("Expected complexity 71 to be allowed, got %v", result.Errors)
+	}
+
+	_, result := Parse(Request{Query: query, Variables: map[string]interface{}{"limit": 10.0}})
+	if result == nil || !strings.Contains(result.Errors[0].Message, "complexity 121") {
+		t.Errorf("Expected complexity 121 to be rejected, got %v", result)
+	}
+
+	// Lists without a limit are expected to be large
+	if _, result := Parse(Request{Query: `{ messages { id replies { id } } }`}); result == nil {
+		t.Errorf("Expected unbounded lists to be rejected")
+	}
+}
+
+func TestParse_ComplexityDoesNotOverflow(t *testing.T) {
+	if _, result := Parse(Request{Query: `{ messages(limit: 1000) { replies(limit: 1000) { id } } }`}); result == nil {
+		t.Errorf("Expected complexity 1001001 to be rejected")
+	}
+
+	// Multiplying the limits would overflow
+	query := `{ messages(limit: 2147483647) { replies(limit: 2147483647) { replies(limit: 2147483647) { id } } } }`
+
+	if _, result := Parse(Request{Query: query}); result == nil || !strings.Contains(result.Errors[0].Message, "complexity") {
+		t.Errorf("Expected huge limits to be rejected, got %v", result)
+	}
+
+	_, result := Parse(Request{Query: `query($limit: Int) { messages(limit: $limit) { replies(limit: $limit) { replies(limit: $limit) { id } } } }`,
+		Variables: map[string]interface{}{"limit": 2147483647.0}})
+	if result == nil {
+		t.Errorf("Expected huge limits in variables to be rejected")
+	}
+}
+
+func TestSubscribe_MessageCreated(t *testing.T) {
+	ctx := setupContext()
+
+	operation, result := Parse(Request{Query: `subscription { messageCreated { id topic } }`})
+	if result != nil {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+
+	if operation.Type != OperationSubscription {
+		t.Errorf("Expected a subscription, got %s", operation.Type)
+	}
+
+	subscriptionCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	results := operation.Subscribe(subscriptionCtx, ctx, &appcontext.Session{CurrentUser: marianne})
+
+	// The subscription is set up in the background, so keep creating
+	// messages until the first result arrives. Messages in channels marianne
+	// isn't a member of are not sent
+	var received *graphql.Result
+	for received == nil {
+		ctx.MessageService.CreateMessage(models.Message{Topic: "secret", Body: "b", ChannelID: "private"}, dennis)
+		ctx.MessageService.CreateMessage(models.Message{Topic: "reply", Body: "b", ParentID: "1"}, dennis)
+
+		select {
+		case received = <-results:
+		case <-time.After(10 * time.Millisecond):
+		case <-subscriptionCtx.Done():
+			t.Fatal("No result received")
+		}
+	}
+
+	data, _ := json.Marshal(received.Data)
+	if !strings.Contains(string(data), `"topic":"reply"`) {
+		t.Errorf("Expected the reply, got %s", data)
+	}
+
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,209 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Bounds on the queries we run, as a single GraphQL query can otherwise ask
+// for the whole database many times over
+type Limits struct {
+	// How deeply fields may be nested, ie 3 for { messages { author { username } } }
+	MaxDepth int `json:"max_depth"`
+	// The estimated cost of a query. Each field costs 1, and the fields
+	// selected below a list are multiplied by its limit argument (or
+	// assumedListSize, if it has none)
+	MaxComplexity int `json:"max_complexity"`
+}
+
+var DefaultLimits = Limits{
+	MaxDepth:      10,
+	MaxComplexity: 10000,
+}
+
+// The limits applied by Parse. Set from the configuration at startup
+var CurrentLimits = DefaultLimits
+
+// The size of lists without a limit argument, see pageFrom
+const assumedListSize = 100
+
+// Complexities saturate here rather than overflow, as nested lists with huge
+// limits multiply quickly
+const complexityCeiling = math.MaxInt32
+
+// Returns x + y, or complexityCeiling if that is more. Both must be between
+// 0 and complexityCeiling
+func addComplexity(x, y int) int {
+	if x > complexityCeiling-y {
+		return complexityCeiling
+	}
+
+	return x + y
+}
+
+// Returns x * y, or complexityCeiling if that is more. Both must be between
+// 0 and complexityCeiling
+func multiplyComplexity(x, y int) int {
+	if x != 0 && y > complexityCeiling/x {
+		return complexityCeiling
+	}
+
+	return x * y
+}
+
+// Returns an error if the operation exceeds CurrentLimits. The document must
+// have been validated, so every field exists
+func checkLimits(document *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) error {
+	a := analyzer{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case OperationMutation:
+		root = Schema.MutationType()
+	case OperationSubscription:
+		root = Schema.SubscriptionType()
+	default:
+		root = Schema.QueryType()
+	}
+
+	depth, complexity := a.selectionSet(root, operation.SelectionSet)
+
+	if depth > CurrentLimits.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, CurrentLimits.MaxDepth)
+	}
+
+	if complexity == complexityCeiling && complexity > CurrentLimits.MaxComplexity {
+		return fmt.Errorf("query complexity exceeds the maximum of %d", CurrentLimits.MaxComplexity)
+	}
+
+	if complexity > CurrentLimits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, CurrentLimits.MaxComplexity)
+	}
+
+	return nil
+}
+
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// Returns the depth and complexity of the fields selected on parent
+func (a *analyzer) selectionSet(parent *graphql.Object, set *ast.SelectionSet) (int, int) {
+	if set == nil || parent == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+
+	for _, selection := range set.Selections {
+		var d, c int
+
+		switch s := selection.(type) {
+		case *ast.Field:
+			d, c = a.field(parent, s)
+		case *ast.InlineFragment:
+			d, c = a.selectionSet(parent, s.SelectionSet)
+		case *ast.FragmentSpread:
+			// Validation guarantees fragments exist and don't form cycles
+			d, c = a.selectionSet(parent, a.fragments[s.Name.Value].SelectionSet)
+		}
+
+		if d > depth {
+			depth = d
+		}
+		complexity = addComplexity(complexity, c)
+	}
+
+	return depth, complexity
+}
+
+func (a *analyzer) field(parent *graphql.Object, field *ast.Field) (int, int) {
+	// Introspection is bounded by the size of the schema, and tools send
+	// deep introspection queries
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+
+	definition := parent.Fields()[field.Name.Value]
+	if definition == nil {
+		return 1, 1
+	}
+
+	fieldType, isList := unwrap(definition.Type)
+
+	object, _ := fieldType.(*graphql.Object)
+	depth, complexity := a.selectionSet(object, field.SelectionSet)
+
+	if isList {
+		complexity = multiplyComplexity(complexity, a.listSize(field))
+	}
+
+	return depth + 1, addComplexity(complexity, 1)
+}
+
+// Strips NonNull and List from t, telling if it was a list
+func unwrap(t graphql.Type) (graphql.Type, bool) {
+	isList := false
+
+	for {
+		switch wrapper := t.(type) {
+		case *graphql.NonNull:
+			t = wrapper.OfType
+		case *graphql.List:
+			t = wrapper.OfType
+			isList = true
+		default:
+			return t, isList
+		}
+	}
+}
+
+// The limit argument of field, or assumedListSize if it has none. Limits
+// above complexityCeiling count as that
+func (a *analyzer) listSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+
+		var limit int
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.ParseInt(value.Value, 10, 64); err == nil && n > complexityCeiling {
+				limit = complexityCeiling
+			} else {
+				limit = int(n)
+			}
+		case *ast.Variable:
+			switch v := a.variables[value.Name.Value].(type) {
+			case float64:
+				limit = int(math.Min(v, complexityCeiling))
+			case int:
+				limit = v
+			}
+		}
+
+		if limit > complexityCeiling {
+			limit = complexityCeiling
+		}
+
+		if limit > 0 {
+			return limit
+		}
+	}
+
+	return assumedListSize
+}
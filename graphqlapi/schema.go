@@ -0,0 +1,805 @@
+package graphqlapi
+
+import (
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+// How many events a messageCreated subscription may have pending before the
+// client is considered too slow, and the subscription ends
+const subscriptionBuffer = 64
+
+// The limit and offset arguments of list fields
+var pageArgs = graphql.FieldConfigArgument{
+	"limit":  &graphql.ArgumentConfig{Type: graphql.Int, Description: "Maximum number of items to return. Left out, or 0, for at most 100"},
+	"offset": &graphql.ArgumentConfig{Type: graphql.Int, Description: "Number of items to skip"},
+}
+
+var reactionArgs = graphql.FieldConfigArgument{
+	"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	"emoji": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+}
+
+func pageFrom(args map[string]interface{}) (services.Page, error) {
+	page := services.Page{}
+	page.Limit, _ = args["limit"].(int)
+	page.Offset, _ = args["offset"].(int)
+
+	if page.Limit < 0 || page.Offset < 0 {
+		return page, errors.New("limit and offset must be non-negative")
+	}
+
+	// Lists without a limit are as long as the complexity check assumes
+	if page.Limit == 0 {
+		page.Limit = assumedListSize
+	}
+
+	return page, nil
+}
+
+// Resolvers returns pointers, so fields can use the message as their source
+func pointers(messages []models.Message) []*models.Message {
+	result := make([]*models.Message, 0, len(messages))
+
+	for i := range messages {
+		result = append(result, &messages[i])
+	}
+
+	return result
+}
+
+// Returns the strings of a list argument, or nil if it wasn't given
+func stringList(value interface{}) []string {
+	var result []string
+
+	values, _ := value.([]interface{})
+	for _, v := range values {
+		result = append(result, v.(string))
+	}
+
+	return result
+}
+
+// Returns t, or an untyped nil if it is nil, so the field becomes null
+func timeOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return *t
+}
+
+// Wraps a resolver returning a page of messages
+func messageList(list func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		r := fromContext(p.Context)
+
+		page, err := pageFrom(p.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, _, err := list(r, p, page)
+		if err != nil {
+			return nil, toError(r.session.Locale, err)
+		}
+
+		return pointers(messages), nil
+	}
+}
+
+// Runs a mutation returning a message
+func messageMutation(mutate func(r requestContext, args map[string]interface{}) (*models.Message, error)) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		r := fromContext(p.Context)
+
+		message, err := mutate(r, p.Args)
+		if err != nil {
+			return nil, toError(r.session.Locale, err)
+		}
+
+		return message, nil
+	}
+}
+
+var (
+	userType         *graphql.Object
+	channelType      *graphql.Object
+	messageType      *graphql.Object
+	tagType          *graphql.Object
+	reactionType     *graphql.Object
+	attachmentType   *graphql.Object
+	queryType        *graphql.Object
+	mutationType     *graphql.Object
+	subscriptionType *graphql.Object
+
+	// The schema served at /api/graphql
+	Schema graphql.Schema
+)
+
+// The types refer to each other, so they are built here rather than in
+// their declarations
+func init() {
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"username": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.User).Username, nil
+					},
+				},
+				"messages": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(messageType))),
+					Description: "Messages written by the user",
+					Args:        pageArgs,
+					Resolve: messageList(func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error) {
+						return r.app.MessageService.GetMessagesByAuthor(p.Source.(*models.User).Username, page, r.session.CurrentUser)
+					}),
+				},
+			}
+		}),
+	})
+
+	channelType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Channel",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Channel).ID, nil
+					},
+				},
+				"name": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Channel).Name, nil
+					},
+				},
+				"description": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Channel).Description, nil
+					},
+				},
+				"open": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Boolean),
+					Description: "Everyone is a member of open channels",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Channel).Open, nil
+					},
+				},
+				"members": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Description: "Usernames of the members, including the owner",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Channel).Members, nil
+					},
+				},
+				"messages": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(messageType))),
+					Args: pageArgs,
+					Resolve: messageList(func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error) {
+						return r.app.MessageService.GetChannelMessages(p.Source.(*models.Channel).ID, page, r.session.CurrentUser)
+					}),
+				},
+				"unreadCount": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Int),
+					Description: "Number of messages in the channel the current user hasn't read",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+
+						unread, err := r.app.MessageService.CountUnread(p.Source.(*models.Channel).ID, r.session.CurrentUser)
+						if err != nil {
+							return nil, toError(r.session.Locale, err)
+						}
+
+						return unread, nil
+					},
+				},
+			}
+		}),
+	})
+
+	messageType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Message",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Message).ID, nil
+					},
+				},
+				"topic": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Message).Topic, nil
+					},
+				},
+				"body": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "The body in Markdown",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Message).Body, nil
+					},
+				},
+				"bodyHtml": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "The body rendered as sanitized HTML",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return fromContext(p.Context).app.MessageService.BodyHTML(*p.Source.(*models.Message)), nil
+					},
+				},
+				"author": &graphql.Field{
+					Type:        userType,
+					Description: "Null if the author no longer exists",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+
+						user, err := r.app.UserService.GetUser(p.Source.(*models.Message).Author)
+						if _, ok := err.(*services.NotFoundError); ok {
+							return nil, nil
+						}
+
+						return user, err
+					},
+				},
+				"channel": &graphql.Field{
+					Type:        channelType,
+					Description: "Null for direct messages",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+						message := p.Source.(*models.Message)
+
+						if message.IsDirect() {
+							return nil, nil
+						}
+
+						channel, err := r.app.ChannelService.GetChannel(message.ChannelID, r.session.CurrentUser)
+						if err != nil {
+							return nil, toError(r.session.Locale, err)
+						}
+
+						return channel, nil
+					},
+				},
+				"unread": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Boolean),
+					Description: "Whether the current user hasn't read the message yet",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+
+						return r.app.MessageService.IsUnread(*p.Source.(*models.Message), r.session.CurrentUser), nil
+					},
+				},
+				"recipients": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Description: "The usernames a direct message is sent to. Empty for other messages",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if recipients := p.Source.(*models.Message).Recipients; recipients != nil {
+							return recipients, nil
+						}
+
+						return []string{}, nil
+					},
+				},
+				"tags": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Description: "Tags categorising the message, in lower case",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if tags := p.Source.(*models.Message).Tags; tags != nil {
+							return tags, nil
+						}
+
+						return []string{}, nil
+					},
+				},
+				"attachments": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(attachmentType))),
+					Description: "Files attached to the message. They are uploaded and downloaded with the REST API",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if attachments := p.Source.(*models.Message).Attachments; attachments != nil {
+							return attachments, nil
+						}
+
+						return []models.Attachment{}, nil
+					},
+				},
+				"reactions": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reactionType))),
+					Description: "The reactions to the message by emoji, ordered by the earliest reaction with each",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+
+						return r.app.MessageService.Reactions(*p.Source.(*models.Message), r.session.CurrentUser), nil
+					},
+				},
+				"createdAt": &graphql.Field{
+					Type:        graphql.DateTime,
+					Description: "When the message was created. Null for messages from before this was recorded",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return timeOrNil(p.Source.(*models.Message).CreatedAt), nil
+					},
+				},
+				"updatedAt": &graphql.Field{
+					Type:        graphql.DateTime,
+					Description: "When the message was last updated, or created if it hasn't been",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return timeOrNil(p.Source.(*models.Message).UpdatedAt), nil
+					},
+				},
+				"pinnedAt": &graphql.Field{
+					Type:        graphql.DateTime,
+					Description: "When a moderator pinned the message to the top of the list, or null unless it is pinned",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return timeOrNil(p.Source.(*models.Message).PinnedAt), nil
+					},
+				},
+				"locked": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.Boolean),
+					Description: "Locked messages, and the replies to them, can't be replied to or updated",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*models.Message).Locked, nil
+					},
+				},
+				"parent": &graphql.Field{
+					Type:        messageType,
+					Description: "The message this is a reply to. Null for messages starting a thread",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+						parentID := p.Source.(*models.Message).ParentID
+
+						if len(parentID) == 0 {
+							return nil, nil
+						}
+
+						parent, err := r.app.MessageService.GetMessage(parentID, r.session.CurrentUser)
+						if _, ok := err.(*services.NotFoundError); ok {
+							return nil, nil
+						}
+
+						return parent, err
+					},
+				},
+				"replies": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(messageType))),
+					Args: pageArgs,
+					Resolve: messageList(func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error) {
+						return r.app.MessageService.GetReplies(p.Source.(*models.Message).ID, page, r.session.CurrentUser)
+					}),
+				},
+				"replyCount": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Int),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := fromContext(p.Context)
+
+						_, total, err := r.app.MessageService.GetReplies(p.Source.(*models.Message).ID, services.Page{Limit: 1}, r.session.CurrentUser)
+
+						return total, err
+					},
+				},
+			}
+		}),
+	})
+
+	tagType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.TagCount).Name, nil
+				},
+			},
+			"count": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of messages the current user can read with the tag",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.TagCount).Count, nil
+				},
+			},
+		},
+	})
+
+	attachmentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Attachment",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).ID, nil
+				},
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).Name, nil
+				},
+			},
+			"contentType": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Sniffed from the content",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).ContentType, nil
+				},
+			},
+			"size": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "In bytes",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).Size, nil
+				},
+			},
+			"sha256": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: "Hex encoded SHA-256 of the content",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).SHA256, nil
+				},
+			},
+			"createdAt": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.DateTime),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.Attachment).CreatedAt, nil
+				},
+			},
+		},
+	})
+
+	reactionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Reaction",
+		Fields: graphql.Fields{
+			"emoji": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.ReactionCount).Emoji, nil
+				},
+			},
+			"count": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of users reacting with the emoji",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.ReactionCount).Count, nil
+				},
+			},
+			"me": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Whether the current user is one of them",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.ReactionCount).Me, nil
+				},
+			},
+		},
+	})
+
+	queryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"messages": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(messageType))),
+				Description: "All messages in the channels of the current user, including replies, and the direct messages they sent or received",
+				Args:        pageArgs,
+				Resolve: messageList(func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error) {
+					return r.app.MessageService.GetMessages(page, r.session.CurrentUser)
+				}),
+			},
+			"threads": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(messageType))),
+				Description: "Messages starting a thread, ie that aren't replies",
+				Args:        pageArgs,
+				Resolve: messageList(func(r requestContext, p graphql.ResolveParams, page services.Page) ([]models.Message, int, error) {
+					return r.app.MessageService.GetThreads(page, r.session.CurrentUser)
+				}),
+			},
+			"message": &graphql.Field{
+				Type:        messageType,
+				Description: "The message is marked as read by the current user",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					message, err := r.app.MessageService.ReadMessage(p.Args["id"].(string), r.session.CurrentUser)
+					if err != nil {
+						return nil, toError(r.session.Locale, err)
+					}
+
+					return message, nil
+				},
+			},
+			"channels": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(channelType))),
+				Description: "The channels the current user is a member of",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+					channels := r.app.ChannelService.GetChannels(r.session.CurrentUser)
+
+					result := make([]*models.Channel, 0, len(channels))
+					for i := range channels {
+						result = append(result, &channels[i])
+					}
+
+					return result, nil
+				},
+			},
+			"channel": &graphql.Field{
+				Type: channelType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					channel, err := r.app.ChannelService.GetChannel(p.Args["id"].(string), r.session.CurrentUser)
+					if err != nil {
+						return nil, toError(r.session.Locale, err)
+					}
+
+					return channel, nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+					users := r.app.UserService.GetUsers()
+
+					result := make([]*models.User, 0, len(users))
+					for i := range users {
+						result = append(result, &users[i])
+					}
+
+					return result, nil
+				},
+			},
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"username": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					user, err := r.app.UserService.GetUser(p.Args["username"].(string))
+					if err != nil {
+						return nil, toError(r.session.Locale, err)
+					}
+
+					return user, nil
+				},
+			},
+			"me": &graphql.Field{
+				Type:        graphql.NewNonNull(userType),
+				Description: "The authenticated user",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					user := fromContext(p.Context).session.CurrentUser
+
+					return &user, nil
+				},
+			},
+			"unreadCount": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of messages the current user can read, but hasn't",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					return r.app.MessageService.CountUnread("", r.session.CurrentUser)
+				},
+			},
+			"tags": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tagType))),
+				Description: "The tags of the messages the current user can read, most used first",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					return r.app.MessageService.GetTags(r.session.CurrentUser), nil
+				},
+			},
+		},
+	})
+
+	mutationType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createMessage": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Creates a message authored by the current user in channelId, or the default channel. With recipients it is a direct message to them instead. With parentId it is a reply to that message, in its channel or to the others taking part in it",
+				Args: graphql.FieldConfigArgument{
+					"topic":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"body":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"parentId":   &graphql.ArgumentConfig{Type: graphql.ID},
+					"channelId":  &graphql.ArgumentConfig{Type: graphql.ID},
+					"recipients": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+					"tags":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+				},
+				Resolve: messageMutation(func(r requestContext, args map[string]interface{}) (*models.Message, error) {
+					message := models.Message{Topic: args["topic"].(string), Body: args["body"].(string)}
+					message.ParentID, _ = args["parentId"].(string)
+					message.ChannelID, _ = args["channelId"].(string)
+					message.Recipients = stringList(args["recipients"])
+					message.Tags = stringList(args["tags"])
+
+					return r.app.MessageService.CreateMessage(message, r.session.CurrentUser)
+				}),
+			},
+			"updateMessage": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Updates a message. Only allowed for its author. Its tags are kept unless tags is given",
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"topic": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"body":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"tags":  &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+				},
+				Resolve: messageMutation(func(r requestContext, args map[string]interface{}) (*models.Message, error) {
+					message := models.Message{ID: args["id"].(string), Topic: args["topic"].(string), Body: args["body"].(string)}
+
+					// An empty list clears the tags
+					if tags, ok := args["tags"]; ok {
+						message.Tags = append([]string{}, stringList(tags)...)
+					}
+
+					return r.app.MessageService.UpdateMessage(message, r.session.CurrentUser)
+				}),
+			},
+			"deleteMessage": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Moves a message to the trash. Only allowed for its author. Returns the ID of the deleted message",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+					id := p.Args["id"].(string)
+
+					if err := r.app.MessageService.DeleteMessage(id, r.session.CurrentUser); err != nil {
+						return nil, toError(r.session.Locale, err)
+					}
+
+					return id, nil
+				},
+			},
+			"markRead": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Marks a message as read by the current user. With thread, all the replies below it are marked too",
+				Args: graphql.FieldConfigArgument{
+					"id":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"thread": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+				},
+				Resolve: messageMutation(func(r requestContext, args map[string]interface{}) (*models.Message, error) {
+					id := args["id"].(string)
+
+					if args["thread"].(bool) {
+						if err := r.app.MessageService.MarkThreadRead(id, r.session.CurrentUser); err != nil {
+							return nil, err
+						}
+					}
+
+					return r.app.MessageService.ReadMessage(id, r.session.CurrentUser)
+				}),
+			},
+			"addReaction": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Reacts to a message with an emoji as the current user. Reacting twice with the same emoji does nothing",
+				Args:        reactionArgs,
+				Resolve: messageMutation(func(r requestContext, args map[string]interface{}) (*models.Message, error) {
+					id := args["id"].(string)
+
+					if _, err := r.app.MessageService.AddReaction(id, args["emoji"].(string), r.session.CurrentUser); err != nil {
+						return nil, err
+					}
+
+					return r.app.MessageService.GetMessage(id, r.session.CurrentUser)
+				}),
+			},
+			"removeReaction": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Takes back a reaction of the current user. Removing a reaction that isn't there does nothing",
+				Args:        reactionArgs,
+				Resolve: messageMutation(func(r requestContext, args map[string]interface{}) (*models.Message, error) {
+					id := args["id"].(string)
+
+					if _, err := r.app.MessageService.RemoveReaction(id, args["emoji"].(string), r.session.CurrentUser); err != nil {
+						return nil, err
+					}
+
+					return r.app.MessageService.GetMessage(id, r.session.CurrentUser)
+				}),
+			},
+			"markAllRead": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "Marks every message the current user can read as read",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+
+					r.app.MessageService.MarkAllRead(r.session.CurrentUser)
+
+					return true, nil
+				},
+			},
+		},
+	})
+
+	subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			"messageCreated": &graphql.Field{
+				Type:        graphql.NewNonNull(messageType),
+				Description: "Sends messages as they are created in the channels of the current user, and direct messages they send or receive. With parentId, only replies to that message are sent, and with channelId only messages in that channel",
+				Args: graphql.FieldConfigArgument{
+					"parentId":  &graphql.ArgumentConfig{Type: graphql.ID},
+					"channelId": &graphql.ArgumentConfig{Type: graphql.ID},
+				},
+				Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+					r := fromContext(p.Context)
+					parentID, byParent := p.Args["parentId"].(string)
+					channelID, byChannel := p.Args["channelId"].(string)
+
+					if r.app.MessageService.Events == nil {
+						return nil, errors.New("subscriptions aren't enabled")
+					}
+
+					events, unsubscribe := r.app.MessageService.Events.Subscribe(subscriptionBuffer)
+					messages := make(chan interface{})
+
+					go func() {
+						defer close(messages)
+						defer unsubscribe()
+
+						for {
+							select {
+							case <-p.Context.Done():
+								return
+							case event, ok := <-events:
+								if !ok {
+									return
+								}
+
+								if event.Type != services.EventCreated ||
+									(byParent && event.Message.ParentID != parentID) ||
+									(byChannel && event.Message.ChannelID != channelID) ||
+									!r.app.MessageService.CanRead(event.Message, r.session.CurrentUser) ||
+									r.app.MessageService.IsHidden(event.Message, r.session.CurrentUser) {
+									continue
+								}
+
+								message := event.Message
+
+								select {
+								case messages <- &message:
+								case <-p.Context.Done():
+									return
+								}
+							}
+						}
+					}()
+
+					return messages, nil
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source, nil
+				},
+			},
+		},
+	})
+
+	var err error
+
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query:        queryType,
+		Mutation:     mutationType,
+		Subscription: subscriptionType,
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,429 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	appcontext "github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/i18n"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+// How many events a WatchMessages stream may have pending before the client
+// is considered too slow, and disconnected
+const watchBuffer = 64
+
+// Implements MessageService on top of the same services as the REST API
+type Server struct {
+	UnimplementedMessageServiceServer
+
+	Context *appcontext.Context
+}
+
+// Returns a gRPC server with MessageService registered. Every call must be
+// authenticated, see authenticate
+func NewServer(ctx *appcontext.Context) *grpc.Server {
+	s := &Server{Context: ctx}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	)
+	RegisterMessageServiceServer(server, s)
+
+	return server
+}
+
+type sessionKey struct{}
+
+// The session of the authenticated user making the call
+func sessionFrom(ctx context.Context) *appcontext.Session {
+	return ctx.Value(sessionKey{}).(*appcontext.Session)
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Extracts the auth token from the authorization metadata. Both "Bearer
+// <token>" and basic authentication with the token as username is accepted
+func authToken(authorization string) string {
+	const bearerScheme, basicScheme = "Bearer ", "Basic "
+
+	switch {
+	case strings.HasPrefix(authorization, bearerScheme):
+		return authorization[len(bearerScheme):]
+	case strings.HasPrefix(authorization, basicScheme):
+		decoded, err := base64.StdEncoding.DecodeString(authorization[len(basicScheme):])
+		if err != nil {
+			return ""
+		}
+
+		return strings.SplitN(string(decoded), ":", 2)[0]
+	default:
+		return ""
+	}
+}
+
+// Authenticates the call, and returns a context carrying its session
+func (s *Server) authenticate(ctx context.Context) (context.Context, *appcontext.Session, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	acceptLanguage := firstMetadata(md, "accept-language")
+
+	token := authToken(firstMetadata(md, "authorization"))
+
+	var user *models.User
+	if len(token) > 0 {
+		user = s.Context.AuthenticationService.Authenticate(token)
+	}
+
+	if user == nil {
+		locale := i18n.Negotiate("", acceptLanguage)
+
+		return ctx, nil, status.Error(codes.Unauthenticated, i18n.Translate(locale, "problem.unauthenticated.detail", nil))
+	}
+
+	session := &appcontext.Session{
+		CurrentUser: *user,
+		Locale:      i18n.Negotiate(user.Locale, acceptLanguage),
+	}
+
+	return context.WithValue(ctx, sessionKey{}, session), session, nil
+}
+
+// Logs calls in the same format as App does for HTTP requests
+func logCall(session *appcontext.Session, method string, start time.Time, err error) {
+	username := "unknown"
+	if session != nil {
+		username = session.CurrentUser.Username
+	}
+
+	log.Printf(
+		"%-3s %-10s%-6s\t%s\t%s",
+		status.Code(err),
+		username,
+		"GRPC",
+		method,
+		time.Since(start))
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+
+	ctx, session, err := s.authenticate(ctx)
+
+	var resp interface{}
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+
+	logCall(session, info.FullMethod, start, err)
+
+	return resp, err
+}
+
+// Replaces the context of a stream with the authenticated one
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Server) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+
+	ctx, session, err := s.authenticate(stream.Context())
+
+	if err == nil {
+		err = handler(srv, &authenticatedStream{stream, ctx})
+	}
+
+	logCall(session, info.FullMethod, start, err)
+
+	return err
+}
+
+// Maps an error returned by a service into a gRPC status, with a message in
+// the language of locale. Validation errors carries a BadRequest detail with
+// a violation per field, where Reason is the validation code
+func toStatus(locale string, err error) error {
+	switch e := err.(type) {
+	case *services.NotFoundError:
+		return status.Error(codes.NotFound, i18n.Translate(locale, "problem.not_found.detail", nil))
+	case *services.NotValidError:
+		errors := i18n.ValidationErrors(locale, e.Errors)
+		badRequest := &errdetails.BadRequest{}
+
+		for _, v := range errors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+				Reason:      v.Code,
+			})
+		}
+
+		st, detailErr := status.New(codes.InvalidArgument, i18n.ValidationSummary(errors)).WithDetails(badRequest)
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, i18n.ValidationSummary(errors))
+		}
+
+		return st.Err()
+	case *services.NotOwnerError:
+		return status.Error(codes.PermissionDenied, i18n.Translate(locale, "problem.not_owner.detail", nil))
+	case *services.ForbiddenError:
+		return status.Error(codes.PermissionDenied, i18n.Translate(locale, "problem.forbidden.detail", nil))
+	case *services.LockedError:
+		return status.Error(codes.FailedPrecondition, i18n.Translate(locale, "problem.locked.detail", nil))
+	case *services.BlockedError:
+		return status.Error(codes.PermissionDenied, i18n.Translate(locale, "problem.blocked.detail", nil))
+	case *services.RejectedError:
+		return status.Error(codes.InvalidArgument, i18n.ModerationReason(locale, e.Code, e.Params, e.Reason))
+	default:
+		return status.Error(codes.Internal, i18n.Translate(locale, "problem.internal.detail", nil))
+	}
+}
+
+func toProto(m *models.Message) *Message {
+	return &Message{Id: m.ID, Topic: m.Topic, Body: m.Body, Author: m.Author, ParentId: m.ParentID, ChannelId: m.ChannelID, Recipients: m.Recipients, Tags: m.Tags,
+		CreatedAt: formatTime(m.CreatedAt), UpdatedAt: formatTime(m.UpdatedAt), Attachments: toAttachmentProtos(m.Attachments),
+		PinnedAt: formatTime(m.PinnedAt), Locked: m.Locked}
+}
+
+// Held messages aren't errors as such, and reporting them as one would make
+// clients retry, queueing them again
+func toHeldProto(held *services.HeldError) *Message {
+	message := toProto(&held.Held.Message)
+	message.Held = true
+	message.HeldReason = held.Held.Reason
+
+	return message
+}
+
+func toAttachmentProtos(attachments []models.Attachment) []*Attachment {
+	var converted []*Attachment
+
+	for _, a := range attachments {
+		converted = append(converted, &Attachment{Id: a.ID, Name: a.Name, ContentType: a.ContentType, Size: a.Size, Sha256: a.SHA256, CreatedAt: formatTime(&a.CreatedAt)})
+	}
+
+	return converted
+}
+
+// Converts a message as seen by the current user, see MessageService.View
+func toListedProto(m models.ListedMessage) *Message {
+	message := toProto(&m.Message)
+	message.Unread = m.Unread
+	message.Reactions = toReactionProtos(m.Reactions)
+	message.BodyHtml = m.BodyHTML
+
+	return message
+}
+
+func toReactionProtos(reactions []models.ReactionCount) []*ReactionCount {
+	var converted []*ReactionCount
+
+	for _, r := range reactions {
+		converted = append(converted, &ReactionCount{Emoji: r.Emoji, Count: int32(r.Count), Me: r.Me})
+	}
+
+	return converted
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+func (s *Server) ListMessages(ctx context.Context, req *ListMessagesRequest) (*ListMessagesResponse, error) {
+	session := sessionFrom(ctx)
+
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must be non-negative")
+	}
+
+	page := services.Page{Limit: int(req.Limit), Offset: int(req.Offset)}
+
+	var messages []models.Message
+	var total int
+	var err error
+
+	if len(req.ChannelId) > 0 {
+		messages, total, err = s.Context.MessageService.GetChannelMessages(req.ChannelId, page, session.CurrentUser)
+	} else {
+		messages, total, err = s.Context.MessageService.GetMessages(page, session.CurrentUser)
+	}
+
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	unread, err := s.Context.MessageService.CountUnread(req.ChannelId, session.CurrentUser)
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	resp := &ListMessagesResponse{TotalCount: int32(total), UnreadCount: int32(unread)}
+	for _, m := range s.Context.MessageService.Views(messages, session.CurrentUser) {
+		resp.Messages = append(resp.Messages, toListedProto(m))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) GetMessage(ctx context.Context, req *GetMessageRequest) (*Message, error) {
+	session := sessionFrom(ctx)
+
+	message, err := s.Context.MessageService.ReadMessage(req.Id, session.CurrentUser)
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return toListedProto(s.Context.MessageService.View(*message, session.CurrentUser)), nil
+}
+
+func (s *Server) CreateMessage(ctx context.Context, req *CreateMessageRequest) (*Message, error) {
+	session := sessionFrom(ctx)
+
+	message, err := s.Context.MessageService.CreateMessage(models.Message{Topic: req.Topic, Body: req.Body, ParentID: req.ParentId, ChannelID: req.ChannelId, Recipients: req.Recipients, Tags: req.Tags}, session.CurrentUser)
+	if held, ok := err.(*services.HeldError); ok {
+		return toHeldProto(held), nil
+	}
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return toListedProto(s.Context.MessageService.View(*message, session.CurrentUser)), nil
+}
+
+func (s *Server) UpdateMessage(ctx context.Context, req *UpdateMessageRequest) (*Message, error) {
+	session := sessionFrom(ctx)
+
+	message, err := s.Context.MessageService.UpdateMessage(models.Message{ID: req.Id, Topic: req.Topic, Body: req.Body, Tags: req.Tags}, session.CurrentUser)
+	if held, ok := err.(*services.HeldError); ok {
+		return toHeldProto(held), nil
+	}
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return toListedProto(s.Context.MessageService.View(*message, session.CurrentUser)), nil
+}
+
+func (s *Server) DeleteMessage(ctx context.Context, req *DeleteMessageRequest) (*DeleteMessageResponse, error) {
+	session := sessionFrom(ctx)
+
+	if err := s.Context.MessageService.DeleteMessage(req.Id, session.CurrentUser); err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return &DeleteMessageResponse{}, nil
+}
+
+func (s *Server) MarkRead(ctx context.Context, req *MarkReadRequest) (*MarkReadResponse, error) {
+	session := sessionFrom(ctx)
+
+	var err error
+
+	switch {
+	case len(req.Id) == 0:
+		s.Context.MessageService.MarkAllRead(session.CurrentUser)
+	case req.Thread:
+		err = s.Context.MessageService.MarkThreadRead(req.Id, session.CurrentUser)
+	default:
+		err = s.Context.MessageService.MarkRead(req.Id, session.CurrentUser)
+	}
+
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return &MarkReadResponse{}, nil
+}
+
+func (s *Server) React(ctx context.Context, req *ReactRequest) (*ReactResponse, error) {
+	session := sessionFrom(ctx)
+
+	var reactions []models.ReactionCount
+	var err error
+
+	if req.Remove {
+		reactions, err = s.Context.MessageService.RemoveReaction(req.Id, req.Emoji, session.CurrentUser)
+	} else {
+		reactions, err = s.Context.MessageService.AddReaction(req.Id, req.Emoji, session.CurrentUser)
+	}
+
+	if err != nil {
+		return nil, toStatus(session.Locale, err)
+	}
+
+	return &ReactResponse{Reactions: toReactionProtos(reactions)}, nil
+}
+
+var eventTypes = map[services.EventType]MessageEvent_Type{
+	services.EventCreated:         MessageEvent_TYPE_CREATED,
+	services.EventUpdated:         MessageEvent_TYPE_UPDATED,
+	services.EventDeleted:         MessageEvent_TYPE_DELETED,
+	services.EventRestored:        MessageEvent_TYPE_RESTORED,
+	services.EventReactionAdded:   MessageEvent_TYPE_REACTION_ADDED,
+	services.EventReactionRemoved: MessageEvent_TYPE_REACTION_REMOVED,
+}
+
+func (s *Server) WatchMessages(req *WatchMessagesRequest, stream MessageService_WatchMessagesServer) error {
+	if s.Context.MessageService.Events == nil {
+		return status.Error(codes.Unimplemented, "message events aren't enabled")
+	}
+
+	session := sessionFrom(stream.Context())
+
+	events, unsubscribe := s.Context.MessageService.Events.Subscribe(watchBuffer)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "too slow to keep up with events, watch again to resume")
+			}
+
+			if len(req.ChannelId) > 0 && event.Message.ChannelID != req.ChannelId {
+				continue
+			}
+
+			if !s.Context.MessageService.CanRead(event.Message, session.CurrentUser) || s.Context.MessageService.IsHidden(event.Message, session.CurrentUser) {
+				continue
+			}
+
+			sent := &MessageEvent{Type: eventTypes[event.Type], Message: toProto(&event.Message)}
+
+			if len(event.Reaction.Emoji) > 0 {
+				sent.Reaction = &MessageEvent_Reaction{Username: event.Reaction.Username, Emoji: event.Reaction.Emoji}
+			}
+
+			if err := stream.Send(sent); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/bulk"
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/i18n"
+	"github.com/dennis/hello_go/services"
+)
+
+// The admin routes are only for users with Admin set
+func requireAdmin(w http.ResponseWriter, r *http.Request, session *context.Session) bool {
+	if !session.CurrentUser.Admin {
+		handleError(w, r, session.Locale, &services.ForbiddenError{})
+		return false
+	}
+
+	return true
+}
+
+// Reads the format query parameter. Defaults to JSON Lines
+func parseFormat(r *http.Request) (bulk.Format, error) {
+	if name := r.URL.Query().Get("format"); len(name) > 0 {
+		return bulk.ParseFormat(name)
+	}
+
+	return bulk.JSONLines, nil
+}
+
+// Reads the format, dry_run, preserve_ids and on_conflict query parameters
+func parseImportOptions(r *http.Request) (bulk.Format, services.ImportOptions, error) {
+	query := r.URL.Query()
+	options := services.ImportOptions{}
+
+	format, err := parseFormat(r)
+	if err != nil {
+		return format, options, err
+	}
+
+	for name, value := range map[string]*bool{"dry_run": &options.DryRun, "preserve_ids": &options.PreserveIDs} {
+		if raw := query.Get(name); len(raw) > 0 {
+			if *value, err = strconv.ParseBool(raw); err != nil {
+				return format, options, err
+			}
+		}
+	}
+
+	options.OnConflict, err = services.ParseConflictStrategy(query.Get("on_conflict"))
+
+	return format, options, err
+}
+
+func writeImportReport(w http.ResponseWriter, session *context.Session, report *services.ImportReport) {
+	for i := range report.Lines {
+		report.Lines[i].Errors = i18n.ValidationErrors(session.Locale, report.Lines[i].Errors)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Imports messages from the request body. The report describes the outcome
+// of each line, see services.ImportReport
+// returns:
+//   200 success: with the report, also when lines were invalid or the import aborted
+//   400 bad request: if the options or the body couldn't be read
+//   403 forbidden: if CurrentUser isn't an admin
+func ImportMessages(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireAdmin(w, r, session) {
+		return
+	}
+
+	format, options, err := parseImportOptions(r)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	records, err := bulk.ReadMessages(r.Body, format)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	writeImportReport(w, session, ctx.BulkService.ImportMessages(records, options))
+}
+
+// Imports users from the request body. See ImportMessages
+func ImportUsers(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireAdmin(w, r, session) {
+		return
+	}
+
+	format, options, err := parseImportOptions(r)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	records, err := bulk.ReadUsers(r.Body, format)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	writeImportReport(w, session, ctx.BulkService.ImportUsers(records, options))
+}
+
+// Exports all messages in the format given by the format query parameter
+// returns:
+//   200 success: if successful
+//   400 bad request: if the format is unknown
+//   403 forbidden: if CurrentUser isn't an admin
+func ExportMessages(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireAdmin(w, r, session) {
+		return
+	}
+
+	format, err := parseFormat(r)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	bulk.WriteMessages(w, format, ctx.BulkService.ExportMessages())
+}
+
+// Exports all users, including their tokens. See ExportMessages
+func ExportUsers(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireAdmin(w, r, session) {
+		return
+	}
+
+	format, err := parseFormat(r)
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	bulk.WriteUsers(w, format, ctx.BulkService.ExportUsers())
+}
+
+// Deletes a message for good, whether it is in the trash or not
+// returns:
+//   200 success: if message was deleted
+//   403 forbidden: if CurrentUser isn't an admin
+//   404 not found: if message wasn't found
+func PurgeMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if !requireAdmin(w, r, session) {
+		return
+	}
+
+	if err := ctx.MessageService.PurgeMessage(vars["id"]); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+func setupAdminContext(admin bool) (*context.Context, *context.Session) {
+	ctx, session := setupContext()
+	session.CurrentUser.Admin = admin
+
+	ctx.BulkService = services.BulkService{
+		MessageRepository:  ctx.MessageService.MessageRepository,
+		UserRepository:     ctx.MessageService.UserRepository,
+		ChannelRepository:  ctx.MessageService.ChannelRepository,
+		ReactionRepository: ctx.MessageService.ReactionRepository,
+	}
+
+	return ctx, session
+}
+
+func TestImportMessages_RequiresAdmin(t *testing.T) {
+	ctx, session := setupAdminContext(false)
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b","author":"foo"}`))
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 403)
+	assertProblem(t, resp, problemTypeForbidden)
+}
+
+func TestImportMessages_ReportsEachLine(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+	session.Locale = "da"
+
+	r := httptest.NewRequest("POST", "/api/admin/messages/import?preserve_ids=true&on_conflict=skip", strings.NewReader(
+		`{"id":"1","topic":"t","body":"b","author":"foo"}`+"\n"+`{"id":"9","topic":"","body":"b","author":"foo"}`+"\n"+`{"id":"10","topic":"t","body":"b","author":"foo"}`))
+	w := httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 200)
+
+	var report services.ImportReport
+
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if report.Skipped != 1 || report.Invalid != 1 || report.Imported != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if len(report.Lines) != 3 || report.Lines[1].Errors[0].Message != "Emne skal udfyldes" {
+		t.Errorf("Expected localized errors for line 2: %+v", report.Lines)
+	}
+	if m, _ := ctx.MessageService.GetMessage("10", fooUser); m == nil {
+		t.Error("Expected message 10 to be imported")
+	}
+}
+
+func TestImportUsers_RefusesTakenTokens(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+	ctx.BulkService.UserRepository.Update(models.User{Username: "foo", AuthToken: "footoken"})
+
+	// foo keeps its own token, but nobody else gets it, or one given
+	// earlier in the import
+	r := httptest.NewRequest("POST", "/api/admin/users/import?on_conflict=overwrite", strings.NewReader(
+		`{"username":"eve","auth_token":"footoken"}`+"\n"+
+			`{"username":"mallory","auth_token":"sharedtoken"}`+"\n"+
+			`{"username":"trent","auth_token":"sharedtoken"}`+"\n"+
+			`{"username":"foo","auth_token":"footoken","locale":"da"}`))
+	w := httptest.NewRecorder()
+
+	ImportUsers(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var report services.ImportReport
+
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if report.Invalid != 2 || report.Imported != 1 || report.Overwritten != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	for _, i := range []int{0, 2} {
+		if errors := report.Lines[i].Errors; len(errors) != 1 || errors[0].Field != "auth_token" || errors[0].Code != "taken" {
+			t.Errorf("Expected line %d to have a taken token, got %+v", i+1, errors)
+		}
+	}
+
+	if user := ctx.BulkService.UserRepository.FindByToken("footoken"); user == nil || user.Username != "foo" {
+		t.Errorf("Expected the token to stay with foo, got %+v", user)
+	}
+}
+
+func TestExportMessages_CSV(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+
+	r := httptest.NewRequest("GET", "/api/admin/messages/export?format=csv", nil)
+	w := httptest.NewRecorder()
+
+	ExportMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 200)
+	assertContentType(t, resp, "text/csv; charset=utf-8")
+
+	body := w.Body.String()
+
+	if !strings.HasPrefix(body, "id,topic,body,author,parent_id,channel_id,recipients,tags,created_at,updated_at,deleted_at\n") || !strings.Contains(body, "Topic2") {
+		t.Errorf("Unexpected export: %q", body)
+	}
+}
+
+func TestImportMessages_RemapsParents(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+
+	// 1 is taken, so the thread is imported as 3 and 4
+	r := httptest.NewRequest("POST", "/api/admin/messages/import", strings.NewReader(
+		`{"id":"1","topic":"t","body":"b","author":"foo"}`+"\n"+`{"id":"2","topic":"re: t","body":"b","author":"foo","parent_id":"1"}`))
+	w := httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	assertStatusCode(t, w.Result(), 200)
+
+	if m, _ := ctx.MessageService.GetMessage("4", fooUser); m == nil || m.ParentID != "3" {
+		t.Errorf("Expected the reply to point to the imported parent, got %+v", m)
+	}
+}
+
+func TestImportMessages_RemapsParentsComingLater(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+
+	// The reply comes first, and is imported as 3 before its parent as 4
+	r := httptest.NewRequest("POST", "/api/admin/messages/import", strings.NewReader(
+		`{"id":"2","topic":"re: t","body":"b","author":"foo","parent_id":"1"}`+"\n"+`{"id":"1","topic":"t","body":"b","author":"foo"}`))
+	w := httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	assertStatusCode(t, w.Result(), 200)
+
+	if m, _ := ctx.MessageService.GetMessage("3", fooUser); m == nil || m.ParentID != "4" {
+		t.Errorf("Expected the reply to point to the imported parent, got %+v", m)
+	}
+}
+
+func TestImportMessages_ChecksReferences(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+	ctx.MessageService.ChannelRepository.InsertWithID(models.Channel{ID: "private", Name: "Private", Owner: "bar", Members: []string{"bar"}})
+
+	// 1 is taken, so the reply to it on line 5 would be remapped without
+	// its parent, and the reply to that on line 6 in turn
+	r := httptest.NewRequest("POST", "/api/admin/messages/import", strings.NewReader(
+		`{"topic":"t","body":"b","author":"nobody"}`+"\n"+
+			`{"topic":"t","body":"b","author":"foo","channel_id":"private"}`+"\n"+
+			`{"topic":"t","body":"b","author":"foo","channel_id":"nowhere"}`+"\n"+
+			`{"topic":"t","body":"b","author":"foo","recipients":["nobody"]}`+"\n"+
+			`{"id":"5","topic":"t","body":"b","author":"foo","parent_id":"1"}`+"\n"+
+			`{"id":"6","topic":"t","body":"b","author":"foo","parent_id":"5"}`+"\n"+
+			`{"topic":"t","body":"b","author":"bar","channel_id":"private"}`))
+	w := httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var report services.ImportReport
+
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if report.Invalid != 6 || report.Imported != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	expected := []string{"author", "author", "channel_id", "recipients", "parent_id", "parent_id"}
+
+	for i, field := range expected {
+		if errors := report.Lines[i].Errors; len(errors) != 1 || errors[0].Field != field {
+			t.Errorf("Expected line %d to be invalid for %s, got %+v", i+1, field, errors)
+		}
+	}
+
+	// With the IDs kept, the reply is to the existing message
+	r = httptest.NewRequest("POST", "/api/admin/messages/import?preserve_ids=true", strings.NewReader(
+		`{"id":"5","topic":"t","body":"b","author":"foo","parent_id":"1"}`))
+	w = httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	if m := ctx.MessageService.MessageRepository.FindByID("5"); m == nil || m.ParentID != "1" {
+		t.Errorf("Expected the reply to the existing message to be imported, got %+v", m)
+	}
+}
+
+func TestImportMessages_OverwriteReportsWhatIsKept(t *testing.T) {
+	ctx, session := setupAdminContext(true)
+	ctx.MessageService.AddReaction("1", "👍", fooUser)
+
+	withAttachment := message2
+	withAttachment.Attachments = []models.Attachment{{ID: "1", Name: "a.txt"}}
+	ctx.MessageService.MessageRepository.Update(withAttachment)
+
+	r := httptest.NewRequest("POST", "/api/admin/messages/import?preserve_ids=true&on_conflict=overwrite", strings.NewReader(
+		`{"id":"1","topic":"t","body":"b","author":"foo"}`+"\n"+`{"id":"2","topic":"t","body":"b","author":"foo"}`))
+	w := httptest.NewRecorder()
+
+	ImportMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var report services.ImportReport
+
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if report.Overwritten != 2 || strings.Join(report.Lines[0].Kept, ",") != "reactions" || strings.Join(report.Lines[1].Kept, ",") != "attachments" {
+		t.Errorf("Expected the kept reactions and attachments to be reported, got %+v", report)
+	}
+
+	if m := ctx.MessageService.MessageRepository.FindByID("2"); len(m.Attachments) != 1 {
+		t.Errorf("Expected the attachment to be kept, got %+v", m)
+	}
+}
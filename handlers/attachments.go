@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Room for the multipart headers around an upload of the largest size allowed
+const multipartOverhead = 64 << 10
+
+// Attaches the file in the "file" field of a multipart/form-data body to a
+// message. The response has the new attachment, in the format selected by
+// Accept. Bodies far larger than limits.attachment_max_size are cut off
+// returns:
+//   200 success: if the file was attached
+//   400 bad request: if the body isn't multipart/form-data with a file, or is much too large
+//   401 unauthorized: if CurrentUser isn't the owner of the message
+//   404 not found: if message wasn't found
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if the file is too large or its name isn't valid, or the message has too many attachments
+//   423 locked: if the message is in a locked thread
+func AddAttachment(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	if max := models.CurrentLimits.AttachmentMaxSize; max > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(max)+multipartOverhead)
+	}
+
+	file, header, err := r.FormFile("file")
+
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := ctx.MessageService.AddAttachment(vars["id"], header.Filename, file, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: attachment, xmlName: "attachment"})
+}
+
+// Downloads the content of an attachment. Range requests are supported, and
+// the ETag is the SHA-256 of the content. It is always served as a download,
+// so browsers won't render it as part of the site
+// returns:
+//   200 success: with the content
+//   206 partial content: with the range asked for
+//   304 not modified: if If-None-Match has the ETag
+//   403 forbidden: if CurrentUser can't read the message
+//   404 not found: if message or attachment wasn't found
+//   416 range not satisfiable: if Range is outside the content
+func GetAttachment(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	attachment, content, err := ctx.MessageService.GetAttachment(vars["id"], vars["attachment_id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+
+	http.ServeContent(w, r, attachment.Name, attachment.CreatedAt, content)
+}
+
+// Removes an attachment from a message. The content is deleted once no other
+// message has the same content attached
+// returns:
+//   200 success: if the attachment was removed
+//   401 unauthorized: if CurrentUser isn't the owner of the message
+//   404 not found: if message or attachment wasn't found
+//   423 locked: if the message is in a locked thread
+func DeleteAttachment(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.DeleteAttachment(vars["id"], vars["attachment_id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
+
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/blobs"
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Like setupContext, with attachments kept in a temporary directory, which
+// is returned too
+func setupAttachmentContext(t *testing.T) (*context.Context, *context.Session, string) {
+	ctx, session := setupContext()
+	dir := t.TempDir()
+	ctx.MessageService.Blobs = &blobs.FileStore{Dir: dir}
+
+	return ctx, session, dir
+}
+
+// Uploads content as a file named name to the message with id
+func upload(ctx *context.Context, session *context.Session, id, name, content string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", name)
+	part.Write([]byte(content))
+	writer.Close()
+
+	r, w := setupRequestWithContent(body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	AddAttachment(ctx, session, w, r, map[string]string{"id": id})
+
+	return w
+}
+
+// Returns the number of blobs stored below dir
+func countBlobs(t *testing.T, dir string) int {
+	blobs, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+	if err != nil {
+		t.Fatalf("Error listing blobs: %v", err)
+	}
+
+	return len(blobs)
+}
+
+func TestAddAttachment(t *testing.T) {
+	ctx, session, dir := setupAttachmentContext(t)
+
+	resp := upload(ctx, session, "1", "hello.txt", "Hello World").Result()
+	assertStatusCode(t, resp, 200)
+
+	var attachment models.Attachment
+	if err := json.NewDecoder(resp.Body).Decode(&attachment); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	assertEqual(t, attachment.ID, "1", "ID is correct")
+	assertEqual(t, attachment.Name, "hello.txt", "Name is correct")
+	assertEqual(t, attachment.ContentType, "text/plain; charset=utf-8", "Content type is sniffed")
+	assertEqual(t, attachment.SHA256, "a591a6d40bf420404a011733cfb7b190d62c65bf0bcda32b57b277d9ad9f146e", "Digest is correct")
+
+	// The same content is stored once
+	assertStatusCode(t, upload(ctx, session, "1", "copy.txt", "Hello World").Result(), 200)
+
+	if n := countBlobs(t, dir); n != 1 {
+		t.Errorf("Expected 1 blob, got %d", n)
+	}
+
+	message, _ := ctx.MessageService.GetMessage("1", fooUser)
+	if len(message.Attachments) != 2 || message.Attachments[1].ID != "2" {
+		t.Errorf("Expected the message to have 2 attachments, got %v", message.Attachments)
+	}
+}
+
+func TestAddAttachment_Errors(t *testing.T) {
+	ctx, session, _ := setupAttachmentContext(t)
+
+	defer func(limits models.Limits) { models.CurrentLimits = limits }(models.CurrentLimits)
+	models.CurrentLimits.AttachmentMaxSize = 4
+	models.CurrentLimits.MaxAttachments = 1
+
+	resp := upload(ctx, session, "1", "hello.txt", "Hello World").Result()
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "file", "File must be at most 4 bytes")
+
+	assertStatusCode(t, upload(ctx, session, "1", "a.txt", "a").Result(), 200)
+
+	resp = upload(ctx, session, "1", "b.txt", "b").Result()
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "attachments", "Attachment can be given at most 1 times")
+
+	assertStatusCode(t, upload(ctx, session, "2", "a.txt", "a").Result(), 401)
+	assertStatusCode(t, upload(ctx, session, "42", "a.txt", "a").Result(), 404)
+
+	r, w := setupRequestWithContent(bytes.NewBufferString(`{"topic":"t"}`))
+	AddAttachment(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 400)
+}
+
+func TestAttachments_Locked(t *testing.T) {
+	ctx, session, _ := setupAttachmentContext(t)
+	moderator := models.User{Username: "moderator", Admin: true}
+
+	assertStatusCode(t, upload(ctx, session, "1", "a.txt", "a").Result(), 200)
+	ctx.MessageService.LockMessage("1", moderator)
+
+	resp := upload(ctx, session, "1", "b.txt", "b").Result()
+	assertStatusCode(t, resp, 423)
+	assertProblem(t, resp, problemTypeLocked)
+
+	r, w := setupRequest()
+	DeleteAttachment(ctx, session, w, r, map[string]string{"id": "1", "attachment_id": "1"})
+	assertStatusCode(t, w.Result(), 423)
+	assertProblem(t, w.Result(), problemTypeLocked)
+}
+
+func TestGetAttachment(t *testing.T) {
+	ctx, session, _ := setupAttachmentContext(t)
+	upload(ctx, session, "1", "hello.txt", "Hello World")
+
+	r, w := setupRequest()
+	r.Header.Set("Range", "bytes=6-")
+	GetAttachment(ctx, &context.Session{CurrentUser: barUser}, w, r, map[string]string{"id": "1", "attachment_id": "1"})
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 206)
+	assertEqual(t, resp.Header.Get("Content-Disposition"), `attachment; filename=hello.txt`, "Content-Disposition is correct")
+
+	content, _ := io.ReadAll(resp.Body)
+	assertEqual(t, string(content), "World", "Content is the range asked for")
+
+	r, w = setupRequest()
+	GetAttachment(ctx, session, w, r, map[string]string{"id": "1", "attachment_id": "2"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestAttachmentBlobsAreCleanedUp(t *testing.T) {
+	ctx, session, dir := setupAttachmentContext(t)
+	admin := &context.Session{CurrentUser: models.User{Username: "admin", Admin: true}}
+
+	upload(ctx, session, "1", "a.txt", "shared")
+	upload(ctx, session, "1", "b.txt", "only in 1")
+	upload(ctx, &context.Session{CurrentUser: barUser}, "2", "a.txt", "shared")
+
+	r, w := setupRequest()
+	DeleteAttachment(ctx, session, w, r, map[string]string{"id": "1", "attachment_id": "2"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if n := countBlobs(t, dir); n != 1 {
+		t.Errorf("Expected the blob of the removed attachment to be deleted, got %d blobs", n)
+	}
+
+	// The shared blob is kept while message 2 has it, also in the trash
+	r, w = setupRequest()
+	PurgeMessage(ctx, admin, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	ctx.MessageService.DeleteMessage("2", barUser)
+
+	if n := countBlobs(t, dir); n != 1 {
+		t.Errorf("Expected the shared blob to be kept, got %d blobs", n)
+	}
+
+	r, w = setupRequest()
+	PurgeMessage(ctx, admin, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if n := countBlobs(t, dir); n != 0 {
+		t.Errorf("Expected no blobs once the messages are purged, got %d", n)
+	}
+}
+
+// A store calling afterPut once something is stored, and then taking its
+// time, so the attachment isn't stored yet for a while
+type slowStore struct {
+	*blobs.FileStore
+	afterPut func()
+}
+
+func (s *slowStore) Put(key string, r io.Reader) error {
+	err := s.FileStore.Put(key, r)
+
+	if s.afterPut != nil {
+		s.afterPut()
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return err
+}
+
+func TestAttachmentBlobsAreKeptForConcurrentUploads(t *testing.T) {
+	ctx, session, dir := setupAttachmentContext(t)
+	upload(ctx, session, "1", "a.txt", "shared")
+
+	// The only attachment with the content is removed while the same content
+	// is uploaded again
+	var wg sync.WaitGroup
+	store := &slowStore{FileStore: ctx.MessageService.Blobs.(*blobs.FileStore)}
+	store.afterPut = func() {
+		store.afterPut = nil
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			ctx.MessageService.DeleteAttachment("1", "1", fooUser)
+		}()
+	}
+	ctx.MessageService.Blobs = store
+
+	assertStatusCode(t, upload(ctx, &context.Session{CurrentUser: barUser}, "2", "a.txt", "shared").Result(), 200)
+	wg.Wait()
+
+	if n := countBlobs(t, dir); n != 1 {
+		t.Errorf("Expected the blob of the new attachment to be kept, got %d blobs", n)
+	}
+}
+
+func TestAddAttachment_KeepsChangesMeanwhile(t *testing.T) {
+	ctx, session, dir := setupAttachmentContext(t)
+	store := &slowStore{FileStore: ctx.MessageService.Blobs.(*blobs.FileStore)}
+	ctx.MessageService.Blobs = store
+
+	// Deleted while the content is stored
+	store.afterPut = func() {
+		store.afterPut = nil
+		ctx.MessageService.DeleteMessage("1", fooUser)
+	}
+
+	w := upload(ctx, session, "1", "a.txt", "content")
+	assertStatusCode(t, w.Result(), 404)
+
+	if m := ctx.MessageService.MessageRepository.FindByID("1"); m == nil || !m.IsDeleted() || len(m.Attachments) != 0 {
+		t.Errorf("Expected the message to stay in the trash, got %+v", m)
+	}
+
+	// Purged while the content is stored
+	var wg sync.WaitGroup
+	store.afterPut = func() {
+		store.afterPut = nil
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			ctx.MessageService.PurgeMessage("2")
+		}()
+	}
+
+	w = upload(ctx, &context.Session{CurrentUser: barUser}, "2", "a.txt", "content")
+	wg.Wait()
+	assertStatusCode(t, w.Result(), 404)
+
+	if m := ctx.MessageService.MessageRepository.FindByID("2"); m != nil {
+		t.Errorf("Expected the message to stay purged, got %+v", m)
+	}
+
+	if n := countBlobs(t, dir); n != 0 {
+		t.Errorf("Expected the content to be released, got %d blobs", n)
+	}
+}
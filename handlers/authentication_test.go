@@ -10,6 +10,7 @@ import (
 	"github.com/dennis/hello_go/models"
 	"github.com/dennis/hello_go/repositories"
 	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
 )
 
 var dennis models.User = models.User{Username: "foo", AuthToken: "authtokendennis"}
@@ -25,7 +26,7 @@ func setup(authHeader string) (*context.Context, *http.Request) {
 
 	ctx := &context.Context{
 		AuthenticationService: services.AuthenticationService{UserRepository: &userRepository},
-		MessageService:        services.MessageService{MessageRepository: &messageRepository},
+		MessageService:        servicestest.NewMessageService(&messageRepository, nil, &userRepository),
 	}
 
 	r := httptest.NewRequest("GET", "/this/doesnt/matter", nil)
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Lists the users CurrentUser has muted, ordered by username
+// returns:
+//   200 success: with the usernames
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetMuted(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: ctx.MessageService.GetMuted(session.CurrentUser), xmlName: "username"})
+}
+
+// Mutes a user, leaving their messages out of every list, search and stream
+// of CurrentUser. Muting a user twice does nothing. The response has the
+// muted users
+// returns:
+//   200 success: if the user is now muted
+//   404 not found: if the user was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if CurrentUser is muting themselves
+func Mute(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	muted, err := ctx.MessageService.Mute(vars["username"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: muted, xmlName: "username"})
+}
+
+// Unmutes a user. Unmuting a user who isn't muted does nothing. The response
+// is as for Mute
+// returns:
+//   200 success: if the user isn't muted now
+//   406 not acceptable: if Accept doesn't allow any format we support
+func Unmute(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: ctx.MessageService.Unmute(vars["username"], session.CurrentUser), xmlName: "username"})
+}
+
+// Lists the users CurrentUser has blocked, ordered by username
+// returns:
+//   200 success: with the usernames
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetBlocked(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: ctx.MessageService.GetBlocked(session.CurrentUser), xmlName: "username"})
+}
+
+// Blocks a user. Their messages are hidden like when muted, and they can't
+// reply to messages by CurrentUser, mention them or send them direct
+// messages. Blocking a user twice does nothing. The response has the blocked
+// users
+// returns:
+//   200 success: if the user is now blocked
+//   404 not found: if the user was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if CurrentUser is blocking themselves
+func Block(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	blocked, err := ctx.MessageService.Block(vars["username"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: blocked, xmlName: "username"})
+}
+
+// Unblocks a user. Unblocking a user who isn't blocked does nothing. The
+// response is as for Block
+// returns:
+//   200 success: if the user isn't blocked now
+//   406 not acceptable: if Accept doesn't allow any format we support
+func Unblock(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: ctx.MessageService.Unblock(vars["username"], session.CurrentUser), xmlName: "username"})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+)
+
+func assertUsernames(t *testing.T, resp *http.Response, expected []string) {
+	assertStatusCode(t, resp, 200)
+
+	var usernames []string
+	if err := json.NewDecoder(resp.Body).Decode(&usernames); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if !reflect.DeepEqual(usernames, expected) {
+		t.Errorf("Expected usernames %v, got %v", expected, usernames)
+	}
+}
+
+func TestMutedUsers(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Hi","body":"Hi @bar","recipients":["bar"]}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	Mute(ctx, barSession, w, r, map[string]string{"username": "foo"})
+	assertUsernames(t, w.Result(), []string{"foo"})
+
+	r, w = setupRequest()
+	GetMuted(ctx, barSession, w, r, noVars)
+	assertUsernames(t, w.Result(), []string{"foo"})
+
+	assertEqual(t, getMessageIDs(t, ctx, barSession, ""), "2", "Messages by muted users are left out")
+	assertEqual(t, getMessageIDs(t, ctx, barSession, "author=foo"), "", "Messages by muted users are left out of searches")
+	assertEqual(t, getMessageIDs(t, ctx, session, ""), "1,2,3", "Muting only affects the user muting")
+
+	if inbox := getInbox(t, ctx, barSession); inbox.Total != 0 || inbox.Unread != 0 {
+		t.Errorf("Expected direct messages by muted users to be left out, got %+v", inbox)
+	}
+
+	if notifications := getNotifications(t, ctx, barSession); notifications.Total != 0 {
+		t.Errorf("Expected mentions by muted users to be left out, got %+v", notifications)
+	}
+
+	// Muted users can still mention and send messages to those muting them
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Hi","body":"Hi again @bar","parent_id":"2"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	Unmute(ctx, barSession, w, r, map[string]string{"username": "foo"})
+	assertUsernames(t, w.Result(), []string{})
+
+	if inbox := getInbox(t, ctx, barSession); inbox.Total != 1 {
+		t.Errorf("Expected the direct message to be back, got %+v", inbox)
+	}
+
+	if notifications := getNotifications(t, ctx, barSession); notifications.Total != 2 {
+		t.Errorf("Expected both mentions to be back, got %+v", notifications)
+	}
+
+	r, w = setupRequest()
+	Mute(ctx, barSession, w, r, map[string]string{"username": "nobody"})
+	assertStatusCode(t, w.Result(), 404)
+	assertProblem(t, w.Result(), problemTypeNotFound)
+
+	r, w = setupRequest()
+	Mute(ctx, barSession, w, r, map[string]string{"username": "bar"})
+	assertStatusCode(t, w.Result(), 422)
+	assertProblem(t, w.Result(), problemTypeNotValid)
+}
+
+func TestBlockedUsers(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w := setupRequest()
+	Block(ctx, barSession, w, r, map[string]string{"username": "foo"})
+	assertUsernames(t, w.Result(), []string{"foo"})
+
+	r, w = setupRequest()
+	GetBlocked(ctx, barSession, w, r, noVars)
+	assertUsernames(t, w.Result(), []string{"foo"})
+
+	assertEqual(t, getMessageIDs(t, ctx, barSession, ""), "2", "Messages by blocked users are left out")
+
+	// Blocked users can't reply to, send direct messages to or mention the
+	// user blocking them
+	for _, body := range []string{
+		`{"topic":"Re","body":"Reply","parent_id":"2"}`,
+		`{"topic":"Hi","body":"Hi","recipients":["bar"]}`,
+	} {
+		r, w = setupRequestWithContent(strings.NewReader(body))
+		CreateMessage(ctx, session, w, r, noVars)
+		assertStatusCode(t, w.Result(), 403)
+		assertProblem(t, w.Result(), problemTypeBlocked)
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Hi","body":"Hi @Bar"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 422)
+	assertProblem(t, w.Result(), problemTypeNotValid)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Topic1","body":"Hi @bar"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 422)
+
+	// The user blocking can still reply to those they have blocked
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Go away @foo","parent_id":"1"}`))
+	CreateMessage(ctx, barSession, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	Unblock(ctx, barSession, w, r, map[string]string{"username": "foo"})
+	assertUsernames(t, w.Result(), []string{})
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Sorry @bar","parent_id":"2"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Channels can be JSON, XML or MessagePack, as selected by Accept
+func channelRepresentation(channel *models.Channel) representation {
+	return representation{value: channel, xmlName: "channel"}
+}
+
+func channelsRepresentation(channels []models.Channel) representation {
+	return representation{value: channels, xmlName: "channel"}
+}
+
+// Returns an array with the channels CurrentUser is a member of
+// returns:
+//   200 success: if successful
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetChannels(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, channelsRepresentation(ctx.ChannelService.GetChannels(session.CurrentUser)))
+}
+
+// Returns a specific channel
+// returns:
+//   200 success: if successful
+//   403 forbidden: if CurrentUser isn't a member of the channel
+//   404 not found: if channel was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetChannel(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	channel, err := ctx.ChannelService.GetChannel(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, channelRepresentation(channel))
+}
+
+// Creates a new channel owned by CurrentUser, who is always a member. ID is
+// assigned by service
+// returns:
+//   200 success: if channel was successful created
+//   400 bad request: in case of errors (reading the body)
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided channel isn't valid, or a member doesn't exist
+func CreateChannel(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	var channel models.Channel
+
+	if err := decodeBody(r, &channel); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	storedChannel, err := ctx.ChannelService.CreateChannel(channel, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, channelRepresentation(storedChannel))
+}
+
+// Returns the messages in a channel, like GetMessages
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   403 forbidden: if CurrentUser isn't a member of the channel
+//   404 not found: if channel was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetChannelMessages(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, listMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	messages, total, err := ctx.MessageService.GetChannelMessages(vars["id"], page, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	unread, err := ctx.MessageService.CountUnread(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("X-Unread-Count", strconv.Itoa(unread))
+	writeRepresentation(w, r, session.Locale, mediaType, messagesRepresentation(ctx.MessageService.Views(messages, session.CurrentUser)))
+}
+
+// Creates a new message in a channel, like CreateMessage. The channel_id of
+// the body is ignored
+// returns:
+//   200 success: if message was successful created
+//   202 accepted: if the message is held for review by a moderator
+//   400 bad request: in case of errors (reading the body)
+//   403 forbidden: if CurrentUser isn't a member of the channel, or someone the message would reach has blocked them
+//   404 not found: if channel was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided message isn't valid, mentions someone who has blocked CurrentUser, or is rejected by moderation
+//   423 locked: if the message replied to is in a locked thread
+func CreateChannelMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	if _, err := ctx.ChannelService.GetChannel(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	var message models.Message
+
+	if err := decodeBody(r, &message); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	message.ChannelID = vars["id"]
+
+	storedMessage, err := ctx.MessageService.CreateMessage(message, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*storedMessage, session.CurrentUser)))
+}
+
+// Makes a user a member of a channel. Adding an existing member does nothing
+// returns:
+//   200 success: with the channel, if the user is now a member
+//   401 unauthorized: if CurrentUser isn't the owner of the channel
+//   404 not found: if the channel or the user wasn't found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func AddChannelMember(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	channel, err := ctx.ChannelService.AddMember(vars["id"], vars["username"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, channelRepresentation(channel))
+}
+
+// Removes a user from a channel. The owner may remove others, and members
+// may remove themselves
+// returns:
+//   200 success: with the channel, if the user is no longer a member
+//   401 unauthorized: if CurrentUser isn't the owner, and isn't removing themselves
+//   403 forbidden: if removing the owner
+//   404 not found: if the channel wasn't found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func RemoveChannelMember(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	channel, err := ctx.ChannelService.RemoveMember(vars["id"], vars["username"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, channelRepresentation(channel))
+}
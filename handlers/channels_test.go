@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Creates a channel owned by foo, and returns its ID
+func setupChannel(t *testing.T, ctx *context.Context) string {
+	channel, err := ctx.ChannelService.CreateChannel(models.Channel{Name: "Private"}, fooUser)
+
+	if err != nil {
+		t.Fatalf("Error creating channel: %v", err)
+	}
+
+	return channel.ID
+}
+
+func assertChannelJSON(t *testing.T, resp *http.Response) *models.Channel {
+	var channel models.Channel
+
+	if err := json.NewDecoder(resp.Body).Decode(&channel); err != nil {
+		t.Errorf("Error decoding json-response: %v", err)
+		return nil
+	}
+
+	return &channel
+}
+
+func TestCreateChannel(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"name":"Go","description":"Gophers","owner":"bar","members":["bar","bar"]}`))
+
+	CreateChannel(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 200)
+
+	channel := assertChannelJSON(t, resp)
+	if channel != nil {
+		assertEqual(t, channel.Owner, "foo", "Owner is correct")
+		assertEqual(t, strings.Join(channel.Members, ","), "foo,bar", "Members are correct")
+	}
+}
+
+func TestCreateChannel_WithUnknownMember(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"name":"Go","members":["nobody"]}`))
+
+	CreateChannel(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "members", "Member does not exist")
+}
+
+func TestGetChannels_OnlyWhereMember(t *testing.T) {
+	ctx, _ := setupContext()
+	setupChannel(t, ctx)
+
+	r, w := setupRequest()
+
+	GetChannels(ctx, &context.Session{CurrentUser: barUser}, w, r, noVars)
+
+	var channels []models.Channel
+	json.NewDecoder(w.Result().Body).Decode(&channels)
+
+	if len(channels) != 1 || channels[0].ID != models.DefaultChannelID {
+		t.Errorf("Expected only the default channel, got %+v", channels)
+	}
+}
+
+func TestChannelMessages_RequireMembership(t *testing.T) {
+	ctx, session := setupContext()
+	id := setupChannel(t, ctx)
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"topic","body":"body","channel_id":"elsewhere"}`))
+
+	CreateChannelMessage(ctx, session, w, r, map[string]string{"id": id})
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 200)
+	assertEqual(t, assertMessageJSON(t, resp).ChannelID, id, "Channel is correct")
+
+	// bar isn't a member
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w = setupRequest()
+	GetChannelMessages(ctx, barSession, w, r, map[string]string{"id": id})
+	assertStatusCode(t, w.Result(), 403)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"topic","body":"body"}`))
+	CreateChannelMessage(ctx, barSession, w, r, map[string]string{"id": id})
+	assertStatusCode(t, w.Result(), 403)
+
+	r, w = setupRequest()
+	GetMessages(ctx, barSession, w, r, noVars)
+	assertEqual(t, w.Result().Header.Get("X-Total-Count"), "2", "Messages of other channels are hidden")
+
+	r, w = setupRequest()
+	GetChannelMessages(ctx, session, w, r, map[string]string{"id": "nope"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestChannelMembers(t *testing.T) {
+	ctx, session := setupContext()
+	id := setupChannel(t, ctx)
+	barSession := &context.Session{CurrentUser: barUser}
+	vars := map[string]string{"id": id, "username": "bar"}
+
+	// Only the owner may add members
+	r, w := setupRequest()
+	AddChannelMember(ctx, barSession, w, r, vars)
+	assertStatusCode(t, w.Result(), 401)
+
+	r, w = setupRequest()
+	AddChannelMember(ctx, session, w, r, vars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	GetChannel(ctx, barSession, w, r, vars)
+	assertStatusCode(t, w.Result(), 200)
+
+	// Members may leave, but the owner can't be removed
+	r, w = setupRequest()
+	RemoveChannelMember(ctx, barSession, w, r, map[string]string{"id": id, "username": "foo"})
+	assertStatusCode(t, w.Result(), 401)
+
+	r, w = setupRequest()
+	RemoveChannelMember(ctx, session, w, r, map[string]string{"id": id, "username": "foo"})
+	assertStatusCode(t, w.Result(), 403)
+
+	r, w = setupRequest()
+	RemoveChannelMember(ctx, barSession, w, r, vars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	GetChannel(ctx, barSession, w, r, vars)
+	assertStatusCode(t, w.Result(), 403)
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Drafts are only shown to their author, so there is nothing to add to them
+func draftRepresentation(draft models.Draft) representation {
+	return representation{value: draft, xmlName: "draft"}
+}
+
+// Returns the drafts of CurrentUser, scheduled or not, in the format
+// selected by Accept. Supports limit and offset like GetMessages
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetDrafts(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	drafts, total := ctx.MessageService.GetDrafts(page, session.CurrentUser)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: drafts, xmlName: "draft"})
+}
+
+// Returns a single draft of CurrentUser
+// returns:
+//   200 success: if successful
+//   404 not found: if CurrentUser has no draft with the id
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetDraft(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	draft, err := ctx.MessageService.GetDraft(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, draftRepresentation(*draft))
+}
+
+// Saves a draft written by CurrentUser. It has the fields of a message, but
+// only needs a topic and body once it is scheduled with publish_at. Formats
+// are handled as for CreateMessage
+// returns:
+//   200 success: with the saved draft
+//   400 bad request: in case of errors (reading the body)
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided draft isn't valid, or publish_at has passed
+func CreateDraft(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	var draft models.Draft
+
+	if err := decodeBody(r, &draft); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	stored, err := ctx.MessageService.CreateDraft(draft, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, draftRepresentation(*stored))
+}
+
+// Replaces a draft of CurrentUser, including when it is published. Leaving
+// out publish_at unschedules it
+// returns:
+//   200 success: with the updated draft
+//   400 bad request: in case of errors (reading the body)
+//   404 not found: if CurrentUser has no draft with the id, ie because it is published
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided draft isn't valid, or publish_at has passed
+func UpdateDraft(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	var draft models.Draft
+
+	if err := decodeBody(r, &draft); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	draft.ID = vars["id"]
+	stored, err := ctx.MessageService.UpdateDraft(draft, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, draftRepresentation(*stored))
+}
+
+// Deletes a draft of CurrentUser, so a scheduled draft is never published
+// returns:
+//   200 success: if the draft was deleted
+//   404 not found: if CurrentUser has no draft with the id
+func DeleteDraft(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.DeleteDraft(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
+
+// Publishes a draft of CurrentUser now, like CreateMessage, and deletes it
+// returns:
+//   200 success: with the published message
+//   202 accepted: if the message is held for review by a moderator. The draft is deleted
+//   403 forbidden: if CurrentUser isn't a member of the channel, or someone the message would reach has blocked them
+//   404 not found: if CurrentUser has no draft with the id
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if the draft isn't a valid message, mentions someone who has blocked CurrentUser, or is rejected by moderation. It is kept then
+//   423 locked: if the draft replies to a message in a locked thread. It is kept then
+func PublishDraft(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	message, err := ctx.MessageService.PublishDraft(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*message, session.CurrentUser)))
+}
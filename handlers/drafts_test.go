@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+func assertDraftJSON(t *testing.T, resp *http.Response) models.Draft {
+	t.Helper()
+	assertStatusCode(t, resp, 200)
+
+	var draft models.Draft
+	if err := json.NewDecoder(resp.Body).Decode(&draft); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return draft
+}
+
+func TestDrafts(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"body":"Half done","tags":["#Ideas"]}`))
+	CreateDraft(ctx, session, w, r, noVars)
+	draft := assertDraftJSON(t, w.Result())
+
+	assertEqual(t, draft.Author, "foo", "Author of the draft")
+	assertEqual(t, strings.Join(draft.Tags, ","), "ideas", "Tags of the draft")
+
+	// Drafts don't show up as messages, and only their author sees them
+	r, w = setupRequest()
+	GetMessages(ctx, session, w, r, noVars)
+	assertEqual(t, w.Result().Header.Get("X-Total-Count"), "2", "Messages")
+
+	r, w = setupRequest()
+	GetDrafts(ctx, barSession, w, r, noVars)
+	assertEqual(t, w.Result().Header.Get("X-Total-Count"), "0", "Drafts of bar")
+
+	r, w = setupRequest()
+	GetDraft(ctx, barSession, w, r, map[string]string{"id": draft.ID})
+	assertStatusCode(t, w.Result(), 404)
+
+	r, w = setupRequest()
+	GetDraft(ctx, session, w, r, map[string]string{"id": draft.ID})
+	assertEqual(t, assertDraftJSON(t, w.Result()).Body, "Half done", "Body of the draft")
+
+	// It can't be published without a topic, and is kept then
+	r, w = setupRequest()
+	PublishDraft(ctx, session, w, r, map[string]string{"id": draft.ID})
+	assertStatusCode(t, w.Result(), 422)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Done","body":"All done"}`))
+	UpdateDraft(ctx, session, w, r, map[string]string{"id": draft.ID})
+	assertEqual(t, assertDraftJSON(t, w.Result()).Topic, "Done", "Topic of the updated draft")
+
+	r, w = setupRequest()
+	PublishDraft(ctx, session, w, r, map[string]string{"id": draft.ID})
+	message := assertMessageJSON(t, w.Result())
+	assertMessage(t, message, "foo", "Done", "All done", "3")
+
+	r, w = setupRequest()
+	GetDraft(ctx, session, w, r, map[string]string{"id": draft.ID})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestScheduledDrafts(t *testing.T) {
+	ctx, session := setupContext()
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx.MessageService.Clock = func() time.Time { return now }
+	ctx.MessageService.Events = &services.EventBus{}
+
+	events, unsubscribe := ctx.MessageService.Events.Subscribe(10)
+	defer unsubscribe()
+
+	create := func(body string) *http.Response {
+		r, w := setupRequestWithContent(strings.NewReader(body))
+		CreateDraft(ctx, session, w, r, noVars)
+		return w.Result()
+	}
+
+	resp := create(`{"topic":"Too late","body":"b","publish_at":"2020-01-01T11:00:00Z"}`)
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "publish_at", "Publish at must not be before 2020-01-01T12:00:00Z")
+
+	assertStatusCode(t, create(`{"body":"No topic","publish_at":"2020-01-01T13:00:00Z"}`), 422)
+
+	scheduled := assertDraftJSON(t, create(`{"topic":"Later","body":"Hi @bar","publish_at":"2020-01-01T13:00:00Z"}`))
+	cancelled := assertDraftJSON(t, create(`{"topic":"Never","body":"b","publish_at":"2020-01-01T13:00:00Z"}`))
+	failing := assertDraftJSON(t, create(`{"topic":"Reply","body":"b","parent_id":"2","publish_at":"2020-01-01T13:00:00Z"}`))
+
+	r, w := setupRequest()
+	DeleteDraft(ctx, session, w, r, map[string]string{"id": cancelled.ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	if published := ctx.MessageService.PublishDueDrafts(); published != 0 {
+		t.Errorf("Expected nothing to be due yet, published %d", published)
+	}
+
+	// The parent is gone by the time the reply is due
+	ctx.MessageService.DeleteMessage("2", barUser)
+	<-events
+
+	now = now.Add(time.Hour)
+
+	if published := ctx.MessageService.PublishDueDrafts(); published != 1 {
+		t.Errorf("Expected 1 draft to be published, published %d", published)
+	}
+
+	if e := <-events; e.Type != services.EventCreated || e.Message.Topic != "Later" || !e.Message.CreatedAt.Equal(now) {
+		t.Errorf("Expected an event for the published message, got %+v", e)
+	}
+
+	if n := ctx.MessageService.GetNotifications(services.Page{}, barUser); n.Total != 1 {
+		t.Errorf("Expected bar to be notified, got %+v", n)
+	}
+
+	r, w = setupRequest()
+	GetDrafts(ctx, session, w, r, noVars)
+	assertEqual(t, w.Result().Header.Get("X-Total-Count"), "1", "Drafts left")
+
+	r, w = setupRequest()
+	GetDraft(ctx, session, w, r, map[string]string{"id": failing.ID})
+	kept := assertDraftJSON(t, w.Result())
+
+	if kept.PublishAt != nil || kept.PublishError != "Parent does not exist" {
+		t.Errorf("Expected the failing draft to be unscheduled with an error, got %+v", kept)
+	}
+
+	r, w = setupRequest()
+	GetDraft(ctx, session, w, r, map[string]string{"id": scheduled.ID})
+	assertStatusCode(t, w.Result(), 404)
+}
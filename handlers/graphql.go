@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/graphqlapi"
+)
+
+// How often a comment is sent on idle subscriptions, so proxies don't close
+// the connection
+const subscriptionKeepAlive = 15 * time.Second
+
+// Reads a GraphQL request from the query parameters of a GET, or the body of
+// a POST
+func parseGraphQLRequest(r *http.Request) (graphqlapi.Request, error) {
+	var req graphqlapi.Request
+
+	if r.Method != "GET" {
+		return req, decodeBody(r, &req)
+	}
+
+	query := r.URL.Query()
+	req.Query = query.Get("query")
+	req.OperationName = query.Get("operationName")
+
+	if variables := query.Get("variables"); len(variables) > 0 {
+		if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+			return req, &malformedRequestError{fmt.Errorf("variables: %v", err)}
+		}
+	}
+
+	return req, nil
+}
+
+// Runs a GraphQL query, mutation or subscription, see graphqlapi. Queries and
+// mutations are answered with a JSON document with data and errors.
+// Subscriptions must be requested with Accept: text/event-stream, and each
+// result is sent as a "next" event, followed by "complete" when it ends
+// returns:
+//   200 success: if the request could be parsed. GraphQL errors are in the body
+//   400 bad request: if the body or the variables couldn't be decoded
+//   405 method not allowed: for mutations and subscriptions sent with GET
+//   406 not acceptable: for subscriptions without Accept: text/event-stream
+//   415 unsupported media type: if Content-Type isn't one we can decode
+func GraphQL(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	req, err := parseGraphQLRequest(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	operation, result := graphqlapi.Parse(req)
+
+	if result != nil {
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	// GET must not change anything, so caches and prefetching are safe
+	if r.Method == "GET" && operation.Type != graphqlapi.OperationQuery {
+		MethodNotAllowed(w, r)
+		return
+	}
+
+	if operation.Type == graphqlapi.OperationSubscription {
+		if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			handleError(w, r, session.Locale, &notAcceptableError{[]string{"text/event-stream"}})
+			return
+		}
+
+		streamSubscription(ctx, session, w, r, operation)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	json.NewEncoder(w).Encode(operation.Execute(r.Context(), ctx, session))
+}
+
+// Sends the results of a subscription as server-sent events, until the
+// client disconnects or the subscription ends
+func streamSubscription(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, operation *graphqlapi.Operation) {
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flush()
+
+	results := operation.Subscribe(r.Context(), ctx, session)
+
+	// The results must be read until the channel is closed, which happens
+	// shortly after the request context is done
+	defer func() {
+		for range results {
+		}
+	}()
+
+	keepAlive := time.NewTicker(subscriptionKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flush()
+		case result, ok := <-results:
+			if !ok {
+				fmt.Fprint(w, "event: complete\ndata:\n\n")
+				flush()
+				return
+			}
+
+			data, _ := json.Marshal(result)
+			fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+			flush()
+		}
+	}
+}
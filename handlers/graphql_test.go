@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/services"
+)
+
+func TestGraphQL_MutationWithGet(t *testing.T) {
+	ctx, session := setupContext()
+
+	r := httptest.NewRequest("GET", "/api/graphql?query="+`mutation{deleteMessage(id:"1")}`, nil)
+	w := httptest.NewRecorder()
+
+	GraphQL(ctx, session, w, r, noVars)
+
+	assertStatusCode(t, w.Result(), 405)
+
+	if m, _ := ctx.MessageService.GetMessage("1", fooUser); m == nil {
+		t.Error("Expected message 1 not to be deleted")
+	}
+}
+
+func TestGraphQL_SubscriptionStream(t *testing.T) {
+	ctx, session := setupContext()
+	ctx.MessageService.Events = &services.EventBus{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		GraphQL(ctx, session, w, r, noVars)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader(`{"query":"subscription { messageCreated { topic } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	assertStatusCode(t, resp, 200)
+	assertContentType(t, resp, "text/event-stream")
+
+	// The subscription starts after the headers are sent, so keep creating
+	// messages until one arrives
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				ctx.MessageService.CreateMessage(models.Message{Topic: "Streamed", Body: "b"}, fooUser)
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && len(lines) < 2 {
+		if len(scanner.Text()) > 0 {
+			lines = append(lines, scanner.Text())
+		}
+	}
+
+	if len(lines) != 2 || lines[0] != "event: next" || !strings.Contains(lines[1], `"topic":"Streamed"`) {
+		t.Errorf("Unexpected events: %q", lines)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Returns the direct messages CurrentUser has received, with the number of
+// unread messages in total and from each sender. Supports limit and offset
+// like GetMessages
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetInbox(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	inbox := ctx.MessageService.GetInbox(page, session.CurrentUser)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(inbox.Total))
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: inbox, xmlName: "inbox"})
+}
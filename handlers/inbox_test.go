@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+func getInbox(t *testing.T, ctx *context.Context, session *context.Session) models.Inbox {
+	r, w := setupRequest()
+
+	GetInbox(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var inbox models.Inbox
+	if err := json.NewDecoder(resp.Body).Decode(&inbox); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return inbox
+}
+
+func TestDirectMessages(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Psst","body":"Secret","recipients":["bar","bar"]}`))
+	CreateMessage(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	message := assertMessageJSON(t, resp)
+	assertEqual(t, strings.Join(message.Recipients, ","), "bar", "Recipients are correct")
+	assertEqual(t, message.ChannelID, "", "Direct messages aren't in a channel")
+
+	inbox := getInbox(t, ctx, barSession)
+	if inbox.Total != 1 || inbox.Unread != 1 || len(inbox.Senders) != 1 || inbox.Senders[0].Username != "foo" || !inbox.Messages[0].Unread {
+		t.Errorf("Expected an unread message from foo, got %+v", inbox)
+	}
+
+	// Only the author and the recipients can read it
+	third := &context.Session{CurrentUser: models.User{Username: "baz"}}
+
+	r, w = setupRequest()
+	GetMessage(ctx, third, w, r, map[string]string{"id": message.ID})
+	assertStatusCode(t, w.Result(), 403)
+
+	r, w = setupRequest()
+	GetMessages(ctx, third, w, r, noVars)
+	assertEqual(t, w.Result().Header.Get("X-Total-Count"), "2", "Direct messages are hidden from others")
+
+	// The author reading it doesn't mark it as read for the recipient
+	r, w = setupRequest()
+	GetMessage(ctx, session, w, r, map[string]string{"id": message.ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	GetMessage(ctx, barSession, w, r, map[string]string{"id": message.ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	if inbox := getInbox(t, ctx, barSession); inbox.Unread != 0 || len(inbox.Senders) != 0 || inbox.Messages[0].Unread {
+		t.Errorf("Expected the message to be read, got %+v", inbox)
+	}
+
+	// The reply goes back to foo
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"re: Psst","body":"Ok","parent_id":"` + message.ID + `"}`))
+	CreateMessage(ctx, barSession, w, r, noVars)
+	assertEqual(t, strings.Join(assertMessageJSON(t, w.Result()).Recipients, ","), "foo", "Reply recipients are correct")
+
+	if inbox := getInbox(t, ctx, session); inbox.Total != 1 || inbox.Unread != 1 {
+		t.Errorf("Expected the reply in the inbox of foo, got %+v", inbox)
+	}
+}
+
+func TestDirectMessages_NotValid(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Psst","body":"Secret","recipients":["nobody"],"channel_id":"general"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 422)
+
+	problem := assertProblem(t, resp, problemTypeNotValid)
+	assertFieldError(t, problem, "recipients", "Recipient does not exist")
+	assertFieldError(t, problem, "channel_id", "Channel is not allowed here")
+}
@@ -1,101 +1,192 @@
 package handlers
 
 import (
-	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/dennis/hello_go/bulk"
 	"github.com/dennis/hello_go/context"
 	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/search"
 	"github.com/dennis/hello_go/services"
 )
 
-func handleError(w http.ResponseWriter, err error) {
-	if _, ok := err.(*services.NotFoundError); ok {
-		w.WriteHeader(http.StatusNotFound)
-	} else if serviceErr, ok := err.(*services.NotValidError); ok {
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(serviceErr.Errors)
-	} else if _, ok := err.(*services.NotOwnerError); ok {
-		w.WriteHeader(http.StatusUnauthorized)
-	} else {
-		// Catch all
-		w.WriteHeader(http.StatusBadRequest)
+// Reads the optional limit and offset query parameters
+func parsePage(r *http.Request) (services.Page, error) {
+	page := services.Page{}
+	query := r.URL.Query()
+
+	for name, value := range map[string]*int{"limit": &page.Limit, "offset": &page.Offset} {
+		if raw := query.Get(name); len(raw) > 0 {
+			n, err := strconv.Atoi(raw)
+
+			if err != nil || n < 0 {
+				return page, fmt.Errorf("%s must be a non-negative integer", name)
+			}
+
+			*value = n
+		}
+	}
+
+	return page, nil
+}
+
+// Responses can be JSON, XML or MessagePack, as selected by Accept. Messages
+// are shown as seen by CurrentUser, see MessageService.View
+func messageRepresentation(message models.ListedMessage) representation {
+	return representation{value: message, xmlName: "message"}
+}
+
+// Lists of messages can be CSV too, with the columns of the bulk export.
+// Whether they are unread, their reactions and the body as HTML are left
+// out of the CSV
+func messagesRepresentation(messages []models.ListedMessage) representation {
+	return representation{
+		value:   messages,
+		xmlName: "message",
+		csv: func(w io.Writer) error {
+			plain := make([]models.Message, 0, len(messages))
+			for _, m := range messages {
+				plain = append(plain, m.Message)
+			}
+
+			return bulk.WriteMessages(w, bulk.CSV, plain)
+		},
 	}
 }
 
-// Returns a JSON array with all the available Messages
+// Returns an array with the Messages in the channels of CurrentUser, in the
+// format selected by Accept. The limit and offset query parameters selects a
+// page of them. The total number of messages is returned in the
+// X-Total-Count header, and the number of those CurrentUser hasn't read in
+// X-Unread-Count. The other query parameters filter and sort the messages,
+// see search.Parse
 // returns:
 //   200 success: if successful
+//   400 bad request: if a query parameter is unknown or isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
 func GetMessages(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
-	messages, err := ctx.MessageService.GetMessages()
+	mediaType, err := negotiateMediaType(r, listMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	q, err := search.Parse(r.URL.Query(), "limit", "offset")
 
 	if err != nil {
-		handleError(w, err)
+		handleError(w, r, session.Locale, &malformedRequestError{err})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(messages)
+	messages, total, unread := ctx.MessageService.FindMessages(q, page, session.CurrentUser)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	w.Header().Set("X-Unread-Count", strconv.Itoa(unread))
+	writeRepresentation(w, r, session.Locale, mediaType, messagesRepresentation(ctx.MessageService.Views(messages, session.CurrentUser)))
 }
 
-// Returns a specific message as json
+// Returns a specific message in the format selected by Accept. It is marked
+// as read by CurrentUser
 // returns:
 //   200 success: if successful
-//   404 bad request: if message was not found
+//   403 forbidden: if CurrentUser isn't a member of the channel of the message, or didn't send or receive the direct message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
 func GetMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
-	message, err := ctx.MessageService.GetMessage(vars["id"])
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
 
 	if err != nil {
-		handleError(w, err)
+		handleError(w, r, session.Locale, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(message)
+	message, err := ctx.MessageService.ReadMessage(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*message, session.CurrentUser)))
 }
 
 // Creates a new Message. Will force Author to be CurrentUser. ID is assigned by
-// service.  The response will contain the message as JSON
+// service.  The body is decoded according to Content-Type, and the response
+// will contain the message in the format selected by Accept. The message is
+// posted in channel_id, or the default channel if it is left out. With
+// recipients, it is a direct message to them instead
 // returns:
 //   200 success: if message was successful created
-//   400 bad request: in case of errors (reading the json)
-//   422 unprocessable entity: if provided JSON isn't valid
+//   202 accepted: if the message is held for review by a moderator
+//   400 bad request: in case of errors (reading the body)
+//   403 forbidden: if CurrentUser isn't a member of the channel, or someone the message would reach has blocked them
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided message isn't valid, mentions someone who has blocked CurrentUser, or is rejected by moderation
+//   423 locked: if the message replied to is in a locked thread
 func CreateMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
 	var message models.Message
 
-	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
-		handleError(w, err)
+	if err := decodeBody(r, &message); err != nil {
+		handleError(w, r, session.Locale, err)
 		return
 	}
 
 	storedMessage, serviceError := ctx.MessageService.CreateMessage(message, session.CurrentUser)
 
 	if serviceError != nil {
-		handleError(w, serviceError)
+		handleError(w, r, session.Locale, serviceError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-
-	json.NewEncoder(w).Encode(storedMessage)
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*storedMessage, session.CurrentUser)))
 }
 
-// Updates the Message.
+// Updates the Message. Formats are handled as for CreateMessage
 // returns:
 //   200 success: if message was successful updated
-//   400 bad request: in case of errors (reading the json)
+//   202 accepted: if the update is held for review by a moderator
+//   400 bad request: in case of errors (reading the body)
 //   401 unauthorized: if CurrentUser isn't the owner of the message
 //   404 not found: if message wasn't found
-//   422 unprocessable entity: if provided JSON isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   415 unsupported media type: if Content-Type isn't one we can decode
+//   422 unprocessable entity: if provided message isn't valid, mentions someone who has blocked CurrentUser, or is rejected by moderation
+//   423 locked: if the message is in a locked thread
 func UpdateMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
 	id := vars["id"]
 
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
 	var message models.Message
 
-	err := json.NewDecoder(r.Body).Decode(&message)
+	err = decodeBody(r, &message)
 
 	if err != nil {
-		handleError(w, err)
+		handleError(w, r, session.Locale, err)
 		return
 	}
 
@@ -104,24 +195,24 @@ func UpdateMessage(ctx *context.Context, session *context.Session, w http.Respon
 	storedMessage, serviceError := ctx.MessageService.UpdateMessage(message, session.CurrentUser)
 
 	if serviceError != nil {
-		handleError(w, serviceError)
+		handleError(w, r, session.Locale, serviceError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(storedMessage)
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*storedMessage, session.CurrentUser)))
 }
 
-// Deletes a Message.
+// Moves a Message to the trash of CurrentUser. It can be restored with
+// RestoreMessage until it is purged
 // returns:
-//   200 success: if message was successful updated
+//   200 success: if message was moved to the trash
 //   401 unauthorized: if CurrentUser isn't the owner of the message
 //   404 not found: if message wasn't found
 func DeleteMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
 	err := ctx.MessageService.DeleteMessage(vars["id"], session.CurrentUser)
 
 	if err != nil {
-		handleError(w, err)
+		handleError(w, r, session.Locale, err)
 		return
 	}
 }
@@ -6,29 +6,41 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/markdown"
 	"github.com/dennis/hello_go/models"
 	"github.com/dennis/hello_go/repositories"
 	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
+	"github.com/dennis/hello_go/validation"
 )
 
-var message1 models.Message = models.Message{ID: "1", Author: "foo", Topic: "Topic1", Body: "Body1"}
-var message2 models.Message = models.Message{ID: "2", Author: "bar", Topic: "Topic2", Body: "Body2"}
+var message1 models.Message = models.Message{ID: "1", Author: "foo", Topic: "Topic1", Body: "Body1", ChannelID: models.DefaultChannelID}
+var message2 models.Message = models.Message{ID: "2", Author: "bar", Topic: "Topic2", Body: "Body2", ChannelID: models.DefaultChannelID}
 var fooUser models.User = models.User{Username: "foo"}
 var barUser models.User = models.User{Username: "bar"}
 
 func setupContext() (*context.Context, *context.Session) {
 	userRepository := repositories.UserRepository{}
+	userRepository.Insert(fooUser)
+	userRepository.Insert(barUser)
+
+	channelRepository := repositories.ChannelRepository{}
+	channelRepository.InsertWithID(models.DefaultChannel)
+
 	messageRepository := repositories.MessageRepository{}
 	messageRepository.Insert(message1)
 	messageRepository.Insert(message2)
 
 	return &context.Context{
 		AuthenticationService: services.AuthenticationService{UserRepository: &userRepository},
-		MessageService:        services.MessageService{MessageRepository: &messageRepository},
+		ChannelService:        services.ChannelService{ChannelRepository: &channelRepository, UserRepository: &userRepository},
+		MessageService:        servicestest.NewMessageService(&messageRepository, &channelRepository, &userRepository),
 	}, &context.Session{CurrentUser: fooUser}
 }
 
@@ -94,9 +106,31 @@ func assertMessage(t *testing.T, message *models.Message, expectedAuthor, expect
 	assertEqual(t, message.ID, expectedID, "Id is correct")
 }
 
-func includesString(haystack []string, needle string) bool {
+func assertProblem(t *testing.T, resp *http.Response, expectedType string) *Problem {
+	assertContentType(t, resp, "application/problem+json")
+
+	var problem Problem
+
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Errorf("Error decoding problem-response: %v", err)
+		return nil
+	}
+
+	assertEqual(t, problem.Type, expectedType, "Type is correct")
+
+	if problem.Status != resp.StatusCode {
+		t.Errorf("Problem status mismatch: problem=%v, response=%v", problem.Status, resp.StatusCode)
+	}
+	if len(problem.Title) == 0 || len(problem.Detail) == 0 || len(problem.Instance) == 0 {
+		t.Errorf("Problem is missing members: %+v", problem)
+	}
+
+	return &problem
+}
+
+func includesFieldError(haystack []validation.Error, field, message string) bool {
 	for _, e := range haystack {
-		if e == needle {
+		if e.Field == field && e.Message == message {
 			return true
 		}
 	}
@@ -104,9 +138,9 @@ func includesString(haystack []string, needle string) bool {
 	return false
 }
 
-func assertArrayContains(t *testing.T, haystack []string, needle, message string) {
-	if !includesString(haystack, needle) {
-		t.Errorf("Assertion '%s' not met", message)
+func assertFieldError(t *testing.T, problem *Problem, field, message string) {
+	if problem != nil && !includesFieldError(problem.Errors, field, message) {
+		t.Errorf("Expected error '%s' for field '%s', got: %v", message, field, problem.Errors)
 	}
 }
 
@@ -136,8 +170,8 @@ func TestGetMessages(t *testing.T) {
 		t.Errorf("Got %v messages expected %v", len(messages), 2)
 	} else {
 		expected_messages :=
-			(messages[0] == message1 || messages[0] == message2) &&
-				(messages[1] == message1 || messages[1] == message2)
+			(reflect.DeepEqual(messages[0], message1) || reflect.DeepEqual(messages[0], message2)) &&
+				(reflect.DeepEqual(messages[1], message1) || reflect.DeepEqual(messages[1], message2))
 
 		if !expected_messages {
 			t.Errorf("Unexpect JSON returned")
@@ -145,6 +179,42 @@ func TestGetMessages(t *testing.T) {
 	}
 }
 
+func TestGetMessages_WithLimitAndOffset(t *testing.T) {
+	ctx, session := setupContext()
+
+	r := httptest.NewRequest("GET", "/api/messages?limit=1&offset=1", nil)
+	w := httptest.NewRecorder()
+
+	GetMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 200)
+	assertEqual(t, resp.Header.Get("X-Total-Count"), "2", "X-Total-Count is correct")
+
+	var messages []models.Message
+
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		t.Errorf("Error decoding json-response: %v", err)
+	} else if len(messages) != 1 || !reflect.DeepEqual(messages[0], message2) {
+		t.Errorf("Expected only the second message, got %v", messages)
+	}
+}
+
+func TestGetMessages_WithInvalidLimit(t *testing.T) {
+	ctx, session := setupContext()
+
+	r := httptest.NewRequest("GET", "/api/messages?limit=-1", nil)
+	w := httptest.NewRecorder()
+
+	GetMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 400)
+	assertProblem(t, resp, problemTypeBadRequest)
+}
+
 func TestGetMessage_WhenMessageExists(t *testing.T) {
 	ctx, session := setupContext()
 
@@ -178,6 +248,7 @@ func TestGetMessage_WhenMessageDoesNotExists(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 404)
+	assertProblem(t, resp, problemTypeNotFound)
 }
 
 func TestCreateMessage_WithCorrectData(t *testing.T) {
@@ -198,7 +269,7 @@ func TestCreateMessage_WithCorrectData(t *testing.T) {
 		assertMessage(t, message, session.CurrentUser.Username, "topic", "body", message.ID)
 
 		// Check repository
-		storedMessage, err := ctx.MessageService.GetMessage(message.ID)
+		storedMessage, err := ctx.MessageService.GetMessage(message.ID, fooUser)
 		if storedMessage != nil && err == nil {
 			assertMessage(t, storedMessage, session.CurrentUser.Username, "topic", "body", message.ID)
 		} else {
@@ -207,6 +278,30 @@ func TestCreateMessage_WithCorrectData(t *testing.T) {
 	}
 }
 
+func TestCreateMessage_RendersBodyAsHTML(t *testing.T) {
+	ctx, session := setupContext()
+	ctx.MessageService.Markdown = &markdown.Cache{}
+
+	bodyHTML := func(resp *http.Response) string {
+		var message models.ListedMessage
+		json.NewDecoder(resp.Body).Decode(&message)
+
+		return message.BodyHTML
+	}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"topic", "body":"See https://go.dev <img src=x onerror=alert(1)>"}`))
+
+	CreateMessage(ctx, session, w, r, noVars)
+
+	assertEqual(t, bodyHTML(w.Result()), `<p>See <a href="https://go.dev" rel="nofollow noopener noreferrer">https://go.dev</a> <img src="x"></p>`+"\n", "Body as HTML")
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"topic", "body":"*edited*"}`))
+
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "3"})
+
+	assertEqual(t, bodyHTML(w.Result()), "<p><em>edited</em></p>\n", "Body as HTML after update")
+}
+
 func TestCreateMessage_WithMissingData(t *testing.T) {
 	ctx, session := setupContext()
 
@@ -220,14 +315,9 @@ func TestCreateMessage_WithMissingData(t *testing.T) {
 
 	// content
 
-	var errors []string
-
-	if err := json.NewDecoder(resp.Body).Decode(&errors); err != nil {
-		t.Errorf("Error decoding json-response: %v", err)
-	} else {
-		assertArrayContains(t, errors, "Topic is mandatory", "Errors contains 'Topic is mandatory'")
-		assertArrayContains(t, errors, "Body is mandatory", "Errors contains 'Body is mandatory'")
-	}
+	problem := assertProblem(t, resp, problemTypeNotValid)
+	assertFieldError(t, problem, "topic", "Topic is mandatory")
+	assertFieldError(t, problem, "body", "Body is mandatory")
 }
 
 func TestCreateMessage_WithInvalidJson(t *testing.T) {
@@ -240,7 +330,7 @@ func TestCreateMessage_WithInvalidJson(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 400)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeBadRequest)
 }
 
 func TestUpdateMessage_OwnerUpdatesMessage(t *testing.T) {
@@ -263,7 +353,7 @@ func TestUpdateMessage_OwnerUpdatesMessage(t *testing.T) {
 		assertMessage(t, message, session.CurrentUser.Username, "modified topic", "modified body", "1")
 
 		// Check repository
-		storedMessage, err := ctx.MessageService.GetMessage("1")
+		storedMessage, err := ctx.MessageService.GetMessage("1", fooUser)
 		if storedMessage != nil && err == nil {
 			assertMessage(t, storedMessage, session.CurrentUser.Username, "modified topic", "modified body", "1")
 		} else {
@@ -284,7 +374,7 @@ func TestUpdateMessage_NonexistantMessage(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 404)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeNotFound)
 }
 
 func TestUpdateMessage_WithMissingData(t *testing.T) {
@@ -302,14 +392,9 @@ func TestUpdateMessage_WithMissingData(t *testing.T) {
 
 	// content
 
-	var errors []string
-
-	if err := json.NewDecoder(resp.Body).Decode(&errors); err != nil {
-		t.Errorf("Error decoding json-response: %v", err)
-	} else {
-		assertArrayContains(t, errors, "Topic is mandatory", "Errors contains 'Topic is mandatory'")
-		assertArrayContains(t, errors, "Body is mandatory", "Errors contains 'Body is mandatory'")
-	}
+	problem := assertProblem(t, resp, problemTypeNotValid)
+	assertFieldError(t, problem, "topic", "Topic is mandatory")
+	assertFieldError(t, problem, "body", "Body is mandatory")
 }
 
 func TestUpdateMessage_OtherUserUpdatesMessage(t *testing.T) {
@@ -324,10 +409,10 @@ func TestUpdateMessage_OtherUserUpdatesMessage(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 401)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeNotOwner)
 
 	// Verify that it isnt modified!
-	storedMessage, err := ctx.MessageService.GetMessage("2")
+	storedMessage, err := ctx.MessageService.GetMessage("2", fooUser)
 	if storedMessage != nil && err == nil {
 		assertMessage(t, storedMessage, "bar", "Topic2", "Body2", "2")
 	} else {
@@ -347,7 +432,7 @@ func TestUpdateMessage_WithInvalidJson(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 400)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeBadRequest)
 }
 
 func TestDeleteMessage_OwnerDeletesMessage(t *testing.T) {
@@ -365,7 +450,7 @@ func TestDeleteMessage_OwnerDeletesMessage(t *testing.T) {
 	assertEmptyBody(t, resp)
 
 	// Check if it was removed from repository
-	if msg, _ := ctx.MessageService.GetMessage("1"); msg != nil {
+	if msg, _ := ctx.MessageService.GetMessage("1", fooUser); msg != nil {
 		t.Errorf("Deleted Message still exists in Repository!")
 	}
 }
@@ -382,7 +467,7 @@ func TestDeleteMessage_NonexistantMessage(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 404)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeNotFound)
 }
 
 func TestDeleteMessage_OtherUserDeletesMessage(t *testing.T) {
@@ -397,10 +482,37 @@ func TestDeleteMessage_OtherUserDeletesMessage(t *testing.T) {
 	resp := w.Result()
 
 	assertStatusCode(t, resp, 401)
-	assertEmptyBody(t, resp)
+	assertProblem(t, resp, problemTypeNotOwner)
 
 	// Check if it was removed from repository
-	if msg, err := ctx.MessageService.GetMessage("1"); msg == nil && err == nil {
+	if msg, err := ctx.MessageService.GetMessage("1", fooUser); msg == nil && err == nil {
 		t.Errorf("Message was unexpectedly removed from Repository!")
 	}
 }
+
+func TestGetMessages_Query(t *testing.T) {
+	ctx, session := setupContext()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx.MessageService.Clock = func() time.Time { return now }
+
+	ctx.MessageService.CreateMessage(models.Message{Topic: "Hello", Body: "World"}, fooUser)
+	now = now.Add(time.Hour)
+	ctx.MessageService.CreateMessage(models.Message{Topic: "Reply", Body: "Hello again", ParentID: "3"}, barUser)
+
+	assertEqual(t, getMessageIDs(t, ctx, session, "author=bar"), "2,4", "Messages by bar")
+	assertEqual(t, getMessageIDs(t, ctx, session, "q=hello&sort=-created_at"), "4,3", "Messages with hello, newest first")
+	assertEqual(t, getMessageIDs(t, ctx, session, "parent=3"), "4", "Replies")
+	assertEqual(t, getMessageIDs(t, ctx, session, "created_after=2020-01-01T00:30:00Z"), "4", "Messages created after")
+	assertEqual(t, getMessageIDs(t, ctx, session, "sort=-author,-id"), "3,1,4,2", "Messages sorted by author, then id")
+
+	for _, query := range []string{"sort=color", "colour=red", "created_after=today"} {
+		r := httptest.NewRequest("GET", "/api/messages?"+query, nil)
+		w := httptest.NewRecorder()
+
+		GetMessages(ctx, session, w, r, noVars)
+
+		resp := w.Result()
+		assertStatusCode(t, resp, 400)
+		assertProblem(t, resp, problemTypeBadRequest)
+	}
+}
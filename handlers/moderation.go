@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Returns the messages held for review, oldest first, in the format selected
+// by Accept. Supports limit and offset like GetMessages. Only for moderators
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   403 forbidden: if CurrentUser isn't a moderator
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetHeldMessages(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	held, total, err := ctx.MessageService.GetHeldMessages(page, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: held, xmlName: "held_message"})
+}
+
+// Publishes a held message as its author, or applies a held edit, and
+// removes it from the queue. Only for moderators
+// returns:
+//   200 success: with the published message
+//   403 forbidden: if CurrentUser isn't a moderator, or the author is no longer a member of the channel
+//   404 not found: if no message with the id is held, or the message edited is gone
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if the message can't be published as it is, ie the parent is gone. It stays held then
+//   423 locked: if the thread has been locked since. It stays held then
+func ApproveHeldMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	message, err := ctx.MessageService.ApproveHeldMessage(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*message, session.CurrentUser)))
+}
+
+// Removes a held message from the queue without publishing it. Only for
+// moderators
+// returns:
+//   200 success: if the message was removed
+//   403 forbidden: if CurrentUser isn't a moderator
+//   404 not found: if no message with the id is held
+func RejectHeldMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.RejectHeldMessage(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
+)
+
+func getHeldMessages(t *testing.T, ctx *context.Context, session *context.Session) []models.HeldMessage {
+	r, w := setupRequest()
+
+	GetHeldMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var held []models.HeldMessage
+	if err := json.NewDecoder(resp.Body).Decode(&held); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	assertEqual(t, resp.Header.Get("X-Total-Count"), strconv.Itoa(len(held)), "Total count is correct")
+
+	return held
+}
+
+func TestModeration(t *testing.T) {
+	ctx, session := setupContext()
+	moderatorSession := &context.Session{CurrentUser: models.User{Username: "moderator", Moderator: true}}
+	ctx.MessageService.Moderation = moderation.Pipeline{
+		moderation.NewWordList([]string{"spam"}, moderation.Reject),
+		moderation.LinkLimit{Max: 1},
+	}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Offer","body":"Buy Spam!"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 422)
+
+	if problem := assertProblem(t, w.Result(), problemTypeRejected); problem != nil {
+		assertEqual(t, problem.Detail, `Contains the word "Spam"`, "The reason is given")
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Offer","body":"Buy Spam!"}`))
+	CreateMessage(ctx, &context.Session{CurrentUser: fooUser, Locale: "da"}, w, r, noVars)
+
+	if problem := assertProblem(t, w.Result(), problemTypeRejected); problem != nil {
+		assertEqual(t, problem.Detail, `Indeholder ordet "Spam"`, "The reason is translated")
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Links","body":"www.a.com and www.b.com"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 202)
+	assertProblem(t, w.Result(), problemTypeHeld)
+
+	assertEqual(t, getMessageIDs(t, ctx, session, ""), "1,2", "Held messages aren't published")
+
+	r, w = setupRequest()
+	GetHeldMessages(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 403)
+
+	held := getHeldMessages(t, ctx, moderatorSession)
+	if len(held) != 1 || held[0].Message.Author != "foo" || held[0].Reason != "Has 2 links, more than 1" || held[0].IsEdit() {
+		t.Fatalf("Unexpected held messages %+v", held)
+	}
+
+	r, w = setupRequest()
+	ApproveHeldMessage(ctx, moderatorSession, w, r, map[string]string{"id": held[0].ID})
+	assertStatusCode(t, w.Result(), 200)
+	message := assertMessageJSON(t, w.Result())
+	assertMessage(t, message, "foo", "Links", "www.a.com and www.b.com", "3")
+
+	r, w = setupRequest()
+	ApproveHeldMessage(ctx, moderatorSession, w, r, map[string]string{"id": held[0].ID})
+	assertStatusCode(t, w.Result(), 404)
+
+	// Edits are held too, and the message is left as it was
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Topic1","body":"www.a.com and www.b.com"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 202)
+
+	held = getHeldMessages(t, ctx, moderatorSession)
+	if len(held) != 1 || held[0].Message.ID != "1" {
+		t.Fatalf("Expected the edit to be held, got %+v", held)
+	}
+
+	r, w = setupRequest()
+	RejectHeldMessage(ctx, moderatorSession, w, r, map[string]string{"id": held[0].ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	if held := getHeldMessages(t, ctx, moderatorSession); len(held) != 0 {
+		t.Errorf("Expected the queue to be empty, got %+v", held)
+	}
+
+	r, w = setupRequest()
+	GetMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertMessage(t, assertMessageJSON(t, w.Result()), "foo", "Topic1", "Body1", "1")
+}
+
+func TestModeration_PurgedMessages(t *testing.T) {
+	ctx, session := setupContext()
+	moderatorSession := &context.Session{CurrentUser: models.User{Username: "moderator", Moderator: true}}
+	ctx.MessageService.Moderation = moderation.Pipeline{moderation.NewWordList([]string{"casino"}, moderation.Hold)}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Topic1","body":"Casino"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 202)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Casino","parent_id":"1"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 202)
+
+	if err := ctx.MessageService.PurgeMessage("1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// They could never be approved
+	if held := getHeldMessages(t, ctx, moderatorSession); len(held) != 0 {
+		t.Errorf("Expected the held edit and reply to be dropped, got %+v", held)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Returns the notifications of CurrentUser about messages mentioning them,
+// newest first, with the number of unread notifications. Supports limit and
+// offset like GetMessages
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetNotifications(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	notifications := ctx.MessageService.GetNotifications(page, session.CurrentUser)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(notifications.Total))
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: notifications, xmlName: "notifications"})
+}
+
+// Marks a notification of CurrentUser as read
+// returns:
+//   200 success: if the notification is now read
+//   404 not found: if CurrentUser has no notification with the id
+func MarkNotificationRead(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.MarkNotificationRead(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
+
+// Marks all the notifications of CurrentUser as read
+// returns:
+//   200 success: always
+func MarkAllNotificationsRead(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	ctx.MessageService.MarkAllNotificationsRead(session.CurrentUser)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+func getNotifications(t *testing.T, ctx *context.Context, session *context.Session) models.Notifications {
+	r, w := setupRequest()
+
+	GetNotifications(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var notifications models.Notifications
+	if err := json.NewDecoder(resp.Body).Decode(&notifications); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return notifications
+}
+
+func TestMentionNotifications(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+	bazSession := &context.Session{CurrentUser: models.User{Username: "baz"}}
+	ctx.MessageService.UserRepository.Insert(bazSession.CurrentUser)
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Hi","body":"Hi @Bar, @foo and @nobody"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	message := assertMessageJSON(t, w.Result())
+
+	notifications := getNotifications(t, ctx, barSession)
+	if notifications.Total != 1 || notifications.Unread != 1 || notifications.Notifications[0].MessageID != message.ID || notifications.Notifications[0].Author != "foo" {
+		t.Errorf("Expected an unread notification from foo, got %+v", notifications)
+	}
+
+	if notifications := getNotifications(t, ctx, session); notifications.Total != 0 {
+		t.Errorf("Expected authors not to be notified about themselves, got %+v", notifications)
+	}
+
+	// Editing the message doesn't notify bar again, but does notify baz
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Hi","body":"Hi @bar and @baz"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": message.ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	if bar, baz := getNotifications(t, ctx, barSession), getNotifications(t, ctx, bazSession); bar.Total != 1 || baz.Total != 1 {
+		t.Errorf("Expected a notification each for bar and baz, got %+v and %+v", bar, baz)
+	}
+
+	// Those who can't read a message aren't notified
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Psst","body":"Don't tell @baz, @bar","recipients":["bar"]}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	if notifications := getNotifications(t, ctx, bazSession); notifications.Total != 1 {
+		t.Errorf("Expected baz not to be notified about the direct message, got %+v", notifications)
+	}
+
+	notifications = getNotifications(t, ctx, barSession)
+	if notifications.Total != 2 || notifications.Notifications[0].MessageID == message.ID {
+		t.Errorf("Expected the newest notification first, got %+v", notifications)
+	}
+
+	r, w = setupRequest()
+	MarkNotificationRead(ctx, barSession, w, r, map[string]string{"id": notifications.Notifications[0].ID})
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	MarkNotificationRead(ctx, bazSession, w, r, map[string]string{"id": notifications.Notifications[1].ID})
+	assertStatusCode(t, w.Result(), 404)
+
+	if notifications := getNotifications(t, ctx, barSession); notifications.Unread != 1 || !notifications.Notifications[0].Read {
+		t.Errorf("Expected one notification to be read, got %+v", notifications)
+	}
+
+	r, w = setupRequest()
+	MarkAllNotificationsRead(ctx, barSession, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	if notifications := getNotifications(t, ctx, barSession); notifications.Unread != 0 {
+		t.Errorf("Expected all notifications to be read, got %+v", notifications)
+	}
+
+	// Notifications about deleted messages are left out
+	r, w = setupRequest()
+	DeleteMessage(ctx, session, w, r, map[string]string{"id": message.ID})
+
+	if notifications := getNotifications(t, ctx, barSession); notifications.Total != 1 {
+		t.Errorf("Expected the notification about the deleted message to be left out, got %+v", notifications)
+	}
+}
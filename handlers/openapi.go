@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/dennis/hello_go/openapi"
+)
+
+// The Swagger UI is loaded from a CDN, so we don't have to ship it ourselves
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Hello Go API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// Returns the OpenAPI document describing the API. Doesn't require
+// authentication
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, openapi.Document)
+}
+
+// Returns a Swagger UI page for browsing the OpenAPI document. Doesn't
+// require authentication
+func Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, swaggerUI)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Writes the message changed by moderate, which is one of the pin and lock
+// operations of MessageService
+func writeModerated(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, moderate func(id string, user models.User) (*models.Message, error), id string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	message, err := moderate(id, session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*message, session.CurrentUser)))
+}
+
+// Pins a message to the top of GET /api/messages, above the messages pinned
+// before it. Only moderators may pin messages
+// returns:
+//   200 success: if the message is pinned
+//   403 forbidden: if CurrentUser isn't a moderator, or can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func PinMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeModerated(ctx, session, w, r, ctx.MessageService.PinMessage, vars["id"])
+}
+
+// Puts a pinned message back in its place. Only moderators may unpin messages
+// returns:
+//   200 success: if the message isn't pinned now
+//   403 forbidden: if CurrentUser isn't a moderator, or can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func UnpinMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeModerated(ctx, session, w, r, ctx.MessageService.UnpinMessage, vars["id"])
+}
+
+// Locks a message, so it and the replies below it can no longer be replied
+// to or updated. Only moderators may lock messages
+// returns:
+//   200 success: if the message is locked
+//   403 forbidden: if CurrentUser isn't a moderator, or can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func LockMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeModerated(ctx, session, w, r, ctx.MessageService.LockMessage, vars["id"])
+}
+
+// Unlocks a message. Only moderators may unlock messages
+// returns:
+//   200 success: if the message isn't locked now
+//   403 forbidden: if CurrentUser isn't a moderator, or can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func UnlockMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeModerated(ctx, session, w, r, ctx.MessageService.UnlockMessage, vars["id"])
+}
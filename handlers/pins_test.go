@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+func TestPinnedMessages(t *testing.T) {
+	ctx, session := setupContext()
+	moderatorSession := &context.Session{CurrentUser: models.User{Username: "foo", Moderator: true}}
+	ctx.MessageService.MessageRepository.Insert(models.Message{Author: "bar", Topic: "Topic3", Body: "Body3", ChannelID: models.DefaultChannelID})
+
+	r, w := setupRequest()
+	PinMessage(ctx, session, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 403)
+	assertProblem(t, w.Result(), problemTypeForbidden)
+
+	for _, id := range []string{"2", "3"} {
+		r, w = setupRequest()
+		PinMessage(ctx, moderatorSession, w, r, map[string]string{"id": id})
+		assertStatusCode(t, w.Result(), 200)
+
+		if message := assertMessageJSON(t, w.Result()); !message.IsPinned() {
+			t.Errorf("Expected message %s to be pinned", id)
+		}
+	}
+
+	// The message pinned last comes first, also when sorting
+	assertEqual(t, getMessageIDs(t, ctx, session, ""), "3,2,1", "Pinned messages come first")
+	assertEqual(t, getMessageIDs(t, ctx, session, "sort=id&author=foo&author=bar"), "3,2,1", "Pinned messages come first when sorting")
+
+	r, w = setupRequest()
+	UnpinMessage(ctx, moderatorSession, w, r, map[string]string{"id": "3"})
+	assertStatusCode(t, w.Result(), 200)
+
+	assertEqual(t, getMessageIDs(t, ctx, session, ""), "2,1,3", "Unpinned messages are back in place")
+
+	// Authors can't pin their own messages by updating them, nor unpin them
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Topic1","body":"Body1","pinned_at":"2020-01-01T00:00:00Z"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	assertEqual(t, getMessageIDs(t, ctx, session, ""), "2,1,3", "Updating doesn't pin messages")
+}
+
+func TestLockedMessages(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+	moderatorSession := &context.Session{CurrentUser: models.User{Username: "moderator", Admin: true}}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Reply","parent_id":"1"}`))
+	CreateMessage(ctx, barSession, w, r, noVars)
+	reply := assertMessageJSON(t, w.Result())
+
+	r, w = setupRequest()
+	LockMessage(ctx, barSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 403)
+
+	r, w = setupRequest()
+	LockMessage(ctx, moderatorSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if message := assertMessageJSON(t, w.Result()); !message.Locked {
+		t.Error("Expected the message to be locked")
+	}
+
+	// The message, and the replies below it, can't be replied to or updated
+	for _, id := range []string{"1", reply.ID} {
+		r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Another reply","parent_id":"` + id + `"}`))
+		CreateMessage(ctx, session, w, r, noVars)
+		assertStatusCode(t, w.Result(), 423)
+		assertProblem(t, w.Result(), problemTypeLocked)
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Topic1","body":"Edited"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 423)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Edited"}`))
+	UpdateMessage(ctx, barSession, w, r, map[string]string{"id": reply.ID})
+	assertStatusCode(t, w.Result(), 423)
+
+	// Other threads are open
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Reply","parent_id":"2"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	UnlockMessage(ctx, moderatorSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Edited"}`))
+	UpdateMessage(ctx, barSession, w, r, map[string]string{"id": reply.ID})
+	assertStatusCode(t, w.Result(), 200)
+}
+
+func TestLockedMessages_Purged(t *testing.T) {
+	ctx, _ := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+	moderatorSession := &context.Session{CurrentUser: models.User{Username: "moderator", Admin: true}}
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Reply","parent_id":"1"}`))
+	CreateMessage(ctx, barSession, w, r, noVars)
+	reply := assertMessageJSON(t, w.Result())
+
+	r, w = setupRequest()
+	LockMessage(ctx, moderatorSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	r, w = setupRequest()
+	PurgeMessage(ctx, moderatorSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	// The reply stays locked without the message
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"Re","body":"Edited"}`))
+	UpdateMessage(ctx, barSession, w, r, map[string]string{"id": reply.ID})
+	assertStatusCode(t, w.Result(), 423)
+}
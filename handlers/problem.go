@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dennis/hello_go/i18n"
+	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/validation"
+)
+
+// Problem types used in the "type" member of our responses. They are
+// relative URIs, as allowed by RFC 7807, and identify the kind of problem
+// independent of the status code
+const (
+	problemTypeNotFound         = "/problems/not-found"
+	problemTypeNotValid         = "/problems/not-valid"
+	problemTypeNotOwner         = "/problems/not-owner"
+	problemTypeForbidden        = "/problems/forbidden"
+	problemTypeLocked           = "/problems/locked"
+	problemTypeBlocked          = "/problems/blocked"
+	problemTypeRejected         = "/problems/rejected"
+	problemTypeHeld             = "/problems/held"
+	problemTypeBadRequest       = "/problems/bad-request"
+	problemTypeUnauthenticated  = "/problems/unauthenticated"
+	problemTypeMethodNotAllowed = "/problems/method-not-allowed"
+	problemTypeNotAcceptable    = "/problems/not-acceptable"
+	problemTypeUnsupportedMedia = "/problems/unsupported-media-type"
+	problemTypeInternal         = "/problems/internal-error"
+)
+
+// An RFC 7807 problem details object. Errors is an extension member, which
+// is only present for validation problems
+type Problem struct {
+	Type     string             `json:"type"`
+	Title    string             `json:"title"`
+	Status   int                `json:"status"`
+	Detail   string             `json:"detail"`
+	Instance string             `json:"instance"`
+	Errors   []validation.Error `json:"errors,omitempty"`
+}
+
+// Returned by handlers when the request body couldn't be decoded
+type malformedRequestError struct {
+	err error
+}
+
+func (e *malformedRequestError) Error() string { return e.err.Error() }
+
+func writeProblem(w http.ResponseWriter, r *http.Request, locale string, problem Problem) {
+	problem.Instance = r.URL.RequestURI()
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("Content-Language", i18n.Negotiate(locale, ""))
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// Looks up the title and detail of a problem in the catalog for locale.
+// Problems with a detail specific to the request, passes it in detail
+func localizedProblem(locale, key, problemType string, status int, detail string) Problem {
+	if len(detail) == 0 {
+		detail = i18n.Translate(locale, "problem."+key+".detail", nil)
+	}
+
+	return Problem{
+		Type:   problemType,
+		Title:  i18n.Translate(locale, "problem."+key+".title", nil),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Maps an error returned by a service (or from decoding the request) into a
+// problem+json response in the language of locale. Errors we don't know
+// about are reported as 500, as they are the result of a bug on our side and
+// not in the request
+func handleError(w http.ResponseWriter, r *http.Request, locale string, err error) {
+	var problem Problem
+
+	switch e := err.(type) {
+	case *services.NotFoundError:
+		problem = localizedProblem(locale, "not_found", problemTypeNotFound, http.StatusNotFound, "")
+	case *services.NotValidError:
+		errors := i18n.ValidationErrors(locale, e.Errors)
+
+		problem = localizedProblem(locale, "not_valid", problemTypeNotValid, http.StatusUnprocessableEntity, i18n.ValidationSummary(errors))
+		problem.Errors = errors
+	case *services.NotOwnerError:
+		problem = localizedProblem(locale, "not_owner", problemTypeNotOwner, http.StatusUnauthorized, "")
+	case *services.ForbiddenError:
+		problem = localizedProblem(locale, "forbidden", problemTypeForbidden, http.StatusForbidden, "")
+	case *services.LockedError:
+		problem = localizedProblem(locale, "locked", problemTypeLocked, http.StatusLocked, "")
+	case *services.BlockedError:
+		problem = localizedProblem(locale, "blocked", problemTypeBlocked, http.StatusForbidden, "")
+	case *services.RejectedError:
+		problem = localizedProblem(locale, "rejected", problemTypeRejected, http.StatusUnprocessableEntity, i18n.ModerationReason(locale, e.Code, e.Params, e.Reason))
+	case *services.HeldError:
+		// Not an error as such, but the message isn't there to respond with
+		problem = localizedProblem(locale, "held", problemTypeHeld, http.StatusAccepted, "")
+	case *malformedRequestError:
+		problem = localizedProblem(locale, "bad_request", problemTypeBadRequest, http.StatusBadRequest, e.Error())
+	case *notAcceptableError:
+		detail := i18n.Translate(locale, "problem.not_acceptable.detail", map[string]interface{}{"types": strings.Join(e.offered, ", ")})
+
+		problem = localizedProblem(locale, "not_acceptable", problemTypeNotAcceptable, http.StatusNotAcceptable, detail)
+	case *unsupportedMediaTypeError:
+		detail := i18n.Translate(locale, "problem.unsupported_media_type.detail", map[string]interface{}{"type": e.mediaType, "types": strings.Join(requestMediaTypes, ", ")})
+
+		problem = localizedProblem(locale, "unsupported_media_type", problemTypeUnsupportedMedia, http.StatusUnsupportedMediaType, detail)
+	default:
+		problem = localizedProblem(locale, "internal", problemTypeInternal, http.StatusInternalServerError, "")
+	}
+
+	writeProblem(w, r, locale, problem)
+}
+
+// The locale for requests without a session, ie before authentication
+func requestLocale(r *http.Request) string {
+	return i18n.Negotiate("", r.Header.Get("Accept-Language"))
+}
+
+// Responds with 401 for requests without valid credentials
+func Unauthenticated(w http.ResponseWriter, r *http.Request) {
+	locale := requestLocale(r)
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="hello_go"`)
+
+	writeProblem(w, r, locale, localizedProblem(locale, "unauthenticated", problemTypeUnauthenticated, http.StatusUnauthorized, ""))
+}
+
+// Responds with 404 for requests not matching any route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	handleError(w, r, requestLocale(r), &services.NotFoundError{})
+}
+
+// Responds with 405 for requests matching a route, but not its method
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	locale := requestLocale(r)
+	detail := i18n.Translate(locale, "problem.method_not_allowed.detail", map[string]interface{}{"method": r.Method})
+
+	writeProblem(w, r, locale, localizedProblem(locale, "method_not_allowed", problemTypeMethodNotAllowed, http.StatusMethodNotAllowed, detail))
+}
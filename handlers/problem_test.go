@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleError_UnknownErrorIsInternal(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/messages/1?foo=bar", nil)
+	w := httptest.NewRecorder()
+
+	handleError(w, r, "", errors.New("something broke"))
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 500)
+
+	if problem := assertProblem(t, resp, problemTypeInternal); problem != nil {
+		assertEqual(t, problem.Instance, "/api/messages/1?foo=bar", "Instance is the request URI")
+	}
+}
+
+func TestUnauthenticated(t *testing.T) {
+	r, w := setupRequest()
+
+	Unauthenticated(w, r)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 401)
+	assertProblem(t, resp, problemTypeUnauthenticated)
+
+	if resp.Header.Get("WWW-Authenticate") == "" {
+		t.Error("Expected WWW-Authenticate header")
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	r, w := setupRequest()
+
+	MethodNotAllowed(w, r)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 405)
+	assertProblem(t, resp, problemTypeMethodNotAllowed)
+}
+
+func TestHandleError_ValidationErrorsAreLocalized(t *testing.T) {
+	ctx, session := setupContext()
+	session.Locale = "da"
+
+	r, w := setupRequestWithContent(strings.NewReader("{}"))
+
+	CreateMessage(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+
+	assertStatusCode(t, resp, 422)
+	assertEqual(t, resp.Header.Get("Content-Language"), "da", "Content-Language is correct")
+
+	problem := assertProblem(t, resp, problemTypeNotValid)
+	assertFieldError(t, problem, "topic", "Emne skal udfyldes")
+	assertFieldError(t, problem, "body", "Tekst skal udfyldes")
+
+	if problem != nil {
+		assertEqual(t, problem.Title, "Validering fejlede", "Title is localized")
+	}
+}
+
+func TestUnauthenticated_UsesAcceptLanguage(t *testing.T) {
+	r, w := setupRequest()
+	r.Header.Set("Accept-Language", "da-DK, en;q=0.5")
+
+	Unauthenticated(w, r)
+
+	resp := w.Result()
+
+	if problem := assertProblem(t, resp, problemTypeUnauthenticated); problem != nil {
+		assertEqual(t, problem.Title, "Ikke logget ind", "Title is localized")
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Reacts to a message with an emoji as CurrentUser. Reacting twice with the
+// same emoji does nothing. The response has the reactions to the message
+// returns:
+//   200 success: if CurrentUser has now reacted with the emoji
+//   403 forbidden: if CurrentUser can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+//   422 unprocessable entity: if emoji isn't an emoji, or the message or CurrentUser has too many reactions
+func AddReaction(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	reactions, err := ctx.MessageService.AddReaction(vars["id"], vars["emoji"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: reactions, xmlName: "reaction"})
+}
+
+// Takes back the reaction of CurrentUser with an emoji. Removing a reaction
+// that isn't there does nothing. The response is as for AddReaction
+// returns:
+//   200 success: if CurrentUser has no reaction with the emoji now
+//   403 forbidden: if CurrentUser can't read the message
+//   404 not found: if message was not found
+//   406 not acceptable: if Accept doesn't allow any format we support
+func RemoveReaction(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	reactions, err := ctx.MessageService.RemoveReaction(vars["id"], vars["emoji"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: reactions, xmlName: "reaction"})
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Reacts with emoji to the message with id, and returns the reactions in the
+// response
+func react(t *testing.T, ctx *context.Context, session *context.Session, add bool, id, emoji string) []models.ReactionCount {
+	r, w := setupRequest()
+	vars := map[string]string{"id": id, "emoji": emoji}
+
+	if add {
+		AddReaction(ctx, session, w, r, vars)
+	} else {
+		RemoveReaction(ctx, session, w, r, vars)
+	}
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var reactions []models.ReactionCount
+	if err := json.NewDecoder(resp.Body).Decode(&reactions); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return reactions
+}
+
+func TestReactions(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	react(t, ctx, session, true, "2", "👍")
+	react(t, ctx, barSession, true, "2", "🎉")
+	reactions := react(t, ctx, barSession, true, "2", "👍")
+
+	// Reacting again does nothing
+	react(t, ctx, barSession, true, "2", "👍")
+
+	expected := []models.ReactionCount{{Emoji: "👍", Count: 2, Me: true}, {Emoji: "🎉", Count: 1, Me: true}}
+	if !reflect.DeepEqual(reactions, expected) {
+		t.Errorf("Expected %v, got %v", expected, reactions)
+	}
+
+	reactions = react(t, ctx, session, false, "2", "👍")
+	react(t, ctx, session, false, "2", "👍")
+
+	// The emoji are in the order of the reactions still there
+	expected = []models.ReactionCount{{Emoji: "🎉", Count: 1, Me: false}, {Emoji: "👍", Count: 1, Me: false}}
+	if !reflect.DeepEqual(reactions, expected) {
+		t.Errorf("Expected %v after removing, got %v", expected, reactions)
+	}
+
+	// The reactions are part of the message, as seen by the current user
+	r, w := setupRequest()
+	GetMessage(ctx, barSession, w, r, map[string]string{"id": "2"})
+
+	var message models.ListedMessage
+	if err := json.NewDecoder(w.Result().Body).Decode(&message); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	expected = []models.ReactionCount{{Emoji: "🎉", Count: 1, Me: true}, {Emoji: "👍", Count: 1, Me: true}}
+	if !reflect.DeepEqual(message.Reactions, expected) {
+		t.Errorf("Expected the message to have %v, got %v", expected, message.Reactions)
+	}
+}
+
+func TestAddReaction_Invalid(t *testing.T) {
+	ctx, session := setupContext()
+
+	for _, emoji := range []string{"", "ok", "👍 👍", "x👍"} {
+		r, w := setupRequest()
+		AddReaction(ctx, session, w, r, map[string]string{"id": "1", "emoji": emoji})
+
+		resp := w.Result()
+		assertStatusCode(t, resp, 422)
+		assertProblem(t, resp, problemTypeNotValid)
+	}
+
+	r, w := setupRequest()
+	AddReaction(ctx, session, w, r, map[string]string{"id": "42", "emoji": "👍"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestAddReaction_Limits(t *testing.T) {
+	ctx, session := setupContext()
+
+	defer func(limits models.Limits) { models.CurrentLimits = limits }(models.CurrentLimits)
+	models.CurrentLimits.MaxReactionEmoji = 3
+	models.CurrentLimits.MaxReactionsPerUser = 2
+
+	react(t, ctx, session, true, "1", "👍")
+	react(t, ctx, session, true, "1", "🎉")
+
+	r, w := setupRequest()
+	AddReaction(ctx, session, w, r, map[string]string{"id": "1", "emoji": "🚀"})
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "reactions", "Reaction can be given at most 2 times")
+
+	// Reacting again with an emoji already used is fine
+	react(t, ctx, session, true, "1", "👍")
+
+	barSession := &context.Session{CurrentUser: barUser}
+	react(t, ctx, barSession, true, "1", "🚀")
+
+	r, w = setupRequest()
+	AddReaction(ctx, barSession, w, r, map[string]string{"id": "1", "emoji": "❤️"})
+
+	resp = w.Result()
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "emoji", "Emoji can be given at most 3 times")
+}
+
+func TestAddReaction_LimitsHoldForConcurrentReactions(t *testing.T) {
+	ctx, _ := setupContext()
+
+	defer func(limits models.Limits) { models.CurrentLimits = limits }(models.CurrentLimits)
+	models.CurrentLimits.MaxReactionsPerUser = 2
+
+	var wg sync.WaitGroup
+	for _, emoji := range []string{"👍", "🎉", "🚀", "❤️", "👀", "😄"} {
+		wg.Add(1)
+
+		go func(emoji string) {
+			defer wg.Done()
+			ctx.MessageService.AddReaction("1", emoji, fooUser)
+		}(emoji)
+	}
+	wg.Wait()
+
+	if reactions := ctx.MessageService.ReactionRepository.FindByMessageID("1"); len(reactions) != 2 {
+		t.Errorf("Expected 2 reactions, got %v", reactions)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Marks a message as read by CurrentUser
+// returns:
+//   200 success: if the message is now read
+//   403 forbidden: if CurrentUser can't read the message
+//   404 not found: if message was not found
+func MarkMessageRead(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.MarkRead(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
+
+// Marks a message and all the replies below it as read by CurrentUser
+// returns:
+//   200 success: if the thread is now read
+//   403 forbidden: if CurrentUser can't read the message
+//   404 not found: if message was not found
+func MarkThreadRead(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if err := ctx.MessageService.MarkThreadRead(vars["id"], session.CurrentUser); err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+}
+
+// Marks every message CurrentUser can read as read
+// returns:
+//   200 success: always
+func MarkAllRead(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	ctx.MessageService.MarkAllRead(session.CurrentUser)
+}
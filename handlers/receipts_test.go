@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Returns the messages listed to session, and the X-Unread-Count header
+func getListedMessages(t *testing.T, ctx *context.Context, session *context.Session) ([]models.ListedMessage, string) {
+	r, w := setupRequest()
+
+	GetMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var messages []models.ListedMessage
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return messages, resp.Header.Get("X-Unread-Count")
+}
+
+func TestUnread(t *testing.T) {
+	ctx, session := setupContext()
+
+	messages, unread := getListedMessages(t, ctx, session)
+
+	// foo wrote message 1, so only message 2 is unread
+	assertEqual(t, unread, "1", "Unread count is correct")
+	if len(messages) != 2 || messages[0].Unread || !messages[1].Unread {
+		t.Errorf("Unexpected unread flags: %+v", messages)
+	}
+
+	r, w := setupRequest()
+	GetMessage(ctx, session, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if _, unread := getListedMessages(t, ctx, session); unread != "0" {
+		t.Errorf("Expected message 2 to be read, got %s unread", unread)
+	}
+}
+
+func TestMarkRead(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	r, w := setupRequest()
+	MarkMessageRead(ctx, barSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if _, unread := getListedMessages(t, ctx, barSession); unread != "0" {
+		t.Errorf("Expected message 1 to be read, got %s unread", unread)
+	}
+
+	r, w = setupRequest()
+	MarkMessageRead(ctx, session, w, r, map[string]string{"id": "42"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestMarkThreadRead(t *testing.T) {
+	ctx, session := setupContext()
+	barSession := &context.Session{CurrentUser: barUser}
+
+	reply, _ := ctx.MessageService.CreateMessage(models.Message{Topic: "re", Body: "b", ParentID: "1"}, fooUser)
+	ctx.MessageService.CreateMessage(models.Message{Topic: "re: re", Body: "b", ParentID: reply.ID}, fooUser)
+
+	r, w := setupRequest()
+	MarkThreadRead(ctx, barSession, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if _, unread := getListedMessages(t, ctx, barSession); unread != "0" {
+		t.Errorf("Expected the thread to be read, got %s unread", unread)
+	}
+
+	// Message 2 isn't in the thread
+	if _, unread := getListedMessages(t, ctx, session); unread != "1" {
+		t.Errorf("Expected message 2 to be unread for foo, got %s unread", unread)
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequest()
+	MarkAllRead(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 200)
+
+	if _, unread := getListedMessages(t, ctx, session); unread != "0" {
+		t.Errorf("Expected everything to be read, got %s unread", unread)
+	}
+
+	// Messages created afterwards are unread
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"New","body":"b"}`))
+	CreateMessage(ctx, &context.Session{CurrentUser: barUser}, w, r, noVars)
+
+	if _, unread := getListedMessages(t, ctx, session); unread != "1" {
+		t.Errorf("Expected the new message to be unread, got %s unread", unread)
+	}
+}
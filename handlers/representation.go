@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dennis/hello_go/msgpack"
+)
+
+// Media types we can represent resources in. Problems are always
+// application/problem+json
+const (
+	mediaTypeJSON    = "application/json"
+	mediaTypeXML     = "application/xml"
+	mediaTypeCSV     = "text/csv"
+	mediaTypeMsgpack = "application/msgpack"
+)
+
+// Other names clients use for the media types above
+var mediaTypeAliases = map[string]string{
+	"text/xml":                mediaTypeXML,
+	"application/x-msgpack":   mediaTypeMsgpack,
+	"application/vnd.msgpack": mediaTypeMsgpack,
+}
+
+// Media types of single resources. The first is used when the client
+// doesn't care
+var resourceMediaTypes = []string{mediaTypeJSON, mediaTypeXML, mediaTypeMsgpack}
+
+// Lists can be represented as CSV too, with a row per resource
+var listMediaTypes = []string{mediaTypeJSON, mediaTypeXML, mediaTypeCSV, mediaTypeMsgpack}
+
+// Media types we can decode request bodies from
+var requestMediaTypes = []string{mediaTypeJSON, mediaTypeXML, mediaTypeMsgpack}
+
+// Returned when none of the media types in Accept can be produced
+type notAcceptableError struct {
+	offered []string
+}
+
+func (e *notAcceptableError) Error() string {
+	return "none of the accepted media types are available"
+}
+
+// Returned when the request body is in a media type we can't decode
+type unsupportedMediaTypeError struct {
+	mediaType string
+}
+
+func (e *unsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("%s is not supported", e.mediaType)
+}
+
+func canonicalMediaType(mediaType string) string {
+	mediaType = strings.ToLower(mediaType)
+
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		return alias
+	}
+
+	return mediaType
+}
+
+// A media range of an Accept header, ie "text/*;q=0.5"
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// How well the range matches mediaType. 0 if it doesn't match at all,
+// otherwise higher for more specific ranges
+func (m mediaRange) specificity(mediaType string) int {
+	switch {
+	case m.mediaType == mediaType:
+		return 3
+	case m.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(m.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(m.mediaType, "*")):
+		return 2
+	default:
+		return 0
+	}
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{canonicalMediaType(mediaType), q})
+	}
+
+	return ranges
+}
+
+// Picks the media type among offered the client prefers, as described by
+// RFC 7231 section 5.3.2: each offered type gets the quality of the most
+// specific range matching it, and the highest quality wins. Ties goes to the
+// type offered first. Without an Accept header the first offered is used
+func negotiateMediaType(r *http.Request, offered []string) (string, error) {
+	accept := r.Header.Get("Accept")
+	if len(strings.TrimSpace(accept)) == 0 {
+		return offered[0], nil
+	}
+
+	ranges := parseAccept(accept)
+	qualities := make([]float64, len(offered))
+
+	for i, mediaType := range offered {
+		best := 0
+
+		for _, m := range ranges {
+			if s := m.specificity(mediaType); s > best {
+				best = s
+				qualities[i] = m.q
+			}
+		}
+	}
+
+	order := make([]int, len(offered))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return qualities[order[a]] > qualities[order[b]] })
+
+	if qualities[order[0]] == 0 {
+		return "", &notAcceptableError{offered}
+	}
+
+	return offered[order[0]], nil
+}
+
+// A resource, or list of resources, to respond with
+type representation struct {
+	value interface{}
+	// Name of the XML element holding the value, ie "message". For lists the
+	// root element is named after the plural, ie "messages", and each element
+	// after the singular
+	xmlName string
+	// Writes the value as CSV. Only set for lists
+	csv func(w io.Writer) error
+}
+
+func writeXML(w io.Writer, rep representation) error {
+	encoder := xml.NewEncoder(w)
+	value := reflect.ValueOf(rep.value)
+
+	if value.Kind() != reflect.Slice {
+		if err := encoder.EncodeElement(rep.value, xml.StartElement{Name: xml.Name{Local: rep.xmlName}}); err != nil {
+			return err
+		}
+		return encoder.Flush()
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: rep.xmlName + "s"}}
+	if err := encoder.EncodeToken(root); err != nil {
+		return err
+	}
+
+	for i := 0; i < value.Len(); i++ {
+		if err := encoder.EncodeElement(value.Index(i).Interface(), xml.StartElement{Name: xml.Name{Local: rep.xmlName}}); err != nil {
+			return err
+		}
+	}
+
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return err
+	}
+
+	return encoder.Flush()
+}
+
+// Writes rep as mediaType, which should come from negotiateMediaType. The
+// body is encoded before any of it is written, so a value that can't be
+// encoded is answered with a problem instead of half a body
+func writeRepresentation(w http.ResponseWriter, r *http.Request, locale, mediaType string, rep representation) {
+	var body bytes.Buffer
+	var err error
+
+	switch mediaType {
+	case mediaTypeXML:
+		body.WriteString(xml.Header)
+		err = writeXML(&body, rep)
+	case mediaTypeCSV:
+		err = rep.csv(&body)
+	case mediaTypeMsgpack:
+		var data []byte
+		data, err = msgpack.Marshal(rep.value)
+		body.Write(data)
+	default:
+		err = json.NewEncoder(&body).Encode(rep.value)
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	if err != nil {
+		handleError(w, r, locale, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	body.WriteTo(w)
+}
+
+// Decodes the request body into v, according to its Content-Type. Requests
+// without one are taken to be JSON, as that's what we always used to expect
+func decodeBody(r *http.Request, v interface{}) error {
+	mediaType := mediaTypeJSON
+
+	if contentType := r.Header.Get("Content-Type"); len(contentType) > 0 {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return &unsupportedMediaTypeError{contentType}
+		}
+
+		mediaType = canonicalMediaType(parsed)
+	}
+
+	var err error
+
+	switch mediaType {
+	case mediaTypeJSON:
+		err = json.NewDecoder(r.Body).Decode(v)
+	case mediaTypeXML:
+		err = xml.NewDecoder(r.Body).Decode(v)
+	case mediaTypeMsgpack:
+		var data []byte
+		if data, err = ioutil.ReadAll(r.Body); err == nil {
+			err = msgpack.Unmarshal(data, v)
+		}
+	default:
+		return &unsupportedMediaTypeError{mediaType}
+	}
+
+	if err != nil {
+		return &malformedRequestError{err}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/msgpack"
+	"github.com/dennis/hello_go/services"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	cases := []struct {
+		accept, expected string
+	}{
+		{"", mediaTypeJSON},
+		{"*/*", mediaTypeJSON},
+		{"application/xml", mediaTypeXML},
+		{"text/xml", mediaTypeXML},
+		{"application/x-msgpack", mediaTypeMsgpack},
+		{"text/*", mediaTypeCSV},
+		{"application/json;q=0.5, text/csv", mediaTypeCSV},
+		{"application/json;q=0, */*", mediaTypeXML},
+		{"text/html, application/xml;q=0.1", mediaTypeXML},
+		{"application/*, application/xml;q=0.2", mediaTypeJSON},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", c.accept)
+
+		mediaType, err := negotiateMediaType(r, listMediaTypes)
+
+		if err != nil || mediaType != c.expected {
+			t.Errorf("Accept %q: expected %s, got %s (%v)", c.accept, c.expected, mediaType, err)
+		}
+	}
+}
+
+func TestNegotiateMediaType_NotAcceptable(t *testing.T) {
+	for _, accept := range []string{"text/html", "application/json;q=0", "text/csv"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", accept)
+
+		if _, err := negotiateMediaType(r, resourceMediaTypes); err == nil {
+			t.Errorf("Accept %q: expected an error", accept)
+		}
+	}
+}
+
+func TestGetMessages_AsXML(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequest()
+	r.Header.Set("Accept", "application/xml")
+
+	GetMessages(ctx, session, w, r, noVars)
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusOK)
+	assertContentType(t, resp, "application/xml")
+
+	var list struct {
+		Messages []models.Message `xml:"message"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("Error decoding XML: %v", err)
+	}
+
+	if len(list.Messages) != 2 || !reflect.DeepEqual(list.Messages[0], message1) {
+		t.Errorf("Unexpected messages: %+v", list.Messages)
+	}
+}
+
+func TestGetMessages_AsCSV(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequest()
+	r.Header.Set("Accept", "text/csv")
+
+	GetMessages(ctx, session, w, r, noVars)
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusOK)
+	assertContentType(t, resp, "text/csv")
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	assertEqual(t, string(body), "id,topic,body,author,parent_id,channel_id,recipients,tags,created_at,updated_at,deleted_at\n1,Topic1,Body1,foo,,general,,,,,\n2,Topic2,Body2,bar,,general,,,,,\n", "CSV is correct")
+}
+
+func TestGetMessage_AsMessagePack(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequest()
+	r.Header.Set("Accept", "application/msgpack")
+
+	GetMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusOK)
+	assertContentType(t, resp, "application/msgpack")
+
+	var message models.Message
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := msgpack.Unmarshal(body, &message); err != nil || !reflect.DeepEqual(message, message1) {
+		t.Errorf("Unexpected message %+v (%v)", message, err)
+	}
+}
+
+func TestGetMessage_CSVIsNotAcceptable(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequest()
+	r.Header.Set("Accept", "text/csv")
+
+	GetMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusNotAcceptable)
+	assertProblem(t, resp, problemTypeNotAcceptable)
+}
+
+func TestCreateMessage_FromXML(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequestWithContent(strings.NewReader("<message><topic>T</topic><body>B</body></message>"))
+	r.Header.Set("Content-Type", "text/xml; charset=utf-8")
+
+	CreateMessage(ctx, session, w, r, noVars)
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusOK)
+	assertMessage(t, assertMessageJSON(t, resp), "foo", "T", "B", "3")
+}
+
+func TestCreateMessage_FromMessagePack(t *testing.T) {
+	ctx, session := setupContext()
+	data, _ := msgpack.Marshal(map[string]string{"topic": "T", "body": "B"})
+	r, w := setupRequestWithContent(bytes.NewReader(data))
+	r.Header.Set("Content-Type", "application/msgpack")
+	r.Header.Set("Accept", "application/xml")
+
+	CreateMessage(ctx, session, w, r, noVars)
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusOK)
+	assertContentType(t, resp, "application/xml")
+
+	var message models.Message
+	if err := xml.NewDecoder(resp.Body).Decode(&message); err != nil {
+		t.Fatalf("Error decoding XML: %v", err)
+	}
+	assertMessage(t, &message, "foo", "T", "B", "3")
+}
+
+func TestCreateMessage_UnsupportedMediaType(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequestWithContent(strings.NewReader("topic=T&body=B"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	CreateMessage(ctx, session, w, r, noVars)
+	resp := w.Result()
+
+	assertStatusCode(t, resp, http.StatusUnsupportedMediaType)
+	problem := assertProblem(t, resp, problemTypeUnsupportedMedia)
+
+	if problem != nil && !strings.Contains(problem.Detail, "application/x-www-form-urlencoded") {
+		t.Errorf("Detail doesn't mention the media type: %s", problem.Detail)
+	}
+}
+
+// Nothing must be created, if we can't respond in an acceptable format
+func TestCreateMessage_NotAcceptableCreatesNothing(t *testing.T) {
+	ctx, session := setupContext()
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"T","body":"B"}`))
+	r.Header.Set("Accept", "text/html")
+
+	CreateMessage(ctx, session, w, r, noVars)
+
+	assertStatusCode(t, w.Result(), http.StatusNotAcceptable)
+
+	if _, total, _ := ctx.MessageService.GetMessages(services.Page{}, fooUser); total != 2 {
+		t.Errorf("Expected no message to be created, got %d messages", total)
+	}
+}
+
+func TestWriteRepresentation_EncodingFails(t *testing.T) {
+	// Maps can't be XML, and channels can't be anything
+	for mediaType, value := range map[string]interface{}{
+		mediaTypeXML:     map[string]int{"a": 1},
+		mediaTypeJSON:    make(chan int),
+		mediaTypeMsgpack: make(chan int),
+	} {
+		r, w := setupRequest()
+
+		writeRepresentation(w, r, "en", mediaType, representation{value: value, xmlName: "value"})
+		resp := w.Result()
+
+		assertStatusCode(t, resp, http.StatusInternalServerError)
+		assertProblem(t, resp, problemTypeInternal)
+	}
+}
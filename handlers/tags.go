@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Returns the tags used by the messages CurrentUser can read, with the number
+// of messages using each, most used first
+// returns:
+//   200 success: if successful
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetTags(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, representation{value: ctx.MessageService.GetTags(session.CurrentUser), xmlName: "tag"})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Creates messages tagged go, go and release, and release, as 3, 4 and 5
+func setupTaggedContext(t *testing.T) (*context.Context, *context.Session) {
+	ctx, session := setupContext()
+
+	for _, tags := range [][]string{{"Go"}, {"#go", "release"}, {"release"}} {
+		if _, err := ctx.MessageService.CreateMessage(models.Message{Topic: "t", Body: "b", Tags: tags}, fooUser); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	return ctx, session
+}
+
+// Returns the IDs of the messages listed for query, separated by commas.
+// Checks that X-Total-Count is the number of them
+func getMessageIDs(t *testing.T, ctx *context.Context, session *context.Session, query string) string {
+	r := httptest.NewRequest("GET", "/api/messages?"+query, nil)
+	w := httptest.NewRecorder()
+
+	GetMessages(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var messages []models.ListedMessage
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	ids := []string{}
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+
+	assertEqual(t, resp.Header.Get("X-Total-Count"), strconv.Itoa(len(ids)), "Total count is correct")
+
+	return strings.Join(ids, ",")
+}
+
+func TestGetMessages_Tags(t *testing.T) {
+	ctx, session := setupTaggedContext(t)
+
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=go"), "3,4", "Messages tagged go")
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=GO&tag=release"), "4", "Messages tagged go and release")
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=go&tag=release&tag_match=any"), "3,4,5", "Messages tagged go or release")
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=unknown"), "", "Messages tagged unknown")
+
+	// Deleted messages aren't listed
+	ctx.MessageService.DeleteMessage("4", fooUser)
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=release"), "5", "Messages tagged release")
+
+	r := httptest.NewRequest("GET", "/api/messages?tag=go&tag_match=some", nil)
+	w := httptest.NewRecorder()
+	GetMessages(ctx, session, w, r, noVars)
+	assertStatusCode(t, w.Result(), 400)
+}
+
+func TestGetTags(t *testing.T) {
+	ctx, session := setupTaggedContext(t)
+
+	r, w := setupRequest()
+	GetTags(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var tags []models.TagCount
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	if len(tags) != 2 || tags[0] != (models.TagCount{Name: "go", Count: 2}) || tags[1] != (models.TagCount{Name: "release", Count: 2}) {
+		t.Errorf("Unexpected tags: %+v", tags)
+	}
+}
+
+func TestUpdateMessage_Tags(t *testing.T) {
+	ctx, session := setupTaggedContext(t)
+
+	// Left out, the tags are kept
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"changed"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "4"})
+	assertEqual(t, strings.Join(assertMessageJSON(t, w.Result()).Tags, ","), "go,release", "Tags are kept")
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b","tags":[]}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "4"})
+	assertEqual(t, strings.Join(assertMessageJSON(t, w.Result()).Tags, ","), "", "Tags are cleared")
+
+	assertEqual(t, getMessageIDs(t, ctx, session, "tag=release"), "5", "Messages tagged release")
+}
+
+func TestCreateMessage_InvalidTags(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b","tags":["ok","not ok"]}`))
+	CreateMessage(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 422)
+	assertFieldError(t, assertProblem(t, resp, problemTypeNotValid), "tags", "Tag has an invalid format")
+}
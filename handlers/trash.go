@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/context"
+)
+
+// Returns the messages CurrentUser has deleted, in the format selected by
+// Accept. Supports limit and offset like GetMessages
+// returns:
+//   200 success: if successful
+//   400 bad request: if limit or offset isn't valid
+//   406 not acceptable: if Accept doesn't allow any format we support
+func GetTrash(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, listMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	page, err := parsePage(r)
+
+	if err != nil {
+		handleError(w, r, session.Locale, &malformedRequestError{err})
+		return
+	}
+
+	messages, total := ctx.MessageService.GetTrash(page, session.CurrentUser)
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeRepresentation(w, r, session.Locale, mediaType, messagesRepresentation(ctx.MessageService.Views(messages, session.CurrentUser)))
+}
+
+// Takes a message out of the trash of CurrentUser
+// returns:
+//   200 success: with the restored message
+//   401 unauthorized: if CurrentUser isn't the owner of the message
+//   404 not found: if message wasn't found in the trash
+//   406 not acceptable: if Accept doesn't allow any format we support
+func RestoreMessage(ctx *context.Context, session *context.Session, w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	mediaType, err := negotiateMediaType(r, resourceMediaTypes)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	message, err := ctx.MessageService.RestoreMessage(vars["id"], session.CurrentUser)
+
+	if err != nil {
+		handleError(w, r, session.Locale, err)
+		return
+	}
+
+	writeRepresentation(w, r, session.Locale, mediaType, messageRepresentation(ctx.MessageService.View(*message, session.CurrentUser)))
+}
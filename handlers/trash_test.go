@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/context"
+	"github.com/dennis/hello_go/models"
+)
+
+// Returns the messages in the trash of session
+func getTrash(t *testing.T, ctx *context.Context, session *context.Session) []models.ListedMessage {
+	r, w := setupRequest()
+
+	GetTrash(ctx, session, w, r, noVars)
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+
+	var messages []models.ListedMessage
+	if err := json.NewDecoder(resp.Body).Decode(&messages); err != nil {
+		t.Fatalf("Error decoding json-response: %v", err)
+	}
+
+	return messages
+}
+
+func TestTrash(t *testing.T) {
+	ctx, session := setupContext()
+	deletedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx.MessageService.Clock = func() time.Time { return deletedAt }
+
+	r, w := setupRequest()
+	DeleteMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 200)
+
+	trash := getTrash(t, ctx, session)
+	if len(trash) != 1 || trash[0].ID != "1" || trash[0].DeletedAt == nil || !trash[0].DeletedAt.Equal(deletedAt) {
+		t.Fatalf("Unexpected trash: %+v", trash)
+	}
+
+	// Only the author sees it in the trash, and nobody sees it elsewhere
+	if trash := getTrash(t, ctx, &context.Session{CurrentUser: barUser}); len(trash) != 0 {
+		t.Errorf("Expected bar's trash to be empty, got %+v", trash)
+	}
+	if messages, _ := getListedMessages(t, ctx, session); len(messages) != 1 {
+		t.Errorf("Expected the deleted message to be hidden, got %+v", messages)
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestRestoreMessage(t *testing.T) {
+	ctx, session := setupContext()
+	ctx.MessageService.DeleteMessage("1", fooUser)
+
+	r, w := setupRequest()
+	RestoreMessage(ctx, &context.Session{CurrentUser: barUser}, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 401)
+
+	r, w = setupRequest()
+	RestoreMessage(ctx, session, w, r, map[string]string{"id": "1"})
+
+	resp := w.Result()
+	assertStatusCode(t, resp, 200)
+	if message := assertMessageJSON(t, resp); message.DeletedAt != nil {
+		t.Errorf("Expected the message to be restored, got %+v", message)
+	}
+
+	if messages, _ := getListedMessages(t, ctx, session); len(messages) != 2 {
+		t.Errorf("Expected the message to be listed again, got %+v", messages)
+	}
+
+	// It is no longer in the trash
+	r, w = setupRequest()
+	RestoreMessage(ctx, session, w, r, map[string]string{"id": "1"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestPurgeMessage(t *testing.T) {
+	ctx, session := setupAdminContext(false)
+
+	r, w := setupRequest()
+	PurgeMessage(ctx, session, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 403)
+
+	ctx, session = setupAdminContext(true)
+
+	r, w = setupRequest()
+	PurgeMessage(ctx, session, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 200)
+
+	if ctx.MessageService.MessageRepository.FindByID("2") != nil {
+		t.Error("Expected the message to be gone for good")
+	}
+
+	r, w = setupRequest()
+	PurgeMessage(ctx, session, w, r, map[string]string{"id": "2"})
+	assertStatusCode(t, w.Result(), 404)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx, _ := setupContext()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx.MessageService.Clock = func() time.Time { return now }
+
+	ctx.MessageService.MarkRead("1", barUser)
+	ctx.MessageService.DeleteMessage("1", fooUser)
+	now = now.Add(time.Hour)
+	ctx.MessageService.DeleteMessage("2", barUser)
+
+	// Only message 1 has been in the trash for more than 30 minutes
+	if purged, err := ctx.MessageService.PurgeTrash(30 * time.Minute); purged != 1 || err != nil {
+		t.Errorf("Expected 1 message to be purged, got %d (%v)", purged, err)
+	}
+
+	if ctx.MessageService.MessageRepository.FindByID("1") != nil || ctx.MessageService.MessageRepository.FindByID("2") == nil {
+		t.Error("Expected only message 1 to be purged")
+	}
+
+	if ctx.MessageService.ReceiptRepository.IsRead("bar", "1") {
+		t.Error("Expected the receipts for message 1 to be purged too")
+	}
+}
+
+func TestDeletedAtCanOnlyBeSetByDeleting(t *testing.T) {
+	ctx, session := setupContext()
+
+	r, w := setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b","deleted_at":"2020-01-02T03:04:05Z"}`))
+	CreateMessage(ctx, session, w, r, noVars)
+
+	if created := assertMessageJSON(t, w.Result()); created.DeletedAt != nil {
+		t.Errorf("Expected the created message not to be in the trash, got %+v", created)
+	}
+
+	r, w = setupRequestWithContent(strings.NewReader(`{"topic":"t","body":"b","deleted_at":"2020-01-02T03:04:05Z"}`))
+	UpdateMessage(ctx, session, w, r, map[string]string{"id": "1"})
+
+	if updated := assertMessageJSON(t, w.Result()); updated.DeletedAt != nil {
+		t.Errorf("Expected the updated message not to be in the trash, got %+v", updated)
+	}
+
+	if trash := getTrash(t, ctx, session); len(trash) != 0 {
+		t.Errorf("Expected the trash to be empty, got %+v", trash)
+	}
+}
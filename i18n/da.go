@@ -0,0 +1,69 @@
+package i18n
+
+var danish = catalog{
+	"field.topic":       "Emne",
+	"field.body":        "Tekst",
+	"field.username":    "Brugernavn",
+	"field.auth_token":  "Adgangsnøgle",
+	"field.parent_id":   "Forælder",
+	"field.channel_id":  "Kanal",
+	"field.name":        "Navn",
+	"field.description": "Beskrivelse",
+	"field.members":     "Medlem",
+	"field.recipients":  "Modtager",
+	"field.tags":        "Tag",
+	"field.emoji":       "Emoji",
+	"field.reactions":   "Reaktion",
+	"field.file":        "Fil",
+	"field.attachments": "Vedhæftning",
+	"field.publish_at":  "Udgivelsestidspunkt",
+	"field.author":      "Forfatter",
+
+	"validation.required":             "{field} skal udfyldes",
+	"validation.min_length":           "{field} skal være mindst {min} tegn",
+	"validation.max_length":           "{field} må højst være {max} tegn",
+	"validation.forbidden_characters": "{field} indeholder ugyldige tegn",
+	"validation.invalid_utf8":         "{field} skal være gyldig UTF-8",
+	"validation.invalid_format":       "{field} har et ugyldigt format",
+	"validation.max_items":            "{field} kan højst angives {max} gange",
+	"validation.max_size":             "{field} må højst være {max} bytes",
+	"validation.too_early":            "{field} må ikke være før {min}",
+	"validation.not_found":            "{field} findes ikke",
+	"validation.not_allowed":          "{field} er ikke tilladt her",
+	"validation.taken":                "{field} er allerede i brug",
+	"validation.malformed":            "Posten kunne ikke læses: {error}",
+
+	"moderation.word":         "Indeholder ordet \"{word}\"",
+	"moderation.links":        "Har {count} links, flere end {max}",
+	"moderation.pattern":      "Matcher {pattern}",
+	"moderation.unclassified": "Kunne ikke klassificeres: {error}",
+	"moderation.spam":         "Ligner spam eller misbrug",
+	"moderation.score":        "Klassificeret som uønsket med en score på {score}",
+
+	"problem.not_found.title":               "Ikke fundet",
+	"problem.not_found.detail":              "Den efterspurgte ressource findes ikke",
+	"problem.not_valid.title":               "Validering fejlede",
+	"problem.not_owner.title":               "Ikke ejer",
+	"problem.not_owner.detail":              "Kun forfatteren af en besked kan ændre den",
+	"problem.forbidden.title":               "Ikke tilladt",
+	"problem.forbidden.detail":              "Du har ikke lov til at gøre dette",
+	"problem.locked.title":                  "Låst",
+	"problem.locked.detail":                 "Tråden er låst af en moderator, så den kan ikke besvares eller ændres",
+	"problem.blocked.title":                 "Blokeret",
+	"problem.blocked.detail":                "En, som beskeden ville nå, har blokeret dig",
+	"problem.rejected.title":                "Afvist",
+	"problem.rejected.detail":               "Beskeden blev afvist af moderationen",
+	"problem.held.title":                    "Afventer godkendelse",
+	"problem.held.detail":                   "En moderator skal godkende beskeden, før den udgives",
+	"problem.bad_request.title":             "Ugyldig forespørgsel",
+	"problem.unauthenticated.title":         "Ikke logget ind",
+	"problem.unauthenticated.detail":        "En gyldig adgangsnøgle skal angives via basic authentication",
+	"problem.method_not_allowed.title":      "Metode ikke tilladt",
+	"problem.method_not_allowed.detail":     "{method} understøttes ikke for denne ressource",
+	"problem.not_acceptable.title":          "Ikke acceptabel",
+	"problem.not_acceptable.detail":         "Ingen af de accepterede medietyper er tilgængelige. Brug en af: {types}",
+	"problem.unsupported_media_type.title":  "Medietype understøttes ikke",
+	"problem.unsupported_media_type.detail": "{type} understøttes ikke. Brug en af: {types}",
+	"problem.internal.title":                "Intern serverfejl",
+	"problem.internal.detail":               "Der opstod en uventet fejl",
+}
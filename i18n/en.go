@@ -0,0 +1,69 @@
+package i18n
+
+var english = catalog{
+	"field.topic":       "Topic",
+	"field.body":        "Body",
+	"field.username":    "Username",
+	"field.auth_token":  "Auth token",
+	"field.parent_id":   "Parent",
+	"field.channel_id":  "Channel",
+	"field.name":        "Name",
+	"field.description": "Description",
+	"field.members":     "Member",
+	"field.recipients":  "Recipient",
+	"field.tags":        "Tag",
+	"field.emoji":       "Emoji",
+	"field.reactions":   "Reaction",
+	"field.file":        "File",
+	"field.attachments": "Attachment",
+	"field.publish_at":  "Publish at",
+	"field.author":      "Author",
+
+	"validation.required":             "{field} is mandatory",
+	"validation.min_length":           "{field} must be at least {min} characters",
+	"validation.max_length":           "{field} must be at most {max} characters",
+	"validation.forbidden_characters": "{field} contains forbidden characters",
+	"validation.invalid_utf8":         "{field} must be valid UTF-8",
+	"validation.invalid_format":       "{field} has an invalid format",
+	"validation.max_items":            "{field} can be given at most {max} times",
+	"validation.max_size":             "{field} must be at most {max} bytes",
+	"validation.too_early":            "{field} must not be before {min}",
+	"validation.taken":                "{field} is already taken",
+	"validation.not_found":            "{field} does not exist",
+	"validation.not_allowed":          "{field} is not allowed here",
+	"validation.malformed":            "The record could not be parsed: {error}",
+
+	"moderation.word":         "Contains the word \"{word}\"",
+	"moderation.links":        "Has {count} links, more than {max}",
+	"moderation.pattern":      "Matches {pattern}",
+	"moderation.unclassified": "Could not be classified: {error}",
+	"moderation.spam":         "Looks like spam or abuse",
+	"moderation.score":        "Classified as unwanted with a score of {score}",
+
+	"problem.not_found.title":               "Not found",
+	"problem.not_found.detail":              "The requested resource does not exist",
+	"problem.not_valid.title":               "Validation failed",
+	"problem.not_owner.title":               "Not owner",
+	"problem.not_owner.detail":              "Only the author of a message can modify it",
+	"problem.forbidden.title":               "Forbidden",
+	"problem.forbidden.detail":              "You are not allowed to do this",
+	"problem.locked.title":                  "Locked",
+	"problem.locked.detail":                 "The thread has been locked by a moderator, so it can't be replied to or updated",
+	"problem.blocked.title":                 "Blocked",
+	"problem.blocked.detail":                "Someone the message would reach has blocked you",
+	"problem.rejected.title":                "Rejected",
+	"problem.rejected.detail":               "The message was rejected by moderation",
+	"problem.held.title":                    "Held for review",
+	"problem.held.detail":                   "A moderator has to approve the message before it is published",
+	"problem.bad_request.title":             "Bad request",
+	"problem.unauthenticated.title":         "Unauthenticated",
+	"problem.unauthenticated.detail":        "A valid auth token must be provided via basic authentication",
+	"problem.method_not_allowed.title":      "Method not allowed",
+	"problem.method_not_allowed.detail":     "{method} is not supported for this resource",
+	"problem.not_acceptable.title":          "Not acceptable",
+	"problem.not_acceptable.detail":         "None of the accepted media types are available. Use one of: {types}",
+	"problem.unsupported_media_type.title":  "Unsupported media type",
+	"problem.unsupported_media_type.detail": "{type} is not supported. Use one of: {types}",
+	"problem.internal.title":                "Internal server error",
+	"problem.internal.detail":               "An unexpected error occurred",
+}
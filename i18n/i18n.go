@@ -0,0 +1,139 @@
+// Package i18n contains the message catalogs for the languages we support
+// and the rules for picking one for a request.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Used when neither the user nor the request asks for a language we know,
+// and for keys missing from the other catalogs
+const DefaultLocale = "en"
+
+// Messages keyed by their code. Messages may contain placeholders like {max},
+// which are replaced by the params given to Translate
+type catalog map[string]string
+
+var catalogs = map[string]catalog{
+	"en": english,
+	"da": danish,
+}
+
+// Returns the locales we have catalogs for, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Maps a language tag like "da-DK" or "EN_us" to a locale we have a catalog
+// for. Returns "" if we don't support the language
+func Supported(tag string) string {
+	tag = strings.ToLower(strings.Replace(strings.TrimSpace(tag), "_", "-", -1))
+
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+
+	if i := strings.Index(tag, "-"); i > 0 {
+		if _, ok := catalogs[tag[:i]]; ok {
+			return tag[:i]
+		}
+	}
+
+	return ""
+}
+
+// Picks the locale to use. The users own preference wins, then the
+// languages in the Accept-Language header by their quality and finally the
+// DefaultLocale
+func Negotiate(preference, acceptLanguage string) string {
+	if locale := Supported(preference); locale != "" {
+		return locale
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale := Supported(tag); locale != "" {
+			return locale
+		}
+	}
+
+	return DefaultLocale
+}
+
+type weightedTag struct {
+	tag     string
+	quality float64
+}
+
+// Returns the tags of an Accept-Language header ordered by quality. Tags with
+// a quality of 0 are left out, as they are explicitly not acceptable
+func parseAcceptLanguage(header string) []string {
+	weighted := []weightedTag{}
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		quality := 1.0
+
+		if len(tag) == 0 || tag == "*" {
+			continue
+		}
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if quality > 0 {
+			weighted = append(weighted, weightedTag{tag, quality})
+		}
+	}
+
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return weighted[i].quality > weighted[j].quality
+	})
+
+	tags := make([]string, 0, len(weighted))
+
+	for _, w := range weighted {
+		tags = append(tags, w.tag)
+	}
+
+	return tags
+}
+
+// Returns the message for key in locale, falling back to the DefaultLocale
+// and then to the key itself. Placeholders like {max} are replaced with the
+// matching value from params
+func Translate(locale, key string, params map[string]interface{}) string {
+	message, ok := catalogs[Supported(locale)][key]
+
+	if !ok {
+		message, ok = catalogs[DefaultLocale][key]
+	}
+
+	if !ok {
+		message = key
+	}
+
+	for name, value := range params {
+		message = strings.Replace(message, "{"+name+"}", fmt.Sprint(value), -1)
+	}
+
+	return message
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+func TestSupported(t *testing.T) {
+	cases := map[string]string{
+		"da":    "da",
+		"da-DK": "da",
+		"EN_us": "en",
+		"de":    "",
+		"":      "",
+	}
+
+	for tag, expected := range cases {
+		if actual := Supported(tag); actual != expected {
+			t.Errorf("Supported(%q): expected=%q, actual=%q", tag, expected, actual)
+		}
+	}
+}
+
+func TestNegotiate_PreferenceWins(t *testing.T) {
+	if locale := Negotiate("da", "en-US,en;q=0.9"); locale != "da" {
+		t.Errorf("Expected users preference 'da', got %q", locale)
+	}
+}
+
+func TestNegotiate_AcceptLanguageByQuality(t *testing.T) {
+	if locale := Negotiate("", "de;q=0.9, en;q=0.5, da-DK;q=0.8"); locale != "da" {
+		t.Errorf("Expected 'da', got %q", locale)
+	}
+}
+
+func TestNegotiate_ZeroQualityIsNotAcceptable(t *testing.T) {
+	if locale := Negotiate("", "da;q=0"); locale != DefaultLocale {
+		t.Errorf("Expected default locale, got %q", locale)
+	}
+}
+
+func TestNegotiate_UnsupportedFallsBackToDefault(t *testing.T) {
+	if locale := Negotiate("fr", "de, *"); locale != DefaultLocale {
+		t.Errorf("Expected default locale, got %q", locale)
+	}
+}
+
+func TestTranslate_SubstitutesParams(t *testing.T) {
+	message := Translate("da", "validation.max_length", map[string]interface{}{"field": "Emne", "max": 10})
+
+	if message != "Emne må højst være 10 tegn" {
+		t.Errorf("Unexpected message: %q", message)
+	}
+}
+
+func TestTranslate_FallsBackToDefaultLocaleThenKey(t *testing.T) {
+	if message := Translate("fr", "problem.not_found.title", nil); message != "Not found" {
+		t.Errorf("Expected english fallback, got %q", message)
+	}
+
+	if message := Translate("da", "no.such.key", nil); message != "no.such.key" {
+		t.Errorf("Expected key as fallback, got %q", message)
+	}
+}
+
+// Every key in the english catalog should be translated, so we don't fall
+// back to english unnoticed
+func TestCatalogsAreComplete(t *testing.T) {
+	for locale, messages := range catalogs {
+		for key := range english {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("Catalog %q is missing %q", locale, key)
+			}
+		}
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	errors := []validation.Error{
+		{Field: "topic", Code: validation.CodeRequired, Message: "Topic is mandatory"},
+		{Field: "unknown_field", Code: validation.CodeMaxLength, Params: map[string]interface{}{"max": 3}},
+	}
+
+	translated := ValidationErrors("da", errors)
+
+	if translated[0].Message != "Emne skal udfyldes" {
+		t.Errorf("Unexpected message: %q", translated[0].Message)
+	}
+	if translated[1].Message != "unknown_field må højst være 3 tegn" {
+		t.Errorf("Unexpected message: %q", translated[1].Message)
+	}
+	if errors[0].Message != "Topic is mandatory" {
+		t.Error("Expected original errors to be left untouched")
+	}
+}
+
+// The english catalog must agree with the messages the validation package
+// produces on its own
+func TestEnglishMatchesValidationMessages(t *testing.T) {
+	original := validation.Validator{}
+	original.Field("topic", "", validation.Required())
+	original.Field("body", "toolong", validation.MaxLength(3))
+
+	for _, err := range original.Errors() {
+		if translated := ValidationErrors("en", []validation.Error{err})[0]; translated.Message != err.Message {
+			t.Errorf("Mismatch: validation=%q, catalog=%q", err.Message, translated.Message)
+		}
+	}
+}
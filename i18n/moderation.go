@@ -0,0 +1,12 @@
+package i18n
+
+// Returns the reason of a moderation decision in the language of locale.
+// Reasons without a code, ie those given in the moderation config, are
+// returned as they are
+func ModerationReason(locale, code string, params map[string]interface{}, reason string) string {
+	if len(code) == 0 {
+		return reason
+	}
+
+	return Translate(locale, "moderation."+code, params)
+}
@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"strings"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+// Returns the name of field to use in messages. Fields without a label in
+// any catalog are used as is
+func fieldLabel(locale, field string) string {
+	key := "field." + field
+
+	if label := Translate(locale, key, nil); label != key {
+		return label
+	}
+
+	return field
+}
+
+// Returns a copy of errors, with each Message translated into locale
+func ValidationErrors(locale string, errors []validation.Error) []validation.Error {
+	translated := make([]validation.Error, 0, len(errors))
+
+	for _, err := range errors {
+		params := map[string]interface{}{"field": fieldLabel(locale, err.Field)}
+
+		for name, value := range err.Params {
+			params[name] = value
+		}
+
+		err.Message = Translate(locale, "validation."+err.Code, params)
+		translated = append(translated, err)
+	}
+
+	return translated
+}
+
+// Joins the translated messages of errors into a single sentence
+func ValidationSummary(errors []validation.Error) string {
+	messages := make([]string, 0, len(errors))
+
+	for _, err := range errors {
+		messages = append(messages, err.Message)
+	}
+
+	return strings.Join(messages, ". ")
+}
@@ -3,18 +3,25 @@ package integration_test
 import (
 	"github.com/dennis/hello_go/app"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TODO: This test is pretty fragile. It assumes that the dummy data exists
 // and looks in a very specific way. It should be changed, so that we add
 // the data here as a part of setup
 func TestMain(t *testing.T) {
-	app := app.App{}
-	app.Initialize()
+	// The tests run from this directory, so point at the data files in the
+	// root of the repository
+	app := app.App{Config: app.Config{MessagesFile: "../messages.json", UsersFile: "../users.json"}}
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("Error initializing app: %v", err)
+	}
 	go app.Run()
+	waitForServer(t)
 
 	// Let's make sure we're protected properly against unauthenticated
 	// requests
@@ -28,6 +35,21 @@ func TestMain(t *testing.T) {
 	t.Run("Auth: DeleteMessage", testAuthenticatedForDeleteMessage)
 }
 
+// Run starts listening in the background, so give it a moment before we
+// start sending requests
+func waitForServer(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", "localhost:8080"); err == nil {
+			conn.Close()
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Server didn't start")
+}
+
 func assertUnauthenticated(t *testing.T, r *http.Request, rerr error) {
 	if rerr != nil {
 		t.Errorf("Error creating request: %v", rerr)
@@ -1,11 +1,15 @@
 package main
 
 import (
-	"github.com/dennis/hello_go/app"
+	"fmt"
+	"os"
+
+	"github.com/dennis/hello_go/commands"
 )
 
 func main() {
-	app := app.App{}
-	app.Initialize()
-	app.Run()
+	if err := commands.Run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,116 @@
+package markdown
+
+import (
+	"container/list"
+	"sync"
+)
+
+// The number of bodies a Cache remembers, if not set
+const DefaultCacheSize = 10000
+
+// Remembers the sanitized HTML of recently rendered bodies, one revision per
+// key. When a body changes, it is rendered again. A nil Cache renders every
+// time
+type Cache struct {
+	// How many keys to remember. When there are more, the least recently
+	// used is forgotten. Zero means DefaultCacheSize
+	Size int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	// Renders the bodies. ToHTML, unless a test replaces it
+	render func(source string) string
+}
+
+type cacheEntry struct {
+	key    string
+	source string
+	html   string
+}
+
+// Returns source rendered with ToHTML, from the cache if it was rendered for
+// key before. Rendering is done without holding the lock, so a long body
+// doesn't hold up the others
+func (c *Cache) ToHTML(key, source string) string {
+	if c == nil {
+		return ToHTML(source)
+	}
+
+	if html, ok := c.lookup(key, source); ok {
+		return html
+	}
+
+	render := c.render
+	if render == nil {
+		render = ToHTML
+	}
+
+	html := render(source)
+	c.store(key, source, html)
+
+	return html
+}
+
+// Returns the HTML remembered for key, if it was rendered from source
+func (c *Cache) lookup(key, source string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	c.order.MoveToFront(element)
+	entry := element.Value.(*cacheEntry)
+
+	return entry.html, entry.source == source
+}
+
+// Remembers html as rendered from source for key. If the body was rendered
+// twice at the same time, the last to finish is kept, which is fine, as
+// lookup checks the source
+func (c *Cache) store(key, source, html string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.order = list.New()
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.source = source
+		entry.html = html
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, source: source, html: html})
+
+	size := c.Size
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+
+	for c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Returns the number of bodies in the cache
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
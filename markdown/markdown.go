@@ -0,0 +1,494 @@
+// Package markdown renders message bodies, written in Markdown, as HTML that
+// is safe to show in a browser. It covers the commonly used parts of
+// CommonMark: headings, paragraphs, emphasis, code spans and blocks, block
+// quotes, lists, links and images. Bare URLs are turned into links. Raw HTML
+// is passed through Render, and cleaned up by Sanitize.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern       = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	quotePattern      = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemPattern   = regexp.MustCompile(`^( {0,3})([-*+]|(\d{1,9})[.)])(?:[ \t]+(.*))?$`)
+	indentedPattern   = regexp.MustCompile(`^(?: {4}|\t)`)
+	rawTagPattern     = regexp.MustCompile(`^(?:<!--[\s\S]*?-->|</?[A-Za-z][A-Za-z0-9-]*(?:\s+[A-Za-z_:][A-Za-z0-9_.:-]*(?:\s*=\s*(?:[^\s"'=<>` + "`" + `]+|'[^']*'|"[^"]*"))?)*\s*/?>)`)
+	autolinkPattern   = regexp.MustCompile(`^<((?:https?|mailto):[^\s<>]+)>`)
+	bareURLPattern    = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]+`)
+	linkTargetPattern = regexp.MustCompile(`^\(\s*(<[^<>\n]*>|[^\s()]*(?:\([^\s()]*\)[^\s()]*)*)(?:\s+("[^"]*"|'[^']*'))?\s*\)`)
+)
+
+// Renders source as HTML. The result isn't safe to show until it has been
+// through Sanitize, see ToHTML
+func Render(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	return renderBlocks(strings.Split(source, "\n"))
+}
+
+// Renders source as sanitized HTML
+func ToHTML(source string) string {
+	return Sanitize(Render(source))
+}
+
+func renderBlocks(lines []string) string {
+	var b strings.Builder
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			b.WriteString("<p>" + renderInline(strings.TrimRight(strings.Join(paragraph, "\n"), " \t")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Indented code can't interrupt a paragraph
+		if len(paragraph) == 0 && indentedPattern.MatchString(line) && len(strings.TrimSpace(line)) > 0 {
+			end := i
+			code := []string{}
+
+			for j := i; j < len(lines) && (indentedPattern.MatchString(lines[j]) || len(strings.TrimSpace(lines[j])) == 0); j++ {
+				code = append(code, indentedPattern.ReplaceAllString(lines[j], ""))
+				if len(strings.TrimSpace(lines[j])) > 0 {
+					end = j
+				}
+			}
+
+			code = code[:end-i+1]
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "\n</code></pre>\n")
+			i = end
+			continue
+		}
+
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			flush()
+
+			fence := m[1]
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			code := []string{}
+
+			for i++; i < len(lines); i++ {
+				if trimmed := strings.TrimSpace(lines[i]); strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+					break
+				}
+
+				code = append(code, trimIndent(lines[i], indent))
+			}
+
+			b.WriteString("<pre><code")
+			if len(m[2]) > 0 {
+				b.WriteString(` class="language-` + html.EscapeString(m[2]) + `"`)
+			}
+			b.WriteString(">")
+			if len(code) > 0 {
+				b.WriteString(html.EscapeString(strings.Join(code, "\n")) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+			continue
+		}
+
+		// Checked before lists, as "- - -" is a rule
+		if rulePattern.MatchString(line) {
+			flush()
+			b.WriteString("<hr>\n")
+			continue
+		}
+
+		if quotePattern.MatchString(line) {
+			flush()
+
+			quoted := []string{}
+			for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quotePattern.ReplaceAllString(lines[i], ""))
+			}
+			i--
+
+			b.WriteString("<blockquote>\n" + renderBlocks(quoted) + "</blockquote>\n")
+			continue
+		}
+
+		if m := listItemPattern.FindStringSubmatch(line); m != nil && (len(paragraph) == 0 || len(m[4]) > 0) {
+			flush()
+			i = renderList(&b, lines, i)
+			continue
+		}
+
+		paragraph = append(paragraph, strings.TrimLeft(line, " \t"))
+	}
+
+	flush()
+
+	return b.String()
+}
+
+// Renders the list starting at lines[start], and returns the index of its
+// last line. Items continue on lines indented past their marker, and the
+// list ends at a line that isn't, or at an item of another kind
+func renderList(b *strings.Builder, lines []string, start int) int {
+	first := listItemPattern.FindStringSubmatch(lines[start])
+	ordered := len(first[3]) > 0
+	kind := first[2][len(first[2])-1:]
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	b.WriteString("<" + tag)
+	if n, _ := strconv.Atoi(first[3]); ordered && n != 1 {
+		b.WriteString(` start="` + strconv.Itoa(n) + `"`)
+	}
+	b.WriteString(">\n")
+
+	var item []string
+	indent := 0
+	end := start
+
+	flush := func() {
+		if item == nil {
+			return
+		}
+
+		for len(item) > 1 && len(strings.TrimSpace(item[len(item)-1])) == 0 {
+			item = item[:len(item)-1]
+		}
+
+		rendered := renderBlocks(item)
+
+		// The text starting a tight item, one without blank lines, isn't
+		// wrapped in a paragraph
+		if !contains(item, "") && strings.HasPrefix(rendered, "<p>") {
+			end := strings.Index(rendered, "</p>\n")
+			rendered = rendered[len("<p>"):end] + "\n" + rendered[end+len("</p>\n"):]
+		}
+
+		b.WriteString("<li>" + strings.TrimSuffix(rendered, "\n") + "</li>\n")
+		item = nil
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+
+		// Items indented to the content of the current one are nested in it
+		if m := listItemPattern.FindStringSubmatch(line); m != nil && (item == nil || len(m[1]) < indent) {
+			if (len(m[3]) > 0) != ordered || m[2][len(m[2])-1:] != kind {
+				break
+			}
+
+			flush()
+			indent = len(m[1]) + len(m[2]) + 1
+			item = []string{m[4]}
+			end = i
+			continue
+		}
+
+		if len(strings.TrimSpace(line)) == 0 {
+			// A blank line ends the list, unless the item or another one
+			// continues after it
+			if i+1 < len(lines) && (leadingSpaces(lines[i+1]) >= indent || listItemPattern.MatchString(lines[i+1])) {
+				item = append(item, "")
+				continue
+			}
+
+			break
+		}
+
+		if leadingSpaces(line) >= indent {
+			item = append(item, trimIndent(line, indent))
+			end = i
+			continue
+		}
+
+		// Lazy continuation of the paragraph in the item
+		if len(item) > 0 && len(strings.TrimSpace(item[len(item)-1])) > 0 && !isBlockStart(line) {
+			item = append(item, line)
+			end = i
+			continue
+		}
+
+		break
+	}
+
+	flush()
+	b.WriteString("</" + tag + ">\n")
+
+	return end
+}
+
+func isBlockStart(line string) bool {
+	return headingPattern.MatchString(line) || rulePattern.MatchString(line) || quotePattern.MatchString(line) ||
+		fencePattern.MatchString(line) || listItemPattern.MatchString(line)
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// Removes up to n spaces from the start of line
+func trimIndent(line string, n int) string {
+	for i := 0; i < n && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+
+	return line
+}
+
+func isPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Renders the inline content of a block: code spans, emphasis, links and
+// raw HTML. Everything else is escaped
+func renderInline(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(s) && isPunctuation(s[i+1]):
+			b.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+		case c == ' ' && strings.HasPrefix(s[i:], "  ") && strings.HasPrefix(strings.TrimLeft(s[i:], " "), "\n"):
+			b.WriteString("<br>\n")
+			i = len(s) - len(strings.TrimLeft(s[i:], " ")) + 1
+		case c == '`':
+			i = codeSpan(&b, s, i)
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if n := link(&b, s, i+1, true); n > 0 {
+				i = n
+			} else {
+				b.WriteString("!")
+				i++
+			}
+		case c == '[':
+			if n := link(&b, s, i, false); n > 0 {
+				i = n
+			} else {
+				b.WriteString("[")
+				i++
+			}
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + html.EscapeString(m[1]) + `">` + html.EscapeString(m[1]) + "</a>")
+				i += len(m[0])
+			} else if tag := rawTagPattern.FindString(s[i:]); len(tag) > 0 {
+				b.WriteString(tag)
+				i += len(tag)
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+		case c == '*' || c == '_':
+			i = emphasis(&b, s, i)
+		case (c == 'h' || c == 'w') && (i == 0 || !isAlphanumeric(s[i-1])) && bareURLPattern.MatchString(s[i:]):
+			i = bareURL(&b, s, i)
+		default:
+			b.WriteString(html.EscapeString(s[i : i+1]))
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// Renders the code span starting with the backticks at s[start]. Returns the
+// index after it
+func codeSpan(b *strings.Builder, s string, start int) int {
+	n := start
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	fence := s[start:n]
+
+	for i := n; i < len(s); {
+		j := strings.Index(s[i:], fence)
+		if j < 0 {
+			break
+		}
+		j += i
+
+		// The closing run must be exactly as long as the opening one
+		k := j + len(fence)
+		if k < len(s) && s[k] == '`' {
+			for k < len(s) && s[k] == '`' {
+				k++
+			}
+			i = k
+			continue
+		}
+
+		code := strings.ReplaceAll(s[n:j], "\n", " ")
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+			code = code[1 : len(code)-1]
+		}
+
+		b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+		return k
+	}
+
+	b.WriteString(fence)
+	return n
+}
+
+// Renders the link or image with text starting at s[start], which is '['.
+// Returns the index after it, or 0 if there isn't a link there
+func link(b *strings.Builder, s string, start int, image bool) int {
+	depth := 0
+	end := -1
+
+	for i := start; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			// Brackets in code spans don't count
+			if j := strings.IndexByte(s[i+1:], '`'); j >= 0 {
+				i += j + 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+
+	if end < 0 {
+		return 0
+	}
+
+	m := linkTargetPattern.FindStringSubmatch(s[end+1:])
+	if m == nil {
+		return 0
+	}
+
+	target := strings.TrimSuffix(strings.TrimPrefix(m[1], "<"), ">")
+	text := s[start+1 : end]
+
+	title := ""
+	if len(m[2]) > 0 {
+		title = ` title="` + html.EscapeString(m[2][1:len(m[2])-1]) + `"`
+	}
+
+	if image {
+		b.WriteString(`<img src="` + html.EscapeString(target) + `" alt="` + html.EscapeString(text) + `"` + title + ">")
+	} else {
+		b.WriteString(`<a href="` + html.EscapeString(target) + `"` + title + ">" + renderInline(text) + "</a>")
+	}
+
+	return end + 1 + len(m[0])
+}
+
+// Renders the emphasis starting with the delimiters at s[start], or the
+// delimiters as they are if they aren't closed. Returns the index after it
+func emphasis(b *strings.Builder, s string, start int) int {
+	c := s[start]
+	n := start
+	for n < len(s) && s[n] == c {
+		n++
+	}
+
+	width := n - start
+	if width > 2 {
+		width = 2
+	}
+	delimiter := s[start : start+width]
+
+	// Opening delimiters must be followed by text, and underscores must not
+	// be within a word, like in snake_case
+	opens := start+width < len(s) && s[start+width] != ' ' && s[start+width] != '\n' &&
+		(c == '*' || start == 0 || !isAlphanumeric(s[start-1]))
+
+	if opens {
+		for i := start + width + 1; i <= len(s)-width; i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+
+			if s[i:i+width] != delimiter || s[i-1] == ' ' || s[i-1] == '\n' {
+				continue
+			}
+
+			// A single delimiter must not be part of a double one
+			if width == 1 && ((i+1 < len(s) && s[i+1] == c) || s[i-1] == c) {
+				continue
+			}
+
+			if c == '_' && i+width < len(s) && isAlphanumeric(s[i+width]) {
+				continue
+			}
+
+			tag := "em"
+			if width == 2 {
+				tag = "strong"
+			}
+
+			b.WriteString("<" + tag + ">" + renderInline(s[start+width:i]) + "</" + tag + ">")
+			return i + width
+		}
+	}
+
+	b.WriteString(s[start:n])
+	return n
+}
+
+// Renders the bare URL starting at s[start] as a link. Trailing punctuation
+// and unbalanced parentheses are left out of it. Returns the index after it
+func bareURL(b *strings.Builder, s string, start int) int {
+	url := bareURLPattern.FindString(s[start:])
+
+	for len(url) > 0 {
+		last := url[len(url)-1]
+
+		if strings.IndexByte(".,:;!?'\"*_~", last) >= 0 {
+			url = url[:len(url)-1]
+		} else if last == ')' && strings.Count(url, ")") > strings.Count(url, "(") {
+			url = url[:len(url)-1]
+		} else {
+			break
+		}
+	}
+
+	href := url
+	if strings.HasPrefix(url, "www.") {
+		href = "http://" + url
+	}
+
+	b.WriteString(`<a href="` + html.EscapeString(href) + `">` + html.EscapeString(url) + "</a>")
+
+	return start + len(url)
+}
@@ -0,0 +1,127 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func assertRendered(t *testing.T, source, expected string) {
+	t.Helper()
+
+	if actual := strings.TrimSpace(ToHTML(source)); actual != expected {
+		t.Errorf("Rendering %q\nexpected: %s\nbut got:  %s", source, expected, actual)
+	}
+}
+
+func TestInline(t *testing.T) {
+	assertRendered(t, "Some **bold**, *em* and _em_", "<p>Some <strong>bold</strong>, <em>em</em> and <em>em</em></p>")
+	assertRendered(t, "snake_case_name and 2 * 3 * 4", "<p>snake_case_name and 2 * 3 * 4</p>")
+	assertRendered(t, "*unclosed", "<p>*unclosed</p>")
+	assertRendered(t, "Use `a < b` or `` ` ``", "<p>Use <code>a &lt; b</code> or <code>`</code></p>")
+	assertRendered(t, `\*not em\*`, "<p>*not em*</p>")
+	assertRendered(t, "5 > 3 & 2 < 4", "<p>5 &gt; 3 &amp; 2 &lt; 4</p>")
+}
+
+func TestLineBreaks(t *testing.T) {
+	assertRendered(t, "one\ntwo", "<p>one\ntwo</p>")
+	assertRendered(t, "one  \ntwo", "<p>one<br>\ntwo</p>")
+	assertRendered(t, "one\\\ntwo", "<p>one<br>\ntwo</p>")
+	assertRendered(t, "one\n\ntwo", "<p>one</p>\n<p>two</p>")
+}
+
+func TestLinks(t *testing.T) {
+	assertRendered(t, `[Go](https://go.dev "The site")`,
+		`<p><a href="https://go.dev" title="The site" rel="nofollow noopener noreferrer">Go</a></p>`)
+	assertRendered(t, "![A gopher](https://go.dev/gopher.png)", `<p><img src="https://go.dev/gopher.png" alt="A gopher"></p>`)
+	assertRendered(t, "<https://go.dev>", `<p><a href="https://go.dev" rel="nofollow noopener noreferrer">https://go.dev</a></p>`)
+	assertRendered(t, "[not a link]", "<p>[not a link]</p>")
+}
+
+func TestAutolinks(t *testing.T) {
+	assertRendered(t, "See https://go.dev/doc.", `<p>See <a href="https://go.dev/doc" rel="nofollow noopener noreferrer">https://go.dev/doc</a>.</p>`)
+	assertRendered(t, "(at www.go.dev)", `<p>(at <a href="http://www.go.dev" rel="nofollow noopener noreferrer">www.go.dev</a>)</p>`)
+	assertRendered(t, "https://en.wikipedia.org/wiki/Go_(game)",
+		`<p><a href="https://en.wikipedia.org/wiki/Go_(game)" rel="nofollow noopener noreferrer">https://en.wikipedia.org/wiki/Go_(game)</a></p>`)
+	assertRendered(t, "`https://go.dev`", "<p><code>https://go.dev</code></p>")
+	assertRendered(t, "nothttps://go.dev", "<p>nothttps://go.dev</p>")
+}
+
+func TestHeadingsAndRules(t *testing.T) {
+	assertRendered(t, "# Title #\n## Sub *title*", "<h1>Title</h1>\n<h2>Sub <em>title</em></h2>")
+	assertRendered(t, "#hashtag", "<p>#hashtag</p>")
+	assertRendered(t, "above\n\n---\n\n* * *", "<p>above</p>\n<hr>\n<hr>")
+}
+
+func TestCodeBlocks(t *testing.T) {
+	assertRendered(t, "```go\nfmt.Println(\"<hi>\")\n\n// *not em*\n```",
+		"<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n\n// *not em*\n</code></pre>")
+	assertRendered(t, "~~~\nunclosed", "<pre><code>unclosed\n</code></pre>")
+	assertRendered(t, "    indented\n\n    code\n\nafter", "<pre><code>indented\n\ncode\n</code></pre>\n<p>after</p>")
+	assertRendered(t, "text\n    not code", "<p>text\nnot code</p>")
+}
+
+func TestBlockquotes(t *testing.T) {
+	assertRendered(t, "> quoted\n> > nested", "<blockquote>\n<p>quoted</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>")
+}
+
+func TestLists(t *testing.T) {
+	assertRendered(t, "- one\n- two\n  continued\n* other", "<ul>\n<li>one</li>\n<li>two\ncontinued</li>\n</ul>\n<ul>\n<li>other</li>\n</ul>")
+	assertRendered(t, "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>")
+	assertRendered(t, "- outer\n  - inner", "<ul>\n<li>outer\n<ul>\n<li>inner</li>\n</ul></li>\n</ul>")
+	assertRendered(t, "- loose\n\n  second", "<ul>\n<li><p>loose</p>\n<p>second</p></li>\n</ul>")
+}
+
+func TestCache(t *testing.T) {
+	cache := &Cache{Size: 2}
+
+	if html := cache.ToHTML("1", "*one*"); html != "<p><em>one</em></p>\n" {
+		t.Errorf("Unexpected HTML: %s", html)
+	}
+
+	if html := cache.ToHTML("1", "*edited*"); html != "<p><em>edited</em></p>\n" {
+		t.Errorf("Expected a new revision to be rendered, got: %s", html)
+	}
+
+	cache.ToHTML("2", "two")
+	cache.ToHTML("1", "*edited*")
+	cache.ToHTML("3", "three")
+
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 bodies in the cache, got %d", cache.Len())
+	}
+
+	if _, ok := cache.entries["2"]; ok {
+		t.Errorf("Expected the least recently used body to be forgotten")
+	}
+}
+
+func TestCacheRendersWithoutTheLock(t *testing.T) {
+	cache := &Cache{}
+	done := make(chan string)
+
+	// Len takes the lock, so it would wait forever if rendering held it
+	cache.render = func(source string) string {
+		cache.Len()
+		return ToHTML(source)
+	}
+
+	go func() { done <- cache.ToHTML("1", "*one*") }()
+
+	select {
+	case html := <-done:
+		if html != "<p><em>one</em></p>\n" || cache.Len() != 1 {
+			t.Errorf("Unexpected HTML: %s", html)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected rendering not to hold the lock")
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var cache *Cache
+
+	if html := cache.ToHTML("1", "**x**"); html != "<p><strong>x</strong></p>\n" {
+		t.Errorf("Unexpected HTML: %s", html)
+	}
+}
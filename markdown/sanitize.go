@@ -0,0 +1,199 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// The elements kept by Sanitize, with the attributes they may have. Other
+// elements are removed, leaving their text
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"b":          nil,
+	"blockquote": nil,
+	"br":         nil,
+	"code":       {"class"},
+	"del":        nil,
+	"em":         nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title"},
+	"li":         nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"s":          nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"ul":         nil,
+}
+
+// Elements that are removed along with their content
+var droppedElements = map[string]bool{
+	"embed":    true,
+	"iframe":   true,
+	"math":     true,
+	"noscript": true,
+	"object":   true,
+	"script":   true,
+	"select":   true,
+	"style":    true,
+	"svg":      true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+// The URL schemes links and images may use. URLs without a scheme are
+// relative, and allowed too
+var allowedSchemes = map[string]map[string]bool{
+	"href": {"http": true, "https": true, "mailto": true},
+	"src":  {"http": true, "https": true},
+}
+
+var (
+	languageClassPattern = regexp.MustCompile(`^language-[A-Za-z0-9_+#.-]+$`)
+	numberPattern        = regexp.MustCompile(`^[0-9]{1,9}$`)
+)
+
+// Returns fragment with everything but allowedElements and their allowed
+// attributes removed. Scripts, styles and the like are removed with their
+// content, so are event handlers and URLs with schemes like javascript:. The
+// elements left are balanced, and links are marked rel="nofollow noopener
+// noreferrer"
+func Sanitize(fragment string) string {
+	var b strings.Builder
+	open := []string{}
+	dropping := ""
+
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(fragment))
+
+	for {
+		tokenType := tokenizer.Next()
+
+		// The tokenizer only fails reading, which a strings.Reader doesn't,
+		// so this is the end of fragment
+		if tokenType == nethtml.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+
+		if len(dropping) > 0 {
+			if tokenType == nethtml.EndTagToken && token.Data == dropping {
+				dropping = ""
+			}
+
+			continue
+		}
+
+		switch tokenType {
+		case nethtml.TextToken:
+			b.WriteString(html.EscapeString(token.Data))
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedElements[token.Data] {
+				if tokenType == nethtml.StartTagToken {
+					dropping = token.Data
+				}
+
+				continue
+			}
+
+			allowed, ok := allowedElements[token.Data]
+			if !ok {
+				continue
+			}
+
+			b.WriteString("<" + token.Data)
+
+			for _, attr := range token.Attr {
+				if value, ok := sanitizeAttribute(token.Data, attr, allowed); ok {
+					b.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+				}
+			}
+
+			if token.Data == "a" {
+				b.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+
+			b.WriteString(">")
+
+			if !voidElements[token.Data] {
+				open = append(open, token.Data)
+			}
+		case nethtml.EndTagToken:
+			// End tags without a matching start tag are left out. Those that
+			// have one close the elements opened since
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+// Returns the value attr may have on element, and whether it may have it at
+// all
+func sanitizeAttribute(element string, attr nethtml.Attribute, allowed []string) (string, bool) {
+	if len(attr.Namespace) > 0 || !contains(allowed, attr.Key) {
+		return "", false
+	}
+
+	value := strings.TrimSpace(attr.Val)
+
+	switch {
+	case attr.Key == "href" || attr.Key == "src":
+		return value, isAllowedURL(value, allowedSchemes[attr.Key])
+	case element == "code" && attr.Key == "class":
+		return value, languageClassPattern.MatchString(value)
+	case element == "ol" && attr.Key == "start":
+		return value, numberPattern.MatchString(value)
+	}
+
+	return attr.Val, true
+}
+
+func isAllowedURL(value string, schemes map[string]bool) bool {
+	u, err := url.Parse(value)
+
+	if err != nil {
+		return false
+	}
+
+	return len(u.Scheme) == 0 && !strings.HasPrefix(value, "//") || schemes[strings.ToLower(u.Scheme)]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package markdown
+
+import "testing"
+
+func assertSanitized(t *testing.T, fragment, expected string) {
+	t.Helper()
+
+	if actual := Sanitize(fragment); actual != expected {
+		t.Errorf("Sanitizing %q\nexpected: %s\nbut got:  %s", fragment, expected, actual)
+	}
+}
+
+func TestSanitizeKeepsAllowedElements(t *testing.T) {
+	assertSanitized(t, "<p>Hi <strong>there</strong><br></p>", "<p>Hi <strong>there</strong><br></p>")
+	assertSanitized(t, `<code class="language-go">x</code>`, `<code class="language-go">x</code>`)
+	assertSanitized(t, `<ol start="2"><li>x</li></ol>`, `<ol start="2"><li>x</li></ol>`)
+}
+
+func TestSanitizeRemovesScripts(t *testing.T) {
+	assertSanitized(t, `a<script>alert("</p>")</script>b`, "ab")
+	assertSanitized(t, "<style>p { display: none }</style>a", "a")
+	assertSanitized(t, `<iframe src="https://evil.example"></iframe>a`, "a")
+	assertSanitized(t, "<svg><script>alert(1)</script></svg>a", "a")
+}
+
+func TestSanitizeRemovesEventHandlers(t *testing.T) {
+	assertSanitized(t, `<p onclick="alert(1)">x</p>`, "<p>x</p>")
+	assertSanitized(t, `<img src="https://go.dev/x.png" onerror="alert(1)">`, `<img src="https://go.dev/x.png">`)
+	assertSanitized(t, `<p style="color: red" class="x">x</p>`, "<p>x</p>")
+}
+
+func TestSanitizeRemovesUnsafeURLs(t *testing.T) {
+	for _, href := range []string{"javascript:alert(1)", "JavaScript:alert(1)", "jav&#x61;script:alert(1)", " javascript:alert(1)", "java\tscript:alert(1)", "vbscript:x", "data:text/html,x", "//evil.example"} {
+		assertSanitized(t, `<a href="`+href+`">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`)
+	}
+
+	assertSanitized(t, `<a href="/api/messages/1">x</a>`, `<a href="/api/messages/1" rel="nofollow noopener noreferrer">x</a>`)
+	assertSanitized(t, `<a href="mailto:dennis@example.com">x</a>`, `<a href="mailto:dennis@example.com" rel="nofollow noopener noreferrer">x</a>`)
+	assertSanitized(t, `<img src="mailto:x">`, "<img>")
+	assertSanitized(t, `<code class="x onclick">y</code>`, "<code>y</code>")
+}
+
+func TestSanitizeKeepsTextOfUnknownElements(t *testing.T) {
+	assertSanitized(t, "<div><span>text</span></div>", "text")
+	assertSanitized(t, "<form><input value=x>text</form>", "text")
+	assertSanitized(t, "<!-- comment -->&lt;b&gt;", "&lt;b&gt;")
+}
+
+func TestSanitizeBalancesElements(t *testing.T) {
+	assertSanitized(t, "<p><em>unclosed", "<p><em>unclosed</em></p>")
+	assertSanitized(t, "</p>stray</strong>", "stray")
+	assertSanitized(t, "<p><em>x</p>y", "<p><em>x</em></p>y")
+}
+
+func TestToHTMLSanitizesRawHTML(t *testing.T) {
+	assertRendered(t, "Hi <b onmouseover=\"alert(1)\">there</b><script>alert(1)</script>", "<p>Hi <b>there</b></p>")
+	assertRendered(t, "[click](javascript:alert(1))", `<p><a rel="nofollow noopener noreferrer">click</a></p>`)
+}
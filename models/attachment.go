@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+const attachmentNameMaxLength = 255
+
+// A file attached to a message. The content is kept in blob storage, see
+// package blobs
+type Attachment struct {
+	// Unique within the message
+	ID   string `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+	// Sniffed from the content, rather than trusting the client
+	ContentType string `json:"content_type" xml:"content_type"`
+	// In bytes
+	Size int64 `json:"size" xml:"size"`
+	// Hex encoded SHA-256 of the content, and the key of its blob
+	SHA256    string    `json:"sha256" xml:"sha256"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+}
+
+func (a *Attachment) Validate() []validation.Error {
+	v := validation.Validator{}
+
+	v.Field("name", a.Name,
+		validation.Required(),
+		validation.ValidUTF8(),
+		validation.MaxLength(attachmentNameMaxLength),
+		validation.ForbiddenCharacters(CurrentLimits.ForbiddenCharacters))
+	v.Size("file", a.Size, int64(CurrentLimits.AttachmentMaxSize))
+
+	return v.Errors()
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentValidation(t *testing.T) {
+	a := Attachment{Name: "report.pdf", Size: int64(CurrentLimits.AttachmentMaxSize)}
+
+	if err := a.Validate(); len(err) > 0 {
+		t.Errorf("Expected %+v to be valid, got: %v", a, err)
+	}
+
+	a = Attachment{Name: strings.Repeat("x", attachmentNameMaxLength+1), Size: int64(CurrentLimits.AttachmentMaxSize) + 1}
+
+	err := a.Validate()
+
+	if len(err) != 2 || err[0].Field != "name" || err[1].Field != "file" {
+		t.Errorf("Expected name and file to be invalid, got: %v", err)
+	}
+}
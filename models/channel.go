@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/dennis/hello_go/validation"
+)
+
+// The channel of messages that don't name one, like the seed data and
+// messages posted to /api/messages
+const DefaultChannelID = "general"
+
+// Created at startup if it doesn't exist. It is open, so everyone can read
+// and post there, like before channels existed
+var DefaultChannel = Channel{
+	ID:          DefaultChannelID,
+	Name:        "General",
+	Description: "Messages for everyone",
+	Open:        true,
+	Members:     []string{},
+}
+
+// A board grouping messages. Only members can read and post messages in it
+type Channel struct {
+	ID          string `json:"id" xml:"id"`
+	Name        string `json:"name" xml:"name"`
+	Description string `json:"description" xml:"description"`
+	// The user who created the channel. Only the owner may add or remove
+	// members. Empty for the default channel
+	Owner string `json:"owner,omitempty" xml:"owner,omitempty"`
+	// Everyone is a member of an open channel
+	Open bool `json:"open" xml:"open"`
+	// Usernames of the members, including the owner
+	Members []string `json:"members" xml:"members>member"`
+}
+
+func (c *Channel) IsMember(username string) bool {
+	if c.Open {
+		return true
+	}
+
+	for _, member := range c.Members {
+		if member == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Channel) Validate() []validation.Error {
+	v := validation.Validator{}
+
+	v.Field("name", c.Name,
+		validation.Required(),
+		validation.ValidUTF8(),
+		validation.MaxLength(CurrentLimits.ChannelNameMaxLength),
+		validation.ForbiddenCharacters(CurrentLimits.ForbiddenCharacters))
+	v.Field("description", c.Description,
+		validation.ValidUTF8(),
+		validation.MaxLength(CurrentLimits.ChannelDescriptionMaxLength),
+		validation.ForbiddenCharacters(CurrentLimits.ForbiddenCharacters))
+
+	return v.Errors()
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannelMembership(t *testing.T) {
+	c := Channel{Name: "Go", Members: []string{"dennis"}}
+
+	if !c.IsMember("dennis") || c.IsMember("marianne") {
+		t.Errorf("Expected only dennis to be a member of %+v", c)
+	}
+
+	if !DefaultChannel.IsMember("marianne") {
+		t.Error("Expected everyone to be a member of the default channel")
+	}
+}
+
+func TestChannelValidation(t *testing.T) {
+	if err := DefaultChannel.Validate(); len(err) > 0 {
+		t.Errorf("Expected the default channel to be valid, got: %v", err)
+	}
+
+	c := Channel{Name: " ", Description: strings.Repeat("x", CurrentLimits.ChannelDescriptionMaxLength+1)}
+
+	err := c.Validate()
+
+	if len(err) != 2 || err[0].Field != "name" || err[1].Field != "description" {
+		t.Errorf("Expected name and description to be invalid, got: %v", err)
+	}
+}
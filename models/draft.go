@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+// A message its author hasn't published yet. Only they can see it. It is
+// published at PublishAt, if that is set, or when the author publishes it.
+// The fields of the Message are those it will be published with, except the
+// ID, which is the ID of the draft
+type Draft struct {
+	Message
+	// When to publish the draft. Nil for drafts that aren't scheduled
+	PublishAt *time.Time `json:"publish_at,omitempty" xml:"publish_at,omitempty"`
+	// Why publishing the draft at PublishAt failed. It isn't scheduled
+	// anymore then, but can be fixed and scheduled again
+	PublishError string `json:"publish_error,omitempty" xml:"publish_error,omitempty"`
+}
+
+func (d *Draft) IsScheduled() bool {
+	return d.PublishAt != nil
+}
+
+// Validates the draft like a message, except that drafts which aren't
+// scheduled may leave out the topic and body until they are
+func (d *Draft) Validate() []validation.Error {
+	errors := []validation.Error{}
+
+	for _, err := range d.Message.Validate() {
+		if d.IsScheduled() || err.Code != validation.CodeRequired {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors
+}
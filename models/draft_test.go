@@ -0,0 +1,28 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDraftValidation(t *testing.T) {
+	draft := Draft{}
+
+	if errors := draft.Validate(); len(errors) != 0 {
+		t.Errorf("Expected an empty draft to be valid, got %v", errors)
+	}
+
+	draft.Body = strings.Repeat("x", CurrentLimits.BodyMaxLength+1)
+
+	if errors := draft.Validate(); len(errors) != 1 || errors[0].Field != "body" {
+		t.Errorf("Expected the body to be too long, got %v", errors)
+	}
+
+	publishAt := time.Now()
+	draft = Draft{PublishAt: &publishAt}
+
+	if errors := draft.Validate(); len(errors) != 2 {
+		t.Errorf("Expected a scheduled draft to need a topic and body, got %v", errors)
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// A new message, or an edit of one, held for review by a moderator, see
+// package moderation. It is published when a moderator approves it
+type HeldMessage struct {
+	// The ID of the held message in the queue
+	ID string `json:"id" xml:"id"`
+	// The message as it will be published. Its ID is only set for edits, to
+	// the message edited
+	Message Message `json:"message" xml:"message"`
+	// Why it was held
+	Reason string    `json:"reason" xml:"reason"`
+	HeldAt time.Time `json:"held_at" xml:"held_at"`
+}
+
+// Tells if this is an edit of a published message, rather than a new one
+func (h *HeldMessage) IsEdit() bool {
+	return len(h.Message.ID) > 0
+}
@@ -0,0 +1,16 @@
+package models
+
+// The number of unread direct messages from a single user
+type InboxSender struct {
+	Username string `json:"username" xml:"username"`
+	Unread   int    `json:"unread" xml:"unread"`
+}
+
+// The direct messages a user has received
+type Inbox struct {
+	Total  int `json:"total" xml:"total"`
+	Unread int `json:"unread" xml:"unread"`
+	// Only senders with unread messages are listed
+	Senders  []InboxSender   `json:"senders" xml:"senders>sender"`
+	Messages []ListedMessage `json:"messages" xml:"messages>message"`
+}
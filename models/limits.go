@@ -0,0 +1,37 @@
+package models
+
+// Limits applied when validating models. They are package level, so they can
+// be changed from configuration at startup - before any requests are served
+type Limits struct {
+	TopicMaxLength              int    `json:"topic_max_length"`
+	BodyMaxLength               int    `json:"body_max_length"`
+	UsernameMaxLength           int    `json:"username_max_length"`
+	AuthTokenMinLength          int    `json:"auth_token_min_length"`
+	ChannelNameMaxLength        int    `json:"channel_name_max_length"`
+	ChannelDescriptionMaxLength int    `json:"channel_description_max_length"`
+	TagMaxLength                int    `json:"tag_max_length"`
+	MaxTags                     int    `json:"max_tags"`
+	MaxReactionEmoji            int    `json:"max_reaction_emoji"`
+	MaxReactionsPerUser         int    `json:"max_reactions_per_user"`
+	AttachmentMaxSize           int    `json:"attachment_max_size"`
+	MaxAttachments              int    `json:"max_attachments"`
+	ForbiddenCharacters         string `json:"forbidden_characters"`
+}
+
+var DefaultLimits = Limits{
+	TopicMaxLength:              200,
+	BodyMaxLength:               10000,
+	UsernameMaxLength:           32,
+	AuthTokenMinLength:          8,
+	ChannelNameMaxLength:        64,
+	ChannelDescriptionMaxLength: 1000,
+	TagMaxLength:                32,
+	MaxTags:                     10,
+	MaxReactionEmoji:            20,
+	MaxReactionsPerUser:         5,
+	AttachmentMaxSize:           10 << 20,
+	MaxAttachments:              10,
+	ForbiddenCharacters:         "\x00",
+}
+
+var CurrentLimits = DefaultLimits
@@ -1,21 +1,123 @@
 package models
 
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dennis/hello_go/validation"
+)
+
 type Message struct {
-	ID     string `json:"id"`
-	Topic  string `json:"topic"`
-	Body   string `json:"body"`
-	Author string `json:"author"`
+	ID     string `json:"id" xml:"id"`
+	Topic  string `json:"topic" xml:"topic"`
+	Body   string `json:"body" xml:"body"`
+	Author string `json:"author" xml:"author"`
+	// ID of the message this is a reply to. Empty for messages starting a
+	// thread
+	ParentID string `json:"parent_id,omitempty" xml:"parent_id,omitempty"`
+	// ID of the channel the message is posted in. Replies are always in the
+	// channel of their parent. Empty for direct messages
+	ChannelID string `json:"channel_id,omitempty" xml:"channel_id,omitempty"`
+	// Usernames of the recipients of a direct message. Only the author and
+	// the recipients can read it
+	Recipients []string `json:"recipients,omitempty" xml:"recipients>recipient,omitempty"`
+	// Categorise the message. They are normalized, see NormalizeTags
+	Tags []string `json:"tags,omitempty" xml:"tags>tag,omitempty"`
+	// Files attached to the message. They are uploaded separately, and
+	// ignored when creating or updating messages
+	Attachments []Attachment `json:"attachments,omitempty" xml:"attachments>attachment,omitempty"`
+	// When the message was created, and last updated. Nil for messages from
+	// before they were recorded
+	CreatedAt *time.Time `json:"created_at,omitempty" xml:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" xml:"updated_at,omitempty"`
+	// When the message was moved to the trash. Nil unless it is deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+	// When a moderator pinned the message to the top of the list of
+	// messages. Nil unless it is pinned
+	PinnedAt *time.Time `json:"pinned_at,omitempty" xml:"pinned_at,omitempty"`
+	// Locked messages, and the replies to them, can't be replied to or
+	// updated. Only moderators may pin and lock messages, so both are ignored
+	// when creating or updating messages
+	Locked bool `json:"locked,omitempty" xml:"locked,omitempty"`
+}
+
+// A message as listed to a single user
+type ListedMessage struct {
+	Message
+	// Whether the user hasn't read the message yet
+	Unread bool `json:"unread" xml:"unread"`
+	// The reactions to the message, counted by emoji
+	Reactions []ReactionCount `json:"reactions,omitempty" xml:"reactions>reaction,omitempty"`
+	// The Body, which is Markdown, rendered as sanitized HTML
+	BodyHTML string `json:"body_html" xml:"body_html"`
+}
+
+// Direct messages are sent to recipients instead of being posted in a
+// channel
+func (m *Message) IsDirect() bool {
+	return len(m.Recipients) > 0
 }
 
-func (m *Message) Validate() []string {
-	errors := make([]string, 0)
+// Deleted messages are in the trash of their author, hidden from everyone
+// until they are restored or purged
+func (m *Message) IsDeleted() bool {
+	return m.DeletedAt != nil
+}
 
-	if len(m.Topic) == 0 {
-		errors = append(errors, "Topic is mandatory")
+func (m *Message) IsPinned() bool {
+	return m.PinnedAt != nil
+}
+
+func (m *Message) IsRecipient(username string) bool {
+	for _, recipient := range m.Recipients {
+		if recipient == username {
+			return true
+		}
 	}
-	if len(m.Body) == 0 {
-		errors = append(errors, "Body is mandatory")
+
+	return false
+}
+
+// Tags are lower case letters, digits, - and _, starting with a letter or
+// digit
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Returns tags in the form they are stored: trimmed, lower case and without
+// a leading #. Empty tags and duplicates are left out, and nil is returned
+// if none are left
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+		if len(tag) > 0 && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
 	}
 
-	return errors
+	return normalized
+}
+
+func (m *Message) Validate() []validation.Error {
+	v := validation.Validator{}
+
+	v.Field("topic", m.Topic,
+		validation.Required(),
+		validation.ValidUTF8(),
+		validation.MaxLength(CurrentLimits.TopicMaxLength),
+		validation.ForbiddenCharacters(CurrentLimits.ForbiddenCharacters))
+	v.Field("body", m.Body,
+		validation.Required(),
+		validation.ValidUTF8(),
+		validation.MaxLength(CurrentLimits.BodyMaxLength),
+		validation.ForbiddenCharacters(CurrentLimits.ForbiddenCharacters))
+	v.Each("tags", m.Tags, CurrentLimits.MaxTags,
+		validation.MaxLength(CurrentLimits.TagMaxLength),
+		validation.Matches(tagPattern))
+
+	return v.Errors()
 }
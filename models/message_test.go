@@ -1,7 +1,10 @@
 package models
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/dennis/hello_go/validation"
 )
 
 func TestValidMessage(t *testing.T) {
@@ -27,7 +30,7 @@ func TestMissingTopic(t *testing.T) {
 
 	err := m.Validate()
 
-	if len(err) != 0 && err[0] != "Topic is mandatory" {
+	if len(err) != 0 && (err[0].Field != "topic" || err[0].Message != "Topic is mandatory") {
 		t.Errorf("Expected validation to fail with 'Topic is mandatory', but got: %v", err)
 	}
 }
@@ -42,7 +45,7 @@ func TestMissingBody(t *testing.T) {
 
 	err := m.Validate()
 
-	if len(err) != 0 && err[0] != "Body is mandatory" {
+	if len(err) != 0 && (err[0].Field != "body" || err[0].Message != "Body is mandatory") {
 		t.Errorf("Expected validation to fail with 'Body is mandatory', but got: %v", err)
 	}
 }
@@ -73,3 +76,61 @@ func TestValidMessageWithMissingID(t *testing.T) {
 	}
 }
 
+
+func TestWhitespaceOnlyTopic(t *testing.T) {
+	m := Message{
+		Topic: "   ",
+		Body:  "Body",
+	}
+
+	err := m.Validate()
+
+	if len(err) != 1 || err[0].Code != validation.CodeRequired {
+		t.Errorf("Expected validation to fail with code 'required', but got: %v", err)
+	}
+}
+
+func TestTooLongBody(t *testing.T) {
+	m := Message{
+		Topic: "Topic",
+		Body:  strings.Repeat("x", CurrentLimits.BodyMaxLength+1),
+	}
+
+	err := m.Validate()
+
+	if len(err) != 1 || err[0].Field != "body" || err[0].Code != validation.CodeMaxLength {
+		t.Errorf("Expected validation to fail with code 'max_length', but got: %v", err)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" Go", "#go", "", "Release"})
+
+	if strings.Join(tags, ",") != "go,release" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+
+	if tags := NormalizeTags([]string{" ", "#"}); tags != nil {
+		t.Errorf("Expected nil, got %v", tags)
+	}
+}
+
+func TestInvalidTags(t *testing.T) {
+	m := Message{
+		Topic: "Topic",
+		Body:  "Body",
+		Tags:  []string{"go", "no spaces", strings.Repeat("x", CurrentLimits.TagMaxLength+1)},
+	}
+
+	err := m.Validate()
+
+	if len(err) != 2 || err[0].Code != validation.CodeInvalidFormat || err[1].Code != validation.CodeMaxLength {
+		t.Errorf("Expected validation to fail for two tags, but got: %v", err)
+	}
+
+	m.Tags = make([]string, CurrentLimits.MaxTags+1)
+
+	if err := m.Validate(); len(err) != 1 || err[0].Code != validation.CodeMaxItems {
+		t.Errorf("Expected validation to fail with code 'max_items', but got: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// A mention is @ followed by a username, not preceded by something that
+// could be part of an email address. A trailing . ends a sentence rather than
+// the username
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@-])@([A-Za-z0-9_.-]*[A-Za-z0-9_-])`)
+
+// Mentions in code aren't meant to notify anyone
+var codePattern = regexp.MustCompile("(?s)```.*?(?:```|$)|~~~.*?(?:~~~|$)|`[^`\n]*`")
+
+// Tells a user that they were mentioned in a message
+type Notification struct {
+	ID string `json:"id" xml:"id"`
+	// The user notified
+	Username  string `json:"-" xml:"-"`
+	MessageID string `json:"message_id" xml:"message_id"`
+	// The author of the message, who mentioned the user
+	Author    string    `json:"author" xml:"author"`
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	Read      bool      `json:"read" xml:"read"`
+}
+
+// Returns the usernames mentioned in the body, as @username, in the order
+// they are first mentioned. They are written as in the body, and may not
+// match any user. Mentions in code spans and blocks are left out
+func (m *Message) Mentions() []string {
+	var mentions []string
+	seen := map[string]bool{}
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(codePattern.ReplaceAllString(m.Body, " "), -1) {
+		if key := strings.ToLower(match[1]); !seen[key] {
+			seen[key] = true
+			mentions = append(mentions, match[1])
+		}
+	}
+
+	return mentions
+}
+
+// The notifications of a user
+type Notifications struct {
+	Total  int `json:"total" xml:"total"`
+	Unread int `json:"unread" xml:"unread"`
+	// Newest first
+	Notifications []Notification `json:"notifications" xml:"notifications>notification"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	cases := map[string][]string{
+		"Hi @Marianne, meet @dennis.":               {"Marianne", "dennis"},
+		"@dennis @Dennis @marianne":                 {"dennis", "marianne"},
+		"(@first_name.last-name)":                   {"first_name.last-name"},
+		"Mail dennis@example.com or @@x or a@b":     nil,
+		"Not `@dennis` or\n```\n@marianne\n```\n@x": {"x"},
+		"No one @ all":                              nil,
+	}
+
+	for body, expected := range cases {
+		message := Message{Body: body}
+
+		if mentions := message.Mentions(); !reflect.DeepEqual(mentions, expected) {
+			t.Errorf("Mentions in %q: expected %v, got %v", body, expected, mentions)
+		}
+	}
+}
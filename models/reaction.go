@@ -0,0 +1,41 @@
+package models
+
+import (
+	"regexp"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+// Emoji may combine several code points (skin tones, joiners, flags), but
+// must include a symbol, and no letters, spaces or control characters
+var emojiPattern = regexp.MustCompile(`^[^\p{L}\p{Z}\p{Cc}]*\p{So}[^\p{L}\p{Z}\p{Cc}]*$`)
+
+// The longest emoji sequences (ie families) are 7 code points
+const emojiMaxLength = 16
+
+// A user reacting to a message with an emoji
+type Reaction struct {
+	MessageID string
+	Username  string
+	Emoji     string
+}
+
+func (r *Reaction) Validate() []validation.Error {
+	v := validation.Validator{}
+
+	v.Field("emoji", r.Emoji,
+		validation.Required(),
+		validation.ValidUTF8(),
+		validation.MaxLength(emojiMaxLength),
+		validation.Matches(emojiPattern))
+
+	return v.Errors()
+}
+
+// The reactions to a message with the same emoji, as seen by a single user
+type ReactionCount struct {
+	Emoji string `json:"emoji" xml:"emoji"`
+	Count int    `json:"count" xml:"count"`
+	// Whether the user is one of those reacting
+	Me bool `json:"me" xml:"me"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+func TestReactionValidation(t *testing.T) {
+	for _, emoji := range []string{"👍", "👍🏽", "❤️", "👨‍👩‍👧", "🇩🇰"} {
+		r := Reaction{Emoji: emoji}
+
+		if err := r.Validate(); len(err) > 0 {
+			t.Errorf("Expected %q to be valid, got: %v", emoji, err)
+		}
+	}
+
+	for _, emoji := range []string{"", "a", "👍a", "👍 👍", "1", "\x00👍", "👍👍👍👍👍👍👍👍👍👍👍👍👍👍👍👍👍"} {
+		r := Reaction{Emoji: emoji}
+
+		if err := r.Validate(); len(err) != 1 || err[0].Field != "emoji" {
+			t.Errorf("Expected %q to be invalid, got: %v", emoji, err)
+		}
+	}
+}
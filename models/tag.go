@@ -0,0 +1,7 @@
+package models
+
+// A tag and the number of messages using it
+type TagCount struct {
+	Name  string `json:"name" xml:"name"`
+	Count int    `json:"count" xml:"count"`
+}
@@ -1,6 +1,40 @@
 package models
 
+import (
+	"regexp"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
 type User struct {
 	Username  string `json:"username"`
 	AuthToken string `json:"auth_token"`
+	// Preferred language (ie "da"). Overrides Accept-Language when set
+	Locale string `json:"locale,omitempty"`
+	// Admins may use the /api/admin routes
+	Admin bool `json:"admin,omitempty"`
+	// Moderators may pin and lock messages
+	Moderator bool `json:"moderator,omitempty"`
+}
+
+// Admins are moderators too
+func (u *User) IsModerator() bool {
+	return u.Admin || u.Moderator
+}
+
+func (u *User) Validate() []validation.Error {
+	v := validation.Validator{}
+
+	v.Field("username", u.Username,
+		validation.Required(),
+		validation.MaxLength(CurrentLimits.UsernameMaxLength),
+		validation.Matches(usernamePattern))
+	v.Field("auth_token", u.AuthToken,
+		validation.Required(),
+		validation.MinLength(CurrentLimits.AuthTokenMinLength),
+		validation.ForbiddenCharacters(":"))
+
+	return v.Errors()
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/dennis/hello_go/validation"
+)
+
+func TestValidUser(t *testing.T) {
+	u := User{Username: "Dennis", AuthToken: "authtokendennis"}
+
+	if err := u.Validate(); len(err) > 0 {
+		t.Errorf("Expected user to be valid, but got errors: %v", err)
+	}
+}
+
+func TestUsernameWithSpaces(t *testing.T) {
+	u := User{Username: "Dennis Hansen", AuthToken: "authtokendennis"}
+
+	err := u.Validate()
+
+	if len(err) != 1 || err[0].Field != "username" || err[0].Code != validation.CodeInvalidFormat {
+		t.Errorf("Expected username to be invalid, but got: %v", err)
+	}
+}
+
+func TestShortAuthToken(t *testing.T) {
+	u := User{Username: "Dennis", AuthToken: "short"}
+
+	err := u.Validate()
+
+	if len(err) != 1 || err[0].Field != "auth_token" || err[0].Code != validation.CodeMinLength {
+		t.Errorf("Expected auth_token to be invalid, but got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// The filters to moderate messages with, as read from the config file. The
+// zero Config allows everything
+type Config struct {
+	// Messages containing any of these words, ignoring case, are rejected or
+	// held for review
+	RejectWords []string `json:"reject_words,omitempty"`
+	HoldWords   []string `json:"hold_words,omitempty"`
+	// Messages with more links than this are held for review. 0 means no
+	// limit
+	MaxLinks int `json:"max_links,omitempty"`
+	// Regular expressions (RE2 syntax) checked against the topic and body
+	Rules []RuleConfig `json:"rules,omitempty"`
+}
+
+type RuleConfig struct {
+	Pattern string `json:"pattern"`
+	// hold or reject
+	Action Action `json:"action"`
+	// Tells the author, or the moderators, why. Defaults to the pattern
+	Reason string `json:"reason,omitempty"`
+}
+
+// Returns the problems with the config, prefixed by the name of the setting
+// below prefix, ie moderation.rules[0].pattern
+func (c Config) Check(prefix string) []string {
+	problems := []string{}
+
+	if c.MaxLinks < 0 {
+		problems = append(problems, prefix+".max_links: must not be negative")
+	}
+
+	for i, rule := range c.Rules {
+		name := fmt.Sprintf("%s.rules[%d]", prefix, i)
+
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.pattern: %v", name, err))
+		}
+		if rule.Action == Allow {
+			problems = append(problems, name+".action: must be hold or reject")
+		}
+	}
+
+	return problems
+}
+
+// Builds the filters configured, rejecting words first, then holding words,
+// the rules and the link limit. Fails if a rule doesn't compile
+func (c Config) Pipeline() (Pipeline, error) {
+	pipeline := Pipeline{}
+
+	if len(c.RejectWords) > 0 {
+		pipeline = append(pipeline, NewWordList(c.RejectWords, Reject))
+	}
+	if len(c.HoldWords) > 0 {
+		pipeline = append(pipeline, NewWordList(c.HoldWords, Hold))
+	}
+
+	for i, rule := range c.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %v", i, err)
+		}
+
+		pipeline = append(pipeline, Rule{Pattern: pattern, Action: rule.Action, Reason: rule.Reason})
+	}
+
+	if c.MaxLinks > 0 {
+		pipeline = append(pipeline, LinkLimit{Max: c.MaxLinks})
+	}
+
+	return pipeline, nil
+}
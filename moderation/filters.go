@@ -0,0 +1,147 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Words are matched as a whole, so blocking "ass" doesn't block "class"
+const wordBoundary = `[^\pL\pN_]`
+
+// Catches messages containing any of a list of words in their topic or body,
+// ignoring case
+type WordList struct {
+	pattern *regexp.Regexp
+	action  Action
+}
+
+// Returns a WordList deciding action for messages with any of words. Empty
+// words are ignored
+func NewWordList(words []string, action Action) *WordList {
+	quoted := []string{}
+
+	for _, word := range words {
+		if word = strings.TrimSpace(word); len(word) > 0 {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return &WordList{action: action}
+	}
+
+	pattern := `(?i)(?:^|` + wordBoundary + `)(` + strings.Join(quoted, "|") + `)(?:$|` + wordBoundary + `)`
+
+	return &WordList{pattern: regexp.MustCompile(pattern), action: action}
+}
+
+func (w *WordList) Check(message models.Message) Decision {
+	if w.pattern == nil {
+		return Decision{Action: Allow}
+	}
+
+	for _, text := range []string{message.Topic, message.Body} {
+		if match := w.pattern.FindStringSubmatch(text); match != nil {
+			return Decision{
+				Action: w.action,
+				Reason: fmt.Sprintf("Contains the word %q", match[1]),
+				Code:   ReasonWord,
+				Params: map[string]interface{}{"word": match[1]},
+			}
+		}
+	}
+
+	return Decision{Action: Allow}
+}
+
+// Links are URLs starting with a scheme or www., as they are detected when
+// rendering the body, see markdown.Render
+var linkPattern = regexp.MustCompile(`(?i)(?:\b[a-z][a-z0-9+.-]*://|\bwww\.)[^\s<>()\[\]]+`)
+
+// Holds messages with more than Max links in their body for review. Spam
+// tends to be mostly links
+type LinkLimit struct {
+	Max int
+}
+
+func (l LinkLimit) Check(message models.Message) Decision {
+	if n := len(linkPattern.FindAllString(message.Body, -1)); n > l.Max {
+		return Decision{
+			Action: Hold,
+			Reason: fmt.Sprintf("Has %d links, more than %d", n, l.Max),
+			Code:   ReasonLinks,
+			Params: map[string]interface{}{"count": n, "max": l.Max},
+		}
+	}
+
+	return Decision{Action: Allow}
+}
+
+// Decides Action for messages with a topic or body matching Pattern
+type Rule struct {
+	Pattern *regexp.Regexp
+	Action  Action
+	// Defaults to telling which pattern matched
+	Reason string
+}
+
+func (r Rule) Check(message models.Message) Decision {
+	if !r.Pattern.MatchString(message.Topic) && !r.Pattern.MatchString(message.Body) {
+		return Decision{Action: Allow}
+	}
+
+	if len(r.Reason) > 0 {
+		return Decision{Action: r.Action, Reason: r.Reason}
+	}
+
+	return Decision{
+		Action: r.Action,
+		Reason: fmt.Sprintf("Matches %s", r.Pattern),
+		Code:   ReasonPattern,
+		Params: map[string]interface{}{"pattern": r.Pattern.String()},
+	}
+}
+
+// Scores how likely a message is to be unwanted (ie spam or abuse), from 0
+// to 1. Implementations may call out to another service
+type Classifier interface {
+	Classify(message models.Message) (float64, error)
+}
+
+// Turns the scores of a Classifier into decisions. Messages scoring at least
+// RejectAbove are rejected, and those scoring at least HoldAbove are held. A
+// threshold of 0 is not used. If the classifier fails, the message is held,
+// so a person can decide
+type ClassifierFilter struct {
+	Classifier  Classifier
+	HoldAbove   float64
+	RejectAbove float64
+}
+
+func (c ClassifierFilter) Check(message models.Message) Decision {
+	score, err := c.Classifier.Classify(message)
+
+	switch {
+	case err != nil:
+		return Decision{
+			Action: Hold,
+			Reason: fmt.Sprintf("Could not be classified: %v", err),
+			Code:   ReasonUnclassified,
+			Params: map[string]interface{}{"error": err.Error()},
+		}
+	case c.RejectAbove > 0 && score >= c.RejectAbove:
+		return Decision{Action: Reject, Reason: "Looks like spam or abuse", Code: ReasonSpam}
+	case c.HoldAbove > 0 && score >= c.HoldAbove:
+		return Decision{
+			Action: Hold,
+			Reason: fmt.Sprintf("Classified as unwanted with a score of %.2f", score),
+			Code:   ReasonScore,
+			Params: map[string]interface{}{"score": fmt.Sprintf("%.2f", score)},
+		}
+	}
+
+	return Decision{Action: Allow}
+}
@@ -0,0 +1,102 @@
+// Package moderation decides whether messages may be published. A Pipeline
+// runs a chain of filters, each of which may allow a message, hold it for
+// review by a moderator or reject it outright. Filters are configured with a
+// Config, and other checks (ie a spam classifier) can be plugged in by
+// implementing Filter or Classifier.
+package moderation
+
+import (
+	"fmt"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// What happens to a message. The actions are ordered by severity, so the
+// most severe decision of a chain wins
+type Action int
+
+const (
+	Allow Action = iota
+	Hold
+	Reject
+)
+
+var actionNames = map[Action]string{Allow: "allow", Hold: "hold", Reject: "reject"}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// Reads an action written as allow, hold or reject, ie in the config file
+func (a *Action) UnmarshalText(text []byte) error {
+	for action, name := range actionNames {
+		if name == string(text) {
+			*a = action
+			return nil
+		}
+	}
+
+	return fmt.Errorf("action must be allow, hold or reject, not %q", text)
+}
+
+// Codes identifying the reasons of the filters in this package, so they can
+// be translated
+const (
+	ReasonWord         = "word"
+	ReasonLinks        = "links"
+	ReasonPattern      = "pattern"
+	ReasonUnclassified = "unclassified"
+	ReasonSpam         = "spam"
+	ReasonScore        = "score"
+)
+
+// The outcome of checking a message. Reason tells the author why it was
+// rejected, or the moderators why it was held. Code identifies the reason,
+// and Params holds the values it mentions, so it can be translated. Reasons
+// given in the config have no code, as they are written by whoever runs the
+// server
+type Decision struct {
+	Action Action
+	Reason string
+	Code   string
+	Params map[string]interface{}
+}
+
+// Checks a message before it is created or updated. The message has been
+// validated, and Author is set
+type Filter interface {
+	Check(message models.Message) Decision
+}
+
+// Lets a plain function be used as a Filter
+type FilterFunc func(message models.Message) Decision
+
+func (f FilterFunc) Check(message models.Message) Decision {
+	return f(message)
+}
+
+// Runs filters in order. The first rejection stops the chain, otherwise the
+// first hold decides. A nil Pipeline allows everything
+type Pipeline []Filter
+
+func (p Pipeline) Check(message models.Message) Decision {
+	decision := Decision{Action: Allow}
+
+	for _, filter := range p {
+		d := filter.Check(message)
+
+		if d.Action == Reject {
+			return d
+		}
+
+		if d.Action > decision.Action {
+			decision = d
+		}
+	}
+
+	return decision
+}
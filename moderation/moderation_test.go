@@ -0,0 +1,129 @@
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+type fixedClassifier struct {
+	score float64
+	err   error
+}
+
+func (c fixedClassifier) Classify(models.Message) (float64, error) {
+	return c.score, c.err
+}
+
+func TestWordList(t *testing.T) {
+	words := NewWordList([]string{"spam", "c++", " "}, Reject)
+
+	cases := map[string]Action{
+		"Buy SPAM now":     Reject,
+		"spam":             Reject,
+		"I like C++!":      Reject,
+		"Spammers":         Allow,
+		"No sp am here":    Allow,
+		"Æspam is a word?": Allow,
+	}
+
+	for body, expected := range cases {
+		if d := words.Check(models.Message{Topic: "Hi", Body: body}); d.Action != expected {
+			t.Errorf("%q: expected %v, got %+v", body, expected, d)
+		}
+	}
+
+	if d := NewWordList(nil, Reject).Check(models.Message{Body: "spam"}); d.Action != Allow {
+		t.Errorf("Expected an empty list to allow everything, got %+v", d)
+	}
+}
+
+func TestLinkLimit(t *testing.T) {
+	limit := LinkLimit{Max: 2}
+
+	if d := limit.Check(models.Message{Body: "See https://www.example.com and [this](http://example.org)"}); d.Action != Allow {
+		t.Errorf("Expected two links to be allowed, got %+v", d)
+	}
+
+	if d := limit.Check(models.Message{Body: "www.a.com www.b.com ftp://c.com"}); d.Action != Hold || d.Reason != "Has 3 links, more than 2" || d.Code != ReasonLinks || d.Params["count"] != 3 || d.Params["max"] != 2 {
+		t.Errorf("Expected three links to be held, got %+v", d)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := Pipeline{
+		Rule{Pattern: regexp.MustCompile(`(?i)casino`), Action: Hold},
+		ClassifierFilter{Classifier: fixedClassifier{score: 0.5}, HoldAbove: 0.4, RejectAbove: 0.9},
+		FilterFunc(func(m models.Message) Decision {
+			if m.Topic == "Bad" {
+				return Decision{Action: Reject, Reason: "Bad topic"}
+			}
+			return Decision{Action: Allow}
+		}),
+	}
+
+	if d := pipeline.Check(models.Message{Topic: "Casino", Body: "Come"}); d.Action != Hold || d.Reason != "Matches (?i)casino" {
+		t.Errorf("Expected the first hold to decide, got %+v", d)
+	}
+
+	if d := pipeline.Check(models.Message{Topic: "Bad", Body: "Casino"}); d.Action != Reject || d.Reason != "Bad topic" {
+		t.Errorf("Expected a rejection to win, got %+v", d)
+	}
+
+	if d := (Pipeline(nil)).Check(models.Message{}); d.Action != Allow {
+		t.Errorf("Expected a nil pipeline to allow, got %+v", d)
+	}
+}
+
+func TestClassifierFilter(t *testing.T) {
+	cases := []struct {
+		classifier fixedClassifier
+		expected   Action
+	}{
+		{fixedClassifier{score: 0.1}, Allow},
+		{fixedClassifier{score: 0.5}, Hold},
+		{fixedClassifier{score: 0.95}, Reject},
+		{fixedClassifier{err: errors.New("timeout")}, Hold},
+	}
+
+	for _, c := range cases {
+		filter := ClassifierFilter{Classifier: c.classifier, HoldAbove: 0.5, RejectAbove: 0.9}
+
+		if d := filter.Check(models.Message{}); d.Action != c.expected {
+			t.Errorf("%+v: expected %v, got %+v", c.classifier, c.expected, d)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{"reject_words":["spam"],"max_links":1,"rules":[{"pattern":"^\\d+$","action":"hold","reason":"Only digits"}]}`), &config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if problems := config.Check("moderation"); len(problems) != 0 {
+		t.Errorf("Unexpected problems: %v", problems)
+	}
+
+	pipeline, err := config.Pipeline()
+	if err != nil || len(pipeline) != 3 {
+		t.Fatalf("Unexpected pipeline: %v, %v", pipeline, err)
+	}
+
+	if d := pipeline.Check(models.Message{Topic: "Hi", Body: "42"}); d.Action != Hold || d.Reason != "Only digits" || d.Code != "" {
+		t.Errorf("Expected the rule to hold, got %+v", d)
+	}
+
+	if err := json.Unmarshal([]byte(`{"action":"maybe"}`), &RuleConfig{}); err == nil {
+		t.Error("Expected an unknown action to be rejected")
+	}
+
+	config = Config{MaxLinks: -1, Rules: []RuleConfig{{Pattern: "(", Action: Reject}, {Pattern: "x"}}}
+	if problems := config.Check("moderation"); len(problems) != 3 {
+		t.Errorf("Expected 3 problems, got %v", problems)
+	}
+}
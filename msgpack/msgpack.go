@@ -0,0 +1,315 @@
+// Package msgpack encodes and decodes MessagePack (https://msgpack.org).
+//
+// Values are mapped the same way encoding/json maps them: Marshal goes
+// through json.Marshal and Unmarshal through json.Unmarshal, so struct tags,
+// omitempty and custom marshalers work exactly as for our JSON responses.
+// Only the types JSON can represent are supported, so binary and extension
+// types are rejected.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+func Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := encode(&b, generic); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func Unmarshal(data []byte, v interface{}) error {
+	d := decoder{data: data}
+
+	generic, err := d.decode(0)
+	if err != nil {
+		return err
+	}
+
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data after value")
+	}
+
+	asJSON, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(asJSON, v)
+}
+
+func writeUint(b *bytes.Buffer, prefix byte, size int, n uint64) {
+	b.WriteByte(prefix)
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	b.Write(buf[8-size:])
+}
+
+// Writes the header for strings, arrays and maps, using the smallest
+// representation for n. fixMax is the largest size the fix variant can hold
+func writeLength(b *bytes.Buffer, n int, fix byte, fixMax int, prefix8, prefix16, prefix32 byte) {
+	switch {
+	case n <= fixMax:
+		b.WriteByte(fix | byte(n))
+	case prefix8 != 0 && n <= math.MaxUint8:
+		writeUint(b, prefix8, 1, uint64(n))
+	case n <= math.MaxUint16:
+		writeUint(b, prefix16, 2, uint64(n))
+	default:
+		writeUint(b, prefix32, 4, uint64(n))
+	}
+}
+
+func encodeNumber(b *bytes.Buffer, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		switch {
+		case i >= 0 && i <= 127:
+			b.WriteByte(byte(i))
+		case i < 0 && i >= -32:
+			b.WriteByte(byte(int8(i)))
+		case i >= math.MinInt8 && i <= math.MaxInt8:
+			writeUint(b, 0xd0, 1, uint64(i))
+		case i >= math.MinInt16 && i <= math.MaxInt16:
+			writeUint(b, 0xd1, 2, uint64(i))
+		case i >= math.MinInt32 && i <= math.MaxInt32:
+			writeUint(b, 0xd2, 4, uint64(i))
+		default:
+			writeUint(b, 0xd3, 8, uint64(i))
+		}
+		return nil
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+
+	writeUint(b, 0xcb, 8, math.Float64bits(f))
+	return nil
+}
+
+func encode(b *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if value {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		return encodeNumber(b, value)
+	case string:
+		writeLength(b, len(value), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		b.WriteString(value)
+	case []interface{}:
+		writeLength(b, len(value), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, e := range value {
+			if err := encode(b, e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeLength(b, len(value), 0x80, 15, 0, 0xde, 0xdf)
+
+		// Sorted, so the output is stable
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			encode(b, k)
+			if err := encode(b, value[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+
+	return nil
+}
+
+// Values nested deeper than this are rejected, so a malicious request can't
+// exhaust the stack
+const maxDepth = 100
+
+var errShort = errors.New("msgpack: unexpected end of data")
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) read(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.data) {
+		return nil, errShort
+	}
+
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+
+	return b, nil
+}
+
+func (d *decoder) readUint(size int) (uint64, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 8)
+	copy(buf[8-size:], b)
+
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func (d *decoder) readInt(size int) (int64, error) {
+	n, err := d.readUint(size)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sign extend
+	shift := uint(64 - size*8)
+	return int64(n<<shift) >> shift, nil
+}
+
+func (d *decoder) readString(n int) (string, error) {
+	b, err := d.read(n)
+	return string(b), err
+}
+
+func (d *decoder) readArray(n int, depth int) ([]interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errShort
+	}
+
+	array := make([]interface{}, 0, n)
+
+	for i := 0; i < n; i++ {
+		e, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, e)
+	}
+
+	return array, nil
+}
+
+func (d *decoder) readMap(n int, depth int) (map[string]interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errShort
+	}
+
+	m := make(map[string]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		k, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map keys must be strings, got %T", k)
+		}
+
+		if m[key], err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: value nested too deeply")
+	}
+
+	b, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.readString(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.readArray(int(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return d.readMap(int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xca:
+		n, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := d.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return d.readUint(1 << (c - 0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return d.readInt(1 << (c - 0xd0))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.readArray(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.readMap(int(n), depth)
+	}
+
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", c)
+}
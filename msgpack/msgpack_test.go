@@ -0,0 +1,104 @@
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+type sample struct {
+	ID      string            `json:"id"`
+	Count   int               `json:"count"`
+	Ratio   float64           `json:"ratio"`
+	Ok      bool              `json:"ok"`
+	Tags    []string          `json:"tags"`
+	Extra   map[string]string `json:"extra,omitempty"`
+	Ignored string            `json:"-"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := sample{
+		ID:      string(bytes.Repeat([]byte("x"), 300)),
+		Count:   -70000,
+		Ratio:   0.25,
+		Ok:      true,
+		Tags:    []string{"a", "b"},
+		Ignored: "not encoded",
+	}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var out sample
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if out.ID != in.ID || out.Count != in.Count || out.Ratio != in.Ratio || !out.Ok || len(out.Tags) != 2 || out.Ignored != "" {
+		t.Errorf("Round trip mismatch: %+v", out)
+	}
+}
+
+// Checks the encoding against the examples of the specification, so we're
+// compatible with other implementations
+func TestEncoding(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{-33, []byte{0xd0, 0xdf}},
+		{300, []byte{0xd1, 0x01, 0x2c}},
+		{int64(math.MaxInt64), []byte{0xd3, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+	}
+
+	for _, c := range cases {
+		data, err := Marshal(c.value)
+
+		if err != nil || !bytes.Equal(data, c.expected) {
+			t.Errorf("Marshal(%v): expected % x, got % x (%v)", c.value, c.expected, data, err)
+		}
+	}
+}
+
+func TestDecodesOtherEncodings(t *testing.T) {
+	// uint16, str8, float32 and array16 - which we never produce ourselves
+	data := []byte{0xdc, 0x00, 0x03, 0xcd, 0x01, 0x00, 0xd9, 0x01, 'x', 0xca, 0x3f, 0xc0, 0x00, 0x00}
+
+	var out []interface{}
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if len(out) != 3 || out[0] != 256.0 || out[1] != "x" || out[2] != 1.5 {
+		t.Errorf("Unexpected result: %v", out)
+	}
+}
+
+func TestRejectsInvalidData(t *testing.T) {
+	cases := [][]byte{
+		{},
+		{0xa3, 'a'},                    // string shorter than its length
+		{0xdd, 0xff, 0xff, 0xff, 0xff}, // array longer than the data
+		{0x81, 0x01, 0x02},             // map with non-string key
+		{0xc4, 0x01, 0x00},             // binary isn't supported
+		{0x01, 0x02},                   // trailing data
+	}
+
+	for _, data := range cases {
+		var out interface{}
+
+		if err := Unmarshal(data, &out); err == nil {
+			t.Errorf("Expected % x to be rejected, got %v", data, out)
+		}
+	}
+}
@@ -0,0 +1,1590 @@
+// Package openapi contains the OpenAPI 3 document describing our API. It is
+// maintained by hand, app/openapi_test.go verifies that it covers every route
+// and matches what the handlers actually respond with.
+package openapi
+
+// The OpenAPI document served at /api/openapi.json
+const Document = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Hello Go",
+    "description": "A small service for posting and reading messages.",
+    "version": "1.0.0"
+  },
+  "servers": [
+    { "url": "http://localhost:8080" }
+  ],
+  "security": [
+    { "basicAuth": [] }
+  ],
+  "paths": {
+    "/api/messages": {
+      "get": {
+        "summary": "Get the messages in the channels of the current user, and the direct messages they sent or received. Pinned messages come first, the one pinned last first, regardless of sort. Unknown query parameters are rejected",
+        "operationId": "getMessages",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of messages to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of messages to skip", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "tag", "in": "query", "description": "Only return messages with this tag. May be given more than once", "schema": { "type": "array", "items": { "type": "string" } }, "explode": true },
+          { "name": "tag_match", "in": "query", "description": "Whether messages must have all of the tags, or any of them", "schema": { "type": "string", "enum": ["all", "any"], "default": "all" } },
+          { "name": "author", "in": "query", "description": "Only return messages by this author. May be given more than once, to return messages by any of them", "schema": { "type": "array", "items": { "type": "string" } }, "explode": true },
+          { "name": "created_after", "in": "query", "description": "Only return messages created after this time", "schema": { "type": "string", "format": "date-time" } },
+          { "name": "created_before", "in": "query", "description": "Only return messages created before this time", "schema": { "type": "string", "format": "date-time" } },
+          { "name": "updated_after", "in": "query", "description": "Only return messages last updated after this time", "schema": { "type": "string", "format": "date-time" } },
+          { "name": "updated_before", "in": "query", "description": "Only return messages last updated before this time", "schema": { "type": "string", "format": "date-time" } },
+          { "name": "parent", "in": "query", "description": "Only return replies to this message. Empty for messages starting a thread", "schema": { "type": "string" } },
+          { "name": "q", "in": "query", "description": "Only return messages with this text in their topic or body, ignoring case", "schema": { "type": "string" } },
+          { "name": "sort", "in": "query", "description": "Fields to order by, separated by commas. A leading - sorts descending. One of id, author, topic, created_at and updated_at. Messages are in the order they were added by default", "schema": { "type": "string" }, "example": "-created_at,author" }
+        ],
+        "responses": {
+          "200": {
+            "description": "The messages within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of messages", "schema": { "type": "integer" } },
+              "X-Unread-Count": { "description": "Number of those the current user hasn't read", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" }, "xml": { "name": "messages", "wrapped": true } }
+              },
+              "text/csv": {
+                "schema": { "type": "string", "description": "A header row with id, topic, body, author, parent_id, channel_id, recipients, tags, created_at, updated_at and deleted_at, followed by a row per message" }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "post": {
+        "summary": "Create a message authored by the current user, in channel_id or the default channel",
+        "operationId": "createMessage",
+        "requestBody": { "$ref": "#/components/requestBodies/MessageInput" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "202": { "$ref": "#/components/responses/Problem" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/read": {
+      "post": {
+        "summary": "Mark every message the current user can read as read",
+        "operationId": "markAllRead",
+        "responses": {
+          "200": { "description": "Every message is read" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/read": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "post": {
+        "summary": "Mark a message as read by the current user",
+        "operationId": "markMessageRead",
+        "responses": {
+          "200": { "description": "The message is read" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/thread/read": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "post": {
+        "summary": "Mark a message and all the replies below it as read by the current user",
+        "operationId": "markThreadRead",
+        "responses": {
+          "200": { "description": "The thread is read" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "get": {
+        "summary": "Get a single message, marking it as read by the current user",
+        "operationId": "getMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "put": {
+        "summary": "Update a message. Only allowed for its author",
+        "operationId": "updateMessage",
+        "requestBody": { "$ref": "#/components/requestBodies/MessageInput" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "202": { "$ref": "#/components/responses/Problem" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Move a message to the trash. Only allowed for its author",
+        "operationId": "deleteMessage",
+        "responses": {
+          "200": { "description": "The message is in the trash" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/restore": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "post": {
+        "summary": "Take a message out of the trash. Only allowed for its author",
+        "operationId": "restoreMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/reactions/{emoji}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" },
+        { "name": "emoji", "in": "path", "required": true, "schema": { "type": "string", "maxLength": 16 }, "description": "A single emoji, percent-encoded. It may be a sequence, ie with a skin tone" }
+      ],
+      "put": {
+        "summary": "React to a message with an emoji. Reacting twice with the same emoji does nothing. A message can have at most 20 different emoji, and a user at most 5 reactions to it",
+        "operationId": "addReaction",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Reactions" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Take back a reaction to a message. Removing a reaction that isn't there does nothing",
+        "operationId": "removeReaction",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Reactions" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/pin": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "put": {
+        "summary": "Pin a message to the top of the list of messages, above those pinned before. Only allowed for moderators",
+        "operationId": "pinMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Unpin a message. Only allowed for moderators",
+        "operationId": "unpinMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/lock": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "put": {
+        "summary": "Lock a message, so it and the replies below it can no longer be replied to or updated. Only allowed for moderators",
+        "operationId": "lockMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Unlock a message. Replies below it stay locked if a message further up the thread is locked. Only allowed for moderators",
+        "operationId": "unlockMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/attachments": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "post": {
+        "summary": "Attach a file to a message. Only allowed for its author, unless its thread is locked. A message can have at most 10 attachments of up to 10 MiB each",
+        "operationId": "addAttachment",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "multipart/form-data": {
+              "schema": {
+                "type": "object",
+                "required": ["file"],
+                "properties": {
+                  "file": { "type": "string", "format": "binary", "description": "The file, with its name in the filename of Content-Disposition" }
+                }
+              }
+            }
+          }
+        },
+        "responses": {
+          "200": {
+            "description": "The new attachment, in the format selected by Accept",
+            "content": {
+              "application/json": { "schema": { "$ref": "#/components/schemas/Attachment" } },
+              "application/xml": { "schema": { "$ref": "#/components/schemas/Attachment" } },
+              "application/msgpack": { "schema": { "$ref": "#/components/schemas/Attachment" } }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/messages/{id}/attachments/{attachment_id}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" },
+        { "name": "attachment_id", "in": "path", "required": true, "schema": { "type": "string" } }
+      ],
+      "get": {
+        "summary": "Download an attachment. Range requests are supported, and the ETag is the SHA-256 of the content",
+        "operationId": "getAttachment",
+        "responses": {
+          "200": { "$ref": "#/components/responses/AttachmentContent" },
+          "206": { "$ref": "#/components/responses/AttachmentContent" },
+          "304": { "description": "The content has the ETag given in If-None-Match" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "416": {
+            "description": "Range is outside the content",
+            "content": {
+              "text/plain": { "schema": { "type": "string" } }
+            }
+          },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Remove an attachment from a message. Only allowed for its author, unless its thread is locked",
+        "operationId": "deleteAttachment",
+        "responses": {
+          "200": { "description": "The attachment is removed" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/moderation/queue": {
+      "get": {
+        "summary": "Get the messages held for review, oldest first. Only allowed for moderators",
+        "operationId": "getHeldMessages",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of held messages to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of held messages to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The held messages within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of held messages", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/HeldMessage" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/HeldMessage" }, "xml": { "name": "held_messages", "wrapped": true } }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/HeldMessage" } }
+              }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/moderation/queue/{id}/approve": {
+      "parameters": [
+        { "$ref": "#/components/parameters/HeldMessageID" }
+      ],
+      "post": {
+        "summary": "Publish a held message as its author, or apply a held edit, without moderating it again. If that fails, it stays held. Only allowed for moderators",
+        "operationId": "approveHeldMessage",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/moderation/queue/{id}/reject": {
+      "parameters": [
+        { "$ref": "#/components/parameters/HeldMessageID" }
+      ],
+      "post": {
+        "summary": "Remove a held message without publishing it. Only allowed for moderators",
+        "operationId": "rejectHeldMessage",
+        "responses": {
+          "200": { "description": "The message is removed from the queue" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/drafts": {
+      "get": {
+        "summary": "Get the drafts of the current user, scheduled or not, in the order they were created",
+        "operationId": "getDrafts",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of drafts to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of drafts to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The drafts within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of drafts", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Draft" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Draft" }, "xml": { "name": "drafts", "wrapped": true } }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Draft" } }
+              }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "post": {
+        "summary": "Save a draft, which only the current user can see. With publish_at, it is published then",
+        "operationId": "createDraft",
+        "requestBody": { "$ref": "#/components/requestBodies/DraftInput" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Draft" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/drafts/{id}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/DraftID" }
+      ],
+      "get": {
+        "summary": "Get a single draft of the current user",
+        "operationId": "getDraft",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Draft" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "put": {
+        "summary": "Replace a draft of the current user until it is published. Leaving out publish_at unschedules it",
+        "operationId": "updateDraft",
+        "requestBody": { "$ref": "#/components/requestBodies/DraftInput" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Draft" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Delete a draft of the current user, cancelling it if it is scheduled",
+        "operationId": "deleteDraft",
+        "responses": {
+          "200": { "description": "The draft is deleted" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/drafts/{id}/publish": {
+      "parameters": [
+        { "$ref": "#/components/parameters/DraftID" }
+      ],
+      "post": {
+        "summary": "Publish a draft of the current user now, as when creating a message, and delete it. If it can't be published, it is kept",
+        "operationId": "publishDraft",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "202": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/trash": {
+      "get": {
+        "summary": "Get the messages the current user has deleted. They are purged after the retention period",
+        "operationId": "getTrash",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of messages to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of messages to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The messages within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of messages in the trash", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" }, "xml": { "name": "messages", "wrapped": true } }
+              },
+              "text/csv": {
+                "schema": { "type": "string", "description": "A header row with id, topic, body, author, parent_id, channel_id, recipients, tags, created_at, updated_at and deleted_at, followed by a row per message" }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/tags": {
+      "get": {
+        "summary": "Get the tags of the messages the current user can read, with usage counts, most used first",
+        "operationId": "getTags",
+        "responses": {
+          "200": {
+            "description": "The tags",
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/TagCount" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/TagCount" }, "xml": { "name": "tags", "wrapped": true } }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/TagCount" } }
+              }
+            }
+          },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/inbox": {
+      "get": {
+        "summary": "Get the direct messages received by the current user, with unread counts",
+        "operationId": "getInbox",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of messages to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of messages to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The inbox, with the messages within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of direct messages received", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": { "schema": { "$ref": "#/components/schemas/Inbox" } },
+              "application/xml": { "schema": { "$ref": "#/components/schemas/Inbox" } },
+              "application/msgpack": { "schema": { "$ref": "#/components/schemas/Inbox" } }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/notifications": {
+      "get": {
+        "summary": "Get the notifications of the current user about messages mentioning them, newest first",
+        "description": "Users are notified when they are mentioned as @username in the body of a message they can read, once per message. Notifications about messages that are deleted, or that the user can no longer read, are left out",
+        "operationId": "getNotifications",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of notifications to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of notifications to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The notifications within limit and offset, with the number of unread notifications",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of notifications", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": { "schema": { "$ref": "#/components/schemas/Notifications" } },
+              "application/xml": { "schema": { "$ref": "#/components/schemas/Notifications" } },
+              "application/msgpack": { "schema": { "$ref": "#/components/schemas/Notifications" } }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/notifications/read": {
+      "post": {
+        "summary": "Mark all the notifications of the current user as read",
+        "operationId": "markAllNotificationsRead",
+        "responses": {
+          "200": { "description": "Every notification is read" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/notifications/{id}/read": {
+      "parameters": [
+        { "$ref": "#/components/parameters/NotificationID" }
+      ],
+      "post": {
+        "summary": "Mark a notification of the current user as read",
+        "operationId": "markNotificationRead",
+        "responses": {
+          "200": { "description": "The notification is read" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/channels": {
+      "get": {
+        "summary": "Get the channels the current user is a member of",
+        "operationId": "getChannels",
+        "responses": {
+          "200": {
+            "description": "The channels",
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Channel" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Channel" }, "xml": { "name": "channels", "wrapped": true } }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Channel" } }
+              }
+            }
+          },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "post": {
+        "summary": "Create a channel owned by the current user",
+        "operationId": "createChannel",
+        "requestBody": {
+          "description": "The channel as JSON, XML or MessagePack, as told by Content-Type",
+          "required": true,
+          "content": {
+            "application/json": { "schema": { "$ref": "#/components/schemas/ChannelInput" } },
+            "application/xml": { "schema": { "$ref": "#/components/schemas/ChannelInput" } },
+            "application/msgpack": { "schema": { "$ref": "#/components/schemas/ChannelInput" } }
+          }
+        },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Channel" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/channels/{id}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/ChannelID" }
+      ],
+      "get": {
+        "summary": "Get a channel. Only allowed for its members",
+        "operationId": "getChannel",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Channel" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/channels/{id}/messages": {
+      "parameters": [
+        { "$ref": "#/components/parameters/ChannelID" }
+      ],
+      "get": {
+        "summary": "Get the messages in a channel. Only allowed for its members",
+        "operationId": "getChannelMessages",
+        "parameters": [
+          { "name": "limit", "in": "query", "description": "Maximum number of messages to return. 0 means no limit", "schema": { "type": "integer", "minimum": 0 } },
+          { "name": "offset", "in": "query", "description": "Number of messages to skip", "schema": { "type": "integer", "minimum": 0 } }
+        ],
+        "responses": {
+          "200": {
+            "description": "The messages within limit and offset",
+            "headers": {
+              "X-Total-Count": { "description": "Total number of messages in the channel", "schema": { "type": "integer" } },
+              "X-Unread-Count": { "description": "Number of those the current user hasn't read", "schema": { "type": "integer" } }
+            },
+            "content": {
+              "application/json": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              },
+              "application/xml": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" }, "xml": { "name": "messages", "wrapped": true } }
+              },
+              "text/csv": {
+                "schema": { "type": "string", "description": "A header row with id, topic, body, author, parent_id, channel_id, recipients, tags, created_at, updated_at and deleted_at, followed by a row per message" }
+              },
+              "application/msgpack": {
+                "schema": { "type": "array", "items": { "$ref": "#/components/schemas/Message" } }
+              }
+            }
+          },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "post": {
+        "summary": "Create a message in a channel. Only allowed for its members",
+        "operationId": "createChannelMessage",
+        "requestBody": { "$ref": "#/components/requestBodies/MessageInput" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/Message" },
+          "202": { "$ref": "#/components/responses/Problem" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "423": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/channels/{id}/members/{username}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/ChannelID" },
+        { "name": "username", "in": "path", "required": true, "schema": { "type": "string" } }
+      ],
+      "put": {
+        "summary": "Add a member to a channel. Only allowed for its owner",
+        "operationId": "addChannelMember",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Channel" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Remove a member from a channel. Allowed for the owner, and for members removing themselves. The owner can't be removed",
+        "operationId": "removeChannelMember",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Channel" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/muted": {
+      "get": {
+        "summary": "List the users the current user has muted, ordered by username",
+        "operationId": "getMuted",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/muted/{username}": {
+      "parameters": [
+        { "name": "username", "in": "path", "required": true, "schema": { "type": "string" } }
+      ],
+      "put": {
+        "summary": "Mute a user, leaving their messages out of every list, search and stream of the current user. Muting a user twice does nothing",
+        "operationId": "mute",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Unmute a user. Unmuting a user who isn't muted does nothing",
+        "operationId": "unmute",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/blocked": {
+      "get": {
+        "summary": "List the users the current user has blocked, ordered by username",
+        "operationId": "getBlocked",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/blocked/{username}": {
+      "parameters": [
+        { "name": "username", "in": "path", "required": true, "schema": { "type": "string" } }
+      ],
+      "put": {
+        "summary": "Block a user. Their messages are hidden like when muted, and they can't reply to messages by the current user, mention them or send them direct messages. Blocking a user twice does nothing",
+        "operationId": "block",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "422": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "delete": {
+        "summary": "Unblock a user. Unblocking a user who isn't blocked does nothing",
+        "operationId": "unblock",
+        "responses": {
+          "200": { "$ref": "#/components/responses/Usernames" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/admin/messages/import": {
+      "post": {
+        "summary": "Import messages. Only allowed for admins",
+        "operationId": "importMessages",
+        "parameters": [
+          { "$ref": "#/components/parameters/Format" },
+          { "$ref": "#/components/parameters/DryRun" },
+          { "$ref": "#/components/parameters/PreserveIDs" },
+          { "$ref": "#/components/parameters/OnConflict" }
+        ],
+        "requestBody": { "$ref": "#/components/requestBodies/Import" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/ImportReport" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/admin/messages/export": {
+      "get": {
+        "summary": "Export all messages. Only allowed for admins",
+        "operationId": "exportMessages",
+        "parameters": [
+          { "$ref": "#/components/parameters/Format" }
+        ],
+        "responses": {
+          "200": { "$ref": "#/components/responses/Export" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/admin/messages/{id}": {
+      "parameters": [
+        { "$ref": "#/components/parameters/MessageID" }
+      ],
+      "delete": {
+        "summary": "Delete a message for good, whether it is in the trash or not. Only allowed for admins",
+        "operationId": "purgeMessage",
+        "responses": {
+          "200": { "description": "The message was deleted" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "404": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/admin/users/import": {
+      "post": {
+        "summary": "Import users. Only allowed for admins",
+        "operationId": "importUsers",
+        "parameters": [
+          { "$ref": "#/components/parameters/Format" },
+          { "$ref": "#/components/parameters/DryRun" },
+          { "$ref": "#/components/parameters/OnConflict" }
+        ],
+        "requestBody": { "$ref": "#/components/requestBodies/Import" },
+        "responses": {
+          "200": { "$ref": "#/components/responses/ImportReport" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/admin/users/export": {
+      "get": {
+        "summary": "Export all users, including their tokens. Only allowed for admins",
+        "operationId": "exportUsers",
+        "parameters": [
+          { "$ref": "#/components/parameters/Format" }
+        ],
+        "responses": {
+          "200": { "$ref": "#/components/responses/Export" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "403": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/graphql": {
+      "get": {
+        "summary": "Run a GraphQL query. Mutations and subscriptions must use POST",
+        "operationId": "graphqlQuery",
+        "parameters": [
+          { "name": "query", "in": "query", "required": true, "schema": { "type": "string" } },
+          { "name": "operationName", "in": "query", "schema": { "type": "string" } },
+          { "name": "variables", "in": "query", "description": "JSON object with the variables", "schema": { "type": "string" } }
+        ],
+        "responses": {
+          "200": { "$ref": "#/components/responses/GraphQL" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "405": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      },
+      "post": {
+        "summary": "Run a GraphQL query, mutation or subscription. Subscriptions are streamed as server-sent events, and requires Accept: text/event-stream",
+        "operationId": "graphql",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "application/json": { "schema": { "$ref": "#/components/schemas/GraphQLRequest" } }
+          }
+        },
+        "responses": {
+          "200": { "$ref": "#/components/responses/GraphQL" },
+          "400": { "$ref": "#/components/responses/Problem" },
+          "401": { "$ref": "#/components/responses/Unauthenticated" },
+          "406": { "$ref": "#/components/responses/Problem" },
+          "415": { "$ref": "#/components/responses/Problem" },
+          "default": { "$ref": "#/components/responses/Problem" }
+        }
+      }
+    },
+    "/api/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "operationId": "getOpenAPI",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "The OpenAPI document",
+            "content": { "application/json": { "schema": { "type": "object" } } }
+          }
+        }
+      }
+    },
+    "/api/docs": {
+      "get": {
+        "summary": "Swagger UI for this document",
+        "operationId": "getDocs",
+        "security": [],
+        "responses": {
+          "200": {
+            "description": "HTML page",
+            "content": { "text/html": { "schema": { "type": "string" } } }
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "basicAuth": {
+        "type": "http",
+        "scheme": "basic",
+        "description": "Use the auth token as username and leave the password empty"
+      }
+    },
+    "parameters": {
+      "MessageID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": { "type": "string" }
+      },
+      "ChannelID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": { "type": "string" }
+      },
+      "DraftID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": { "type": "string" }
+      },
+      "HeldMessageID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": { "type": "string" }
+      },
+      "NotificationID": {
+        "name": "id",
+        "in": "path",
+        "required": true,
+        "schema": { "type": "string" }
+      },
+      "Format": {
+        "name": "format",
+        "in": "query",
+        "description": "Format of the body. Defaults to jsonl",
+        "schema": { "type": "string", "enum": ["json", "jsonl", "csv"] }
+      },
+      "DryRun": {
+        "name": "dry_run",
+        "in": "query",
+        "description": "Only report what would be imported",
+        "schema": { "type": "boolean" }
+      },
+      "PreserveIDs": {
+        "name": "preserve_ids",
+        "in": "query",
+        "description": "Keep the IDs of imported messages instead of assigning new ones",
+        "schema": { "type": "boolean" }
+      },
+      "OnConflict": {
+        "name": "on_conflict",
+        "in": "query",
+        "description": "What to do with records that already exists. Defaults to fail, which imports nothing",
+        "schema": { "type": "string", "enum": ["skip", "overwrite", "fail"] }
+      }
+    },
+    "requestBodies": {
+      "Import": {
+        "required": true,
+        "content": {
+          "application/x-ndjson": { "schema": { "type": "string" } },
+          "application/json": { "schema": { "type": "array", "items": { "type": "object" } } },
+          "text/csv": { "schema": { "type": "string" } }
+        }
+      },
+      "DraftInput": {
+        "description": "The draft as JSON, XML or MessagePack, as with MessageInput",
+        "required": true,
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/DraftInput" }
+          },
+          "application/xml": {
+            "schema": { "$ref": "#/components/schemas/DraftInput" }
+          },
+          "application/msgpack": {
+            "schema": { "$ref": "#/components/schemas/DraftInput" }
+          }
+        }
+      },
+      "MessageInput": {
+        "description": "The message as JSON, XML or MessagePack, as told by Content-Type. JSON is assumed without a Content-Type",
+        "required": true,
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/MessageInput" }
+          },
+          "application/xml": {
+            "schema": { "$ref": "#/components/schemas/MessageInput" }
+          },
+          "application/msgpack": {
+            "schema": { "$ref": "#/components/schemas/MessageInput" }
+          }
+        }
+      }
+    },
+    "responses": {
+      "ImportReport": {
+        "description": "The outcome of the import, line by line",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/ImportReport" }
+          }
+        }
+      },
+      "Export": {
+        "description": "All records in the requested format",
+        "content": {
+          "application/x-ndjson": { "schema": { "type": "string" } },
+          "application/json": { "schema": { "type": "array", "items": { "type": "object" } } },
+          "text/csv": { "schema": { "type": "string" } }
+        }
+      },
+      "Draft": {
+        "description": "The draft, in the format selected by Accept",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/Draft" }
+          },
+          "application/xml": {
+            "schema": { "$ref": "#/components/schemas/Draft" }
+          },
+          "application/msgpack": {
+            "schema": { "$ref": "#/components/schemas/Draft" }
+          }
+        }
+      },
+      "Message": {
+        "description": "The message, in the format selected by Accept",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/Message" }
+          },
+          "application/xml": {
+            "schema": { "$ref": "#/components/schemas/Message" }
+          },
+          "application/msgpack": {
+            "schema": { "$ref": "#/components/schemas/Message" }
+          }
+        }
+      },
+      "AttachmentContent": {
+        "description": "The content of the attachment, or the range asked for. Content-Type is sniffed from the content, and Content-Disposition makes it a download",
+        "content": {
+          "*/*": { "schema": { "type": "string", "format": "binary" } }
+        }
+      },
+      "Reactions": {
+        "description": "The reactions to the message afterwards, in the format selected by Accept",
+        "content": {
+          "application/json": {
+            "schema": { "type": "array", "items": { "$ref": "#/components/schemas/ReactionCount" } }
+          },
+          "application/xml": {
+            "schema": { "type": "array", "items": { "$ref": "#/components/schemas/ReactionCount" }, "xml": { "name": "reactions", "wrapped": true } }
+          },
+          "application/msgpack": {
+            "schema": { "type": "array", "items": { "$ref": "#/components/schemas/ReactionCount" } }
+          }
+        }
+      },
+      "Usernames": {
+        "description": "The usernames afterwards, in the format selected by Accept",
+        "content": {
+          "application/json": {
+            "schema": { "type": "array", "items": { "type": "string" } }
+          },
+          "application/xml": {
+            "schema": { "type": "array", "items": { "type": "string", "xml": { "name": "username" } }, "xml": { "name": "usernames", "wrapped": true } }
+          },
+          "application/msgpack": {
+            "schema": { "type": "array", "items": { "type": "string" } }
+          }
+        }
+      },
+      "Channel": {
+        "description": "The channel, in the format selected by Accept",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/Channel" }
+          },
+          "application/xml": {
+            "schema": { "$ref": "#/components/schemas/Channel" }
+          },
+          "application/msgpack": {
+            "schema": { "$ref": "#/components/schemas/Channel" }
+          }
+        }
+      },
+      "GraphQL": {
+        "description": "The result of the operation. Errors in the operation are reported in errors",
+        "content": {
+          "application/json": {
+            "schema": { "$ref": "#/components/schemas/GraphQLResponse" }
+          },
+          "text/event-stream": {
+            "schema": { "type": "string", "description": "For subscriptions: a next event per result, with the result as data, and a complete event when the subscription ends" }
+          }
+        }
+      },
+      "Problem": {
+        "description": "An error",
+        "content": {
+          "application/problem+json": {
+            "schema": { "$ref": "#/components/schemas/Problem" }
+          }
+        }
+      },
+      "Unauthenticated": {
+        "description": "Missing or invalid credentials, or not the owner of the message",
+        "content": {
+          "application/problem+json": {
+            "schema": { "$ref": "#/components/schemas/Problem" }
+          }
+        }
+      }
+    },
+    "schemas": {
+      "Message": {
+        "type": "object",
+        "description": "A message as seen by the current user",
+        "xml": { "name": "message" },
+        "additionalProperties": false,
+        "required": ["id", "topic", "body", "body_html", "author", "unread"],
+        "properties": {
+          "id": { "type": "string" },
+          "topic": { "type": "string" },
+          "body": { "type": "string", "description": "The body in Markdown" },
+          "body_html": { "type": "string", "description": "The body rendered as HTML, with links detected. It is sanitized, keeping only formatting, links and images" },
+          "author": { "type": "string" },
+          "parent_id": { "type": "string", "description": "The message this is a reply to. Left out for messages starting a thread" },
+          "channel_id": { "type": "string", "description": "The channel the message is posted in. Left out for direct messages" },
+          "recipients": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "recipients" }, "description": "The recipients of a direct message. Only they and the author can read it. Left out for other messages" },
+          "tags": { "type": "array", "items": { "type": "string", "xml": { "name": "tag" } }, "xml": { "wrapped": true, "name": "tags" }, "description": "Tags categorising the message, in lower case. Left out if there are none" },
+          "attachments": { "type": "array", "items": { "$ref": "#/components/schemas/Attachment" }, "xml": { "wrapped": true, "name": "attachments" }, "description": "Files attached to the message, see /api/messages/{id}/attachments. Left out if there are none" },
+          "created_at": { "type": "string", "format": "date-time", "description": "When the message was created. Left out for messages from before this was recorded" },
+          "updated_at": { "type": "string", "format": "date-time", "description": "When the message was last updated, or created if it hasn't been" },
+          "deleted_at": { "type": "string", "format": "date-time", "description": "When the message was moved to the trash. Left out for messages that aren't" },
+          "pinned_at": { "type": "string", "format": "date-time", "description": "When a moderator pinned the message to the top of the list. Left out for messages that aren't" },
+          "locked": { "type": "boolean", "description": "Whether a moderator locked the message. Locked messages, and the replies below them, can't be replied to or updated. Left out for messages that aren't" },
+          "unread": { "type": "boolean", "description": "Whether the current user hasn't read the message yet. Messages are read when fetched by ID or marked as read, and users have always read their own" },
+          "reactions": { "type": "array", "items": { "$ref": "#/components/schemas/ReactionCount" }, "xml": { "wrapped": true, "name": "reactions" }, "description": "The reactions to the message by emoji, ordered by the earliest reaction with each. Left out if there are none" }
+        }
+      },
+      "HeldMessage": {
+        "type": "object",
+        "description": "A new message, or an edit of one, held for review by a moderator",
+        "xml": { "name": "held_message" },
+        "additionalProperties": false,
+        "required": ["id", "message", "reason", "held_at"],
+        "properties": {
+          "id": { "type": "string", "description": "The ID in the queue" },
+          "message": { "$ref": "#/components/schemas/HeldMessageContent" },
+          "reason": { "type": "string", "description": "Why it was held" },
+          "held_at": { "type": "string", "format": "date-time" }
+        }
+      },
+      "HeldMessageContent": {
+        "type": "object",
+        "description": "The message as it will be published. id is only set for edits, to the message edited",
+        "additionalProperties": false,
+        "required": ["id", "topic", "body", "author"],
+        "properties": {
+          "id": { "type": "string" },
+          "topic": { "type": "string" },
+          "body": { "type": "string" },
+          "author": { "type": "string" },
+          "parent_id": { "type": "string" },
+          "channel_id": { "type": "string" },
+          "recipients": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "recipients" } },
+          "tags": { "type": "array", "items": { "type": "string", "xml": { "name": "tag" } }, "xml": { "wrapped": true, "name": "tags" } },
+          "attachments": { "type": "array", "items": { "$ref": "#/components/schemas/Attachment" }, "xml": { "wrapped": true, "name": "attachments" } },
+          "created_at": { "type": "string", "format": "date-time" },
+          "updated_at": { "type": "string", "format": "date-time" },
+          "pinned_at": { "type": "string", "format": "date-time" },
+          "locked": { "type": "boolean" }
+        }
+      },
+      "Notifications": {
+        "type": "object",
+        "xml": { "name": "notifications" },
+        "additionalProperties": false,
+        "required": ["total", "unread", "notifications"],
+        "properties": {
+          "total": { "type": "integer", "description": "Number of notifications" },
+          "unread": { "type": "integer", "description": "Number of those not marked as read" },
+          "notifications": {
+            "type": "array",
+            "xml": { "wrapped": true },
+            "items": {
+              "type": "object",
+              "xml": { "name": "notification" },
+              "additionalProperties": false,
+              "required": ["id", "message_id", "author", "created_at", "read"],
+              "properties": {
+                "id": { "type": "string" },
+                "message_id": { "type": "string", "description": "The message mentioning the user" },
+                "author": { "type": "string", "description": "The author of the message" },
+                "created_at": { "type": "string", "format": "date-time" },
+                "read": { "type": "boolean" }
+              }
+            }
+          }
+        }
+      },
+      "Inbox": {
+        "type": "object",
+        "xml": { "name": "inbox" },
+        "additionalProperties": false,
+        "required": ["total", "unread", "senders", "messages"],
+        "properties": {
+          "total": { "type": "integer", "description": "Number of direct messages received" },
+          "unread": { "type": "integer", "description": "Number of those not opened yet" },
+          "senders": {
+            "type": "array",
+            "description": "Senders of unread messages",
+            "xml": { "wrapped": true },
+            "items": {
+              "type": "object",
+              "xml": { "name": "sender" },
+              "additionalProperties": false,
+              "required": ["username", "unread"],
+              "properties": {
+                "username": { "type": "string" },
+                "unread": { "type": "integer" }
+              }
+            }
+          },
+          "messages": { "type": "array", "items": { "$ref": "#/components/schemas/Message" }, "xml": { "wrapped": true } }
+        }
+      },
+      "MessageInput": {
+        "type": "object",
+        "xml": { "name": "message" },
+        "required": ["topic", "body"],
+        "properties": {
+          "topic": { "type": "string", "maxLength": 200 },
+          "body": { "type": "string", "maxLength": 10000, "description": "The body in Markdown" },
+          "parent_id": { "type": "string", "description": "Makes the message a reply to this message, in its channel. Replies to direct messages are sent to the others taking part. Ignored when updating" },
+          "channel_id": { "type": "string", "description": "The channel to post in. Defaults to general. Ignored when updating, and when posting to /api/channels/{id}/messages" },
+          "recipients": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "recipients" }, "description": "Makes the message a direct message to these users, instead of posting it in a channel. Ignored when updating and replying" },
+          "tags": { "type": "array", "maxItems": 10, "items": { "type": "string", "maxLength": 32, "xml": { "name": "tag" } }, "xml": { "wrapped": true, "name": "tags" }, "description": "Tags categorising the message. They are trimmed, lower cased and stripped of a leading #, and may then contain letters, digits, - and _. When updating, the tags are kept if this is left out" }
+        }
+      },
+      "Draft": {
+        "type": "object",
+        "description": "A message the current user hasn't published yet",
+        "xml": { "name": "draft" },
+        "additionalProperties": false,
+        "required": ["id", "topic", "body", "author"],
+        "properties": {
+          "id": { "type": "string", "description": "The ID of the draft. The message gets another one when it is published" },
+          "topic": { "type": "string" },
+          "body": { "type": "string", "description": "The body in Markdown" },
+          "author": { "type": "string" },
+          "parent_id": { "type": "string" },
+          "channel_id": { "type": "string" },
+          "recipients": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "recipients" } },
+          "tags": { "type": "array", "items": { "type": "string", "xml": { "name": "tag" } }, "xml": { "wrapped": true, "name": "tags" } },
+          "created_at": { "type": "string", "format": "date-time" },
+          "updated_at": { "type": "string", "format": "date-time" },
+          "publish_at": { "type": "string", "format": "date-time", "description": "When the draft is published. Left out when it isn't scheduled" },
+          "publish_error": { "type": "string", "description": "Why publishing the draft at publish_at failed, ie because the parent was deleted. The draft is no longer scheduled then" }
+        }
+      },
+      "DraftInput": {
+        "type": "object",
+        "xml": { "name": "draft" },
+        "properties": {
+          "topic": { "type": "string", "maxLength": 200, "description": "Required when publish_at is given" },
+          "body": { "type": "string", "maxLength": 10000, "description": "Required when publish_at is given" },
+          "parent_id": { "type": "string" },
+          "channel_id": { "type": "string" },
+          "recipients": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "recipients" } },
+          "tags": { "type": "array", "maxItems": 10, "items": { "type": "string", "maxLength": 32, "xml": { "name": "tag" } }, "xml": { "wrapped": true, "name": "tags" } },
+          "publish_at": { "type": "string", "format": "date-time", "description": "When to publish the draft, which must not have passed. It is then published as when creating a message, and deleted. Leaving it out unschedules the draft" }
+        }
+      },
+      "Attachment": {
+        "type": "object",
+        "xml": { "name": "attachment" },
+        "additionalProperties": false,
+        "required": ["id", "name", "content_type", "size", "sha256", "created_at"],
+        "properties": {
+          "id": { "type": "string", "description": "Unique within the message" },
+          "name": { "type": "string", "maxLength": 255 },
+          "content_type": { "type": "string", "description": "Sniffed from the content" },
+          "size": { "type": "integer", "description": "In bytes" },
+          "sha256": { "type": "string", "description": "Hex encoded SHA-256 of the content" },
+          "created_at": { "type": "string", "format": "date-time" }
+        }
+      },
+      "ReactionCount": {
+        "type": "object",
+        "xml": { "name": "reaction" },
+        "additionalProperties": false,
+        "required": ["emoji", "count", "me"],
+        "properties": {
+          "emoji": { "type": "string" },
+          "count": { "type": "integer", "description": "Number of users reacting with the emoji" },
+          "me": { "type": "boolean", "description": "Whether the current user is one of them" }
+        }
+      },
+      "TagCount": {
+        "type": "object",
+        "xml": { "name": "tag" },
+        "additionalProperties": false,
+        "required": ["name", "count"],
+        "properties": {
+          "name": { "type": "string" },
+          "count": { "type": "integer", "description": "Number of messages the current user can read with the tag" }
+        }
+      },
+      "Channel": {
+        "type": "object",
+        "xml": { "name": "channel" },
+        "additionalProperties": false,
+        "required": ["id", "name", "description", "open", "members"],
+        "properties": {
+          "id": { "type": "string" },
+          "name": { "type": "string" },
+          "description": { "type": "string" },
+          "owner": { "type": "string", "description": "Manages the members. Left out for the default channel" },
+          "open": { "type": "boolean", "description": "Everyone is a member of open channels" },
+          "members": { "type": "array", "items": { "type": "string" }, "xml": { "wrapped": true, "name": "members" } }
+        }
+      },
+      "ChannelInput": {
+        "type": "object",
+        "xml": { "name": "channel" },
+        "required": ["name"],
+        "properties": {
+          "name": { "type": "string", "maxLength": 64 },
+          "description": { "type": "string", "maxLength": 1000 },
+          "open": { "type": "boolean" },
+          "members": { "type": "array", "items": { "type": "string" }, "description": "Usernames of members besides the current user, who always is" }
+        }
+      },
+      "GraphQLRequest": {
+        "type": "object",
+        "required": ["query"],
+        "properties": {
+          "query": { "type": "string" },
+          "operationName": { "type": "string" },
+          "variables": { "type": "object" }
+        }
+      },
+      "GraphQLResponse": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "data": { "type": "object", "nullable": true },
+          "errors": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["message"],
+              "properties": {
+                "message": { "type": "string" },
+                "extensions": {
+                  "type": "object",
+                  "description": "For errors from the services: code (NOT_FOUND, NOT_VALID, NOT_OWNER, FORBIDDEN, LOCKED, BLOCKED, REJECTED, HELD or INTERNAL), and errors for NOT_VALID"
+                }
+              }
+            }
+          }
+        }
+      },
+      "ImportReport": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["dry_run", "aborted", "imported", "overwritten", "skipped", "invalid", "lines"],
+        "properties": {
+          "dry_run": { "type": "boolean" },
+          "aborted": { "type": "boolean", "description": "A conflict was found with on_conflict=fail, so nothing was imported" },
+          "imported": { "type": "integer" },
+          "overwritten": { "type": "integer" },
+          "skipped": { "type": "integer" },
+          "invalid": { "type": "integer" },
+          "lines": { "type": "array", "items": { "$ref": "#/components/schemas/ImportLine" } }
+        }
+      },
+      "ImportLine": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["line", "status"],
+        "properties": {
+          "line": { "type": "integer" },
+          "id": { "type": "string" },
+          "original_id": { "type": "string" },
+          "status": { "type": "string", "enum": ["imported", "overwritten", "skipped", "invalid", "conflict"] },
+          "errors": { "type": "array", "items": { "$ref": "#/components/schemas/ValidationError" } },
+          "kept": {
+            "type": "array",
+            "description": "What an overwritten message keeps of the message it replaces, as the import doesn't have it. Attachments are kept unless the imported message has its own",
+            "items": { "type": "string", "enum": ["attachments", "reactions"] }
+          }
+        }
+      },
+      "Problem": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["type", "title", "status", "detail", "instance"],
+        "properties": {
+          "type": { "type": "string" },
+          "title": { "type": "string" },
+          "status": { "type": "integer" },
+          "detail": { "type": "string" },
+          "instance": { "type": "string" },
+          "errors": { "type": "array", "items": { "$ref": "#/components/schemas/ValidationError" } }
+        }
+      },
+      "ValidationError": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["field", "code", "message"],
+        "properties": {
+          "field": { "type": "string" },
+          "code": {
+            "type": "string",
+            "enum": ["required", "min_length", "max_length", "forbidden_characters", "invalid_utf8", "invalid_format", "max_items", "max_size", "too_early", "taken", "not_found", "not_allowed", "malformed"]
+          },
+          "message": { "type": "string" },
+          "params": { "type": "object" }
+        }
+      }
+    }
+  }
+}
+`
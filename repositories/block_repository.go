@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"sort"
+	"sync"
+)
+
+// Remembers whom each user has muted and blocked. The lists are only kept in
+// memory
+type BlockRepository struct {
+	muted   map[string]map[string]bool
+	blocked map[string]map[string]bool
+	sync.Mutex
+}
+
+func add(lists map[string]map[string]bool, username, other string) bool {
+	if lists[username] == nil {
+		lists[username] = map[string]bool{}
+	}
+
+	if lists[username][other] {
+		return false
+	}
+
+	lists[username][other] = true
+
+	return true
+}
+
+func remove(lists map[string]map[string]bool, username, other string) bool {
+	if !lists[username][other] {
+		return false
+	}
+
+	delete(lists[username], other)
+
+	return true
+}
+
+func sorted(list map[string]bool) []string {
+	usernames := []string{}
+
+	for username := range list {
+		usernames = append(usernames, username)
+	}
+
+	sort.Strings(usernames)
+
+	return usernames
+}
+
+// Adds other to the users username has muted. Returns whether they weren't
+// muted already
+func (r *BlockRepository) Mute(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.muted == nil {
+		r.muted = map[string]map[string]bool{}
+	}
+
+	return add(r.muted, username, other)
+}
+
+// Removes other from the users username has muted. Returns whether they were
+// muted
+func (r *BlockRepository) Unmute(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return remove(r.muted, username, other)
+}
+
+// Returns the users username has muted, ordered by username
+func (r *BlockRepository) FindMuted(username string) []string {
+	r.Lock()
+	defer r.Unlock()
+
+	return sorted(r.muted[username])
+}
+
+func (r *BlockRepository) IsMuted(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.muted[username][other]
+}
+
+// Adds other to the users username has blocked. Returns whether they weren't
+// blocked already
+func (r *BlockRepository) Block(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.blocked == nil {
+		r.blocked = map[string]map[string]bool{}
+	}
+
+	return add(r.blocked, username, other)
+}
+
+// Removes other from the users username has blocked. Returns whether they
+// were blocked
+func (r *BlockRepository) Unblock(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return remove(r.blocked, username, other)
+}
+
+// Returns the users username has blocked, ordered by username
+func (r *BlockRepository) FindBlocked(username string) []string {
+	r.Lock()
+	defer r.Unlock()
+
+	return sorted(r.blocked[username])
+}
+
+func (r *BlockRepository) IsBlocked(username, other string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.blocked[username][other]
+}
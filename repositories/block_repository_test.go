@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMutes(t *testing.T) {
+	repo := BlockRepository{}
+
+	if repo.IsMuted("marianne", "dennis") {
+		t.Error("Expected nobody to be muted yet")
+	}
+
+	if !repo.Mute("marianne", "dennis") || repo.Mute("marianne", "dennis") {
+		t.Error("Expected only the first mute to be added")
+	}
+	repo.Mute("marianne", "alice")
+
+	if !repo.IsMuted("marianne", "dennis") || repo.IsMuted("dennis", "marianne") {
+		t.Error("Expected mutes to be per user")
+	}
+
+	if muted := repo.FindMuted("marianne"); !reflect.DeepEqual(muted, []string{"alice", "dennis"}) {
+		t.Errorf("Expected the muted users in order, got %v", muted)
+	}
+
+	if !repo.Unmute("marianne", "dennis") || repo.Unmute("marianne", "dennis") {
+		t.Error("Expected only the first unmute to remove it")
+	}
+
+	if repo.IsBlocked("marianne", "alice") {
+		t.Error("Expected muting not to block")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	repo := BlockRepository{}
+
+	if repo.Unblock("marianne", "dennis") {
+		t.Error("Expected nothing to unblock")
+	}
+
+	if !repo.Block("marianne", "dennis") || repo.Block("marianne", "dennis") {
+		t.Error("Expected only the first block to be added")
+	}
+
+	if !repo.IsBlocked("marianne", "dennis") || repo.IsMuted("marianne", "dennis") {
+		t.Error("Expected dennis to be blocked, not muted")
+	}
+
+	if blocked := repo.FindBlocked("dennis"); len(blocked) != 0 {
+		t.Errorf("Expected dennis to block nobody, got %v", blocked)
+	}
+
+	repo.Unblock("marianne", "dennis")
+
+	if blocked := repo.FindBlocked("marianne"); len(blocked) != 0 {
+		t.Errorf("Expected the block to be removed, got %v", blocked)
+	}
+}
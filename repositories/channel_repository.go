@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"github.com/dennis/hello_go/models"
+	"strconv"
+	"sync"
+)
+
+type ChannelRepository struct {
+	channels []models.Channel
+	sequence uint64
+	sync.Mutex
+}
+
+// Channels are returned as copies, so the members can't be changed behind
+// the back of the repository
+func copyChannel(channel models.Channel) models.Channel {
+	channel.Members = append([]string{}, channel.Members...)
+	return channel
+}
+
+func (r *ChannelRepository) nextID() string {
+	r.sequence += 1
+	return strconv.FormatUint(r.sequence, 10)
+}
+
+func (r *ChannelRepository) Insert(channel models.Channel) string {
+	r.Lock()
+	defer r.Unlock()
+	channel.ID = r.nextID()
+	r.channels = append(r.channels, copyChannel(channel))
+
+	return channel.ID
+}
+
+// Inserts channel keeping its ID. IDs assigned by Insert afterwards won't
+// collide with it
+func (r *ChannelRepository) InsertWithID(channel models.Channel) {
+	r.Lock()
+	defer r.Unlock()
+
+	if n, err := strconv.ParseUint(channel.ID, 10, 64); err == nil && n > r.sequence {
+		r.sequence = n
+	}
+
+	r.channels = append(r.channels, copyChannel(channel))
+}
+
+func (r *ChannelRepository) GetAll() []models.Channel {
+	r.Lock()
+	defer r.Unlock()
+
+	channels := []models.Channel{}
+
+	for _, c := range r.channels {
+		channels = append(channels, copyChannel(c))
+	}
+
+	return channels
+}
+
+func (r *ChannelRepository) FindByID(id string) *models.Channel {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, channel := range r.channels {
+		if channel.ID == id {
+			c := copyChannel(channel)
+			return &c
+		}
+	}
+
+	return nil
+}
+
+// Replaces the channel with the same ID
+func (r *ChannelRepository) Update(channel models.Channel) {
+	r.Lock()
+	defer r.Unlock()
+
+	for index := range r.channels {
+		if r.channels[index].ID == channel.ID {
+			r.channels[index] = copyChannel(channel)
+			return
+		}
+	}
+
+	r.channels = append(r.channels, copyChannel(channel))
+}
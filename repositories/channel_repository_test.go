@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func TestChannelIDsDontCollideWithInsertedIDs(t *testing.T) {
+	repo := ChannelRepository{}
+
+	repo.InsertWithID(models.DefaultChannel)
+	repo.InsertWithID(models.Channel{ID: "5"})
+
+	if id := repo.Insert(models.Channel{}); id != "6" {
+		t.Errorf("Expected ID 6, got %v", id)
+	}
+
+	if c := repo.FindByID(models.DefaultChannelID); c == nil || !c.Open {
+		t.Errorf("Expected to find the default channel, got %v", c)
+	}
+}
+
+func TestChannelsAreCopied(t *testing.T) {
+	repo := ChannelRepository{}
+	members := []string{"dennis"}
+
+	id := repo.Insert(models.Channel{Members: members})
+	members[0] = "marianne"
+
+	c := repo.FindByID(id)
+	c.Members[0] = "marianne"
+
+	if c := repo.FindByID(id); c.Members[0] != "dennis" {
+		t.Errorf("Expected members to be unchanged, got %v", c.Members)
+	}
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Keeps the drafts of all users, in the order they were created
+type DraftRepository struct {
+	// Called with all the drafts after every change, ie to save them. It
+	// is called with the repository locked, so changes are seen in order.
+	// May be nil
+	OnChange func(drafts []models.Draft)
+
+	drafts   []models.Draft
+	sequence uint64
+	sync.Mutex
+}
+
+// Must be called with the repository locked
+func (r *DraftRepository) changed() {
+	if r.OnChange == nil {
+		return
+	}
+
+	drafts := []models.Draft{}
+
+	for _, d := range r.drafts {
+		drafts = append(drafts, copyDraft(d))
+	}
+
+	r.OnChange(drafts)
+}
+
+// Drafts are returned as copies, like messages
+func copyDraft(draft models.Draft) models.Draft {
+	draft.Message = copyMessage(draft.Message)
+	draft.PublishAt = copyTime(draft.PublishAt)
+	return draft
+}
+
+// Adds draft with a new ID, which is returned
+func (r *DraftRepository) Insert(draft models.Draft) string {
+	r.Lock()
+	defer r.Unlock()
+
+	r.sequence++
+	draft.ID = strconv.FormatUint(r.sequence, 10)
+	r.drafts = append(r.drafts, copyDraft(draft))
+	r.changed()
+
+	return draft.ID
+}
+
+// Inserts draft keeping its ID. IDs assigned by Insert afterwards won't
+// collide with it
+func (r *DraftRepository) InsertWithID(draft models.Draft) {
+	r.Lock()
+	defer r.Unlock()
+
+	if n, err := strconv.ParseUint(draft.ID, 10, 64); err == nil && n > r.sequence {
+		r.sequence = n
+	}
+
+	r.drafts = append(r.drafts, copyDraft(draft))
+	r.changed()
+}
+
+func (r *DraftRepository) GetAll() []models.Draft {
+	r.Lock()
+	defer r.Unlock()
+
+	drafts := []models.Draft{}
+
+	for _, d := range r.drafts {
+		drafts = append(drafts, copyDraft(d))
+	}
+
+	return drafts
+}
+
+func (r *DraftRepository) FindByID(id string) *models.Draft {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, d := range r.drafts {
+		if d.ID == id {
+			draft := copyDraft(d)
+			return &draft
+		}
+	}
+
+	return nil
+}
+
+func (r *DraftRepository) FindByAuthor(author string) []models.Draft {
+	r.Lock()
+	defer r.Unlock()
+
+	drafts := []models.Draft{}
+
+	for _, d := range r.drafts {
+		if d.Author == author {
+			drafts = append(drafts, copyDraft(d))
+		}
+	}
+
+	return drafts
+}
+
+// Returns the drafts scheduled at or before t
+func (r *DraftRepository) FindDue(t time.Time) []models.Draft {
+	r.Lock()
+	defer r.Unlock()
+
+	drafts := []models.Draft{}
+
+	for _, d := range r.drafts {
+		if d.PublishAt != nil && !d.PublishAt.After(t) {
+			drafts = append(drafts, copyDraft(d))
+		}
+	}
+
+	return drafts
+}
+
+// Replaces the draft with the same ID. Returns whether there was one
+func (r *DraftRepository) Update(draft models.Draft) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.drafts {
+		if r.drafts[i].ID == draft.ID {
+			r.drafts[i] = copyDraft(draft)
+			r.changed()
+			return true
+		}
+	}
+
+	return false
+}
+
+// Removes the draft with id. Returns whether there was one, so a draft
+// published by one caller isn't published again by another
+func (r *DraftRepository) DeleteByID(id string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.drafts {
+		if r.drafts[i].ID == id {
+			r.drafts = append(r.drafts[:i], r.drafts[i+1:]...)
+			r.changed()
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func TestDrafts(t *testing.T) {
+	repo := DraftRepository{}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	repo.InsertWithID(models.Draft{Message: models.Message{ID: "5", Author: "dennis"}, PublishAt: &later})
+	id := repo.Insert(models.Draft{Message: models.Message{Author: "dennis", Tags: []string{"go"}}})
+	repo.Insert(models.Draft{Message: models.Message{Author: "marianne"}, PublishAt: &now})
+
+	if id != "6" {
+		t.Errorf("Expected IDs to continue after those inserted, got %s", id)
+	}
+
+	if d := repo.FindByAuthor("dennis"); len(d) != 2 || d[0].ID != "5" || d[1].ID != "6" {
+		t.Errorf("Unexpected drafts %v", d)
+	}
+
+	if d := repo.FindDue(now); len(d) != 1 || d[0].Author != "marianne" {
+		t.Errorf("Expected only the draft of marianne to be due, got %v", d)
+	}
+
+	draft := repo.FindByID(id)
+	draft.Tags[0] = "changed"
+
+	if repo.FindByID(id).Tags[0] != "go" {
+		t.Error("Expected drafts to be copies")
+	}
+
+	if !repo.Update(*draft) || repo.FindByID(id).Tags[0] != "changed" {
+		t.Error("Expected the draft to be updated")
+	}
+
+	if !repo.DeleteByID(id) || repo.DeleteByID(id) || repo.FindByID(id) != nil {
+		t.Error("Expected the draft to be deleted once")
+	}
+
+	if len(repo.GetAll()) != 2 {
+		t.Errorf("Expected 2 drafts left, got %v", repo.GetAll())
+	}
+}
+
+func TestDrafts_OnChange(t *testing.T) {
+	var saved []models.Draft
+	repo := DraftRepository{OnChange: func(drafts []models.Draft) { saved = drafts }}
+
+	id := repo.Insert(models.Draft{Message: models.Message{Topic: "First"}})
+	repo.Insert(models.Draft{Message: models.Message{Topic: "Second"}})
+
+	if len(saved) != 2 {
+		t.Errorf("Expected both drafts to be passed on, got %v", saved)
+	}
+
+	repo.Update(models.Draft{Message: models.Message{ID: id, Topic: "Changed"}})
+
+	if saved[0].Topic != "Changed" {
+		t.Errorf("Expected the update to be passed on, got %v", saved)
+	}
+
+	repo.DeleteByID(id)
+
+	if len(saved) != 1 || saved[0].Topic != "Second" {
+		t.Errorf("Expected the deletion to be passed on, got %v", saved)
+	}
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// The queue of messages held for review, in the order they were held. Held
+// messages are only kept in memory, like the messages published by the
+// server
+type HeldMessageRepository struct {
+	held     []models.HeldMessage
+	sequence uint64
+	sync.Mutex
+}
+
+func copyHeldMessage(held models.HeldMessage) models.HeldMessage {
+	held.Message = copyMessage(held.Message)
+	return held
+}
+
+// Adds held with a new ID, which is returned
+func (r *HeldMessageRepository) Insert(held models.HeldMessage) string {
+	r.Lock()
+	defer r.Unlock()
+
+	r.sequence++
+	held.ID = strconv.FormatUint(r.sequence, 10)
+	r.held = append(r.held, copyHeldMessage(held))
+
+	return held.ID
+}
+
+// Puts held back in the queue with its ID, ie when approving it failed. It
+// keeps its place, by HeldAt
+func (r *HeldMessageRepository) InsertWithID(held models.HeldMessage) {
+	r.Lock()
+	defer r.Unlock()
+
+	if n, err := strconv.ParseUint(held.ID, 10, 64); err == nil && n > r.sequence {
+		r.sequence = n
+	}
+
+	i := 0
+	for i < len(r.held) && !r.held[i].HeldAt.After(held.HeldAt) {
+		i++
+	}
+
+	r.held = append(r.held[:i], append([]models.HeldMessage{copyHeldMessage(held)}, r.held[i:]...)...)
+}
+
+func (r *HeldMessageRepository) GetAll() []models.HeldMessage {
+	r.Lock()
+	defer r.Unlock()
+
+	held := []models.HeldMessage{}
+
+	for _, h := range r.held {
+		held = append(held, copyHeldMessage(h))
+	}
+
+	return held
+}
+
+func (r *HeldMessageRepository) FindByID(id string) *models.HeldMessage {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, h := range r.held {
+		if h.ID == id {
+			held := copyHeldMessage(h)
+			return &held
+		}
+	}
+
+	return nil
+}
+
+// Removes the held message with id. Returns whether there was one, so two
+// moderators can't both approve it
+func (r *HeldMessageRepository) DeleteByID(id string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.held {
+		if r.held[i].ID == id {
+			r.held = append(r.held[:i], r.held[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Removes the held edits of the message with id, and the held replies to it,
+// as they can't be published once it is gone
+func (r *HeldMessageRepository) DeleteByMessageID(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	kept := r.held[:0]
+
+	for _, h := range r.held {
+		if h.Message.ID != id && h.Message.ParentID != id {
+			kept = append(kept, h)
+		}
+	}
+
+	r.held = kept
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func TestHeldMessages(t *testing.T) {
+	repo := HeldMessageRepository{}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	first := repo.Insert(models.HeldMessage{Message: models.Message{Author: "dennis", Tags: []string{"go"}}, HeldAt: now})
+	repo.Insert(models.HeldMessage{Message: models.Message{ID: "7", Author: "marianne"}, Reason: "Spam", HeldAt: now.Add(time.Minute)})
+
+	held := repo.FindByID(first)
+	if held == nil || held.IsEdit() || held.Message.Author != "dennis" {
+		t.Fatalf("Unexpected held message %v", held)
+	}
+
+	// Changing the copy doesn't change the queue
+	held.Message.Tags[0] = "changed"
+
+	if h := repo.FindByID(first); h.Message.Tags[0] != "go" {
+		t.Errorf("Expected the held message to be copied, got %v", h)
+	}
+
+	if !repo.DeleteByID(first) || repo.DeleteByID(first) {
+		t.Error("Expected the held message to be deleted once")
+	}
+
+	repo.InsertWithID(*held)
+
+	if all := repo.GetAll(); len(all) != 2 || all[0].ID != first || !all[1].IsEdit() {
+		t.Errorf("Expected the held message back in its place, got %v", all)
+	}
+
+	if id := repo.Insert(models.HeldMessage{}); id != "3" {
+		t.Errorf("Expected a new ID, got %s", id)
+	}
+}
+
+func TestHeldMessages_DeleteByMessageID(t *testing.T) {
+	repo := HeldMessageRepository{}
+
+	repo.Insert(models.HeldMessage{Message: models.Message{ID: "7"}})
+	repo.Insert(models.HeldMessage{Message: models.Message{ParentID: "7"}})
+	kept := repo.Insert(models.HeldMessage{Message: models.Message{ParentID: "8"}})
+
+	repo.DeleteByMessageID("7")
+
+	if all := repo.GetAll(); len(all) != 1 || all[0].ID != kept {
+		t.Errorf("Expected only the edit of and the reply to 7 to be removed, got %v", all)
+	}
+}
@@ -2,16 +2,85 @@ package repositories
 
 import (
 	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/search"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type MessageRepository struct {
 	messages []models.Message
 	sequence uint64
+	// The IDs of the messages with each tag
+	tags map[string]map[string]bool
+	// The index of each message in messages, by ID
+	positions map[string]int
 	sync.Mutex
 }
 
+// Messages are stored as copies, so the recipients, tags and times can't be
+// changed behind the back of the repository
+func copyMessage(message models.Message) models.Message {
+	if message.Recipients != nil {
+		message.Recipients = append([]string{}, message.Recipients...)
+	}
+	if message.Tags != nil {
+		message.Tags = append([]string{}, message.Tags...)
+	}
+	if message.Attachments != nil {
+		message.Attachments = append([]models.Attachment{}, message.Attachments...)
+	}
+	message.CreatedAt = copyTime(message.CreatedAt)
+	message.UpdatedAt = copyTime(message.UpdatedAt)
+	message.DeletedAt = copyTime(message.DeletedAt)
+	message.PinnedAt = copyTime(message.PinnedAt)
+	return message
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	copied := *t
+	return &copied
+}
+
+func (r *MessageRepository) index(message models.Message) {
+	if r.tags == nil {
+		r.tags = map[string]map[string]bool{}
+	}
+
+	for _, tag := range message.Tags {
+		if r.tags[tag] == nil {
+			r.tags[tag] = map[string]bool{}
+		}
+		r.tags[tag][message.ID] = true
+	}
+}
+
+func (r *MessageRepository) unindex(message models.Message) {
+	for _, tag := range message.Tags {
+		delete(r.tags[tag], message.ID)
+
+		if len(r.tags[tag]) == 0 {
+			delete(r.tags, tag)
+		}
+	}
+}
+
+// Appends message, which must be a copy
+func (r *MessageRepository) add(message models.Message) {
+	if r.positions == nil {
+		r.positions = map[string]int{}
+	}
+
+	r.positions[message.ID] = len(r.messages)
+	r.messages = append(r.messages, message)
+	r.index(message)
+}
+
 func (r *MessageRepository) nextID() string {
 	r.sequence += 1
 	return strconv.FormatUint(r.sequence, 10)
@@ -21,11 +90,24 @@ func (r *MessageRepository) Insert(message models.Message) string {
 	r.Lock()
 	defer r.Unlock()
 	message.ID = r.nextID()
-	r.messages = append(r.messages, message)
+	r.add(copyMessage(message))
 
 	return message.ID
 }
 
+// Inserts message keeping its ID. IDs assigned by Insert afterwards won't
+// collide with it
+func (r *MessageRepository) InsertWithID(message models.Message) {
+	r.Lock()
+	defer r.Unlock()
+
+	if n, err := strconv.ParseUint(message.ID, 10, 64); err == nil && n > r.sequence {
+		r.sequence = n
+	}
+
+	r.add(copyMessage(message))
+}
+
 func (r *MessageRepository) GetAll() []models.Message {
 	r.Lock()
 	defer r.Unlock()
@@ -33,7 +115,55 @@ func (r *MessageRepository) GetAll() []models.Message {
 	messages := []models.Message{}
 
 	for _, m := range r.messages {
-		messages = append(messages, m)
+		messages = append(messages, copyMessage(m))
+	}
+
+	return messages
+}
+
+// Returns the replies to the message with id, in the order they were added
+func (r *MessageRepository) FindByParentID(id string) []models.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	replies := []models.Message{}
+
+	for _, m := range r.messages {
+		if m.ParentID == id {
+			replies = append(replies, copyMessage(m))
+		}
+	}
+
+	return replies
+}
+
+// Returns the messages in the channel with id, in the order they were added
+func (r *MessageRepository) FindByChannelID(id string) []models.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	messages := []models.Message{}
+
+	for _, m := range r.messages {
+		if m.ChannelID == id {
+			messages = append(messages, copyMessage(m))
+		}
+	}
+
+	return messages
+}
+
+// Returns the direct messages sent to username, in the order they were added
+func (r *MessageRepository) FindByRecipient(username string) []models.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	messages := []models.Message{}
+
+	for _, m := range r.messages {
+		if m.IsRecipient(username) {
+			messages = append(messages, copyMessage(m))
+		}
 	}
 
 	return messages
@@ -45,7 +175,7 @@ func (r *MessageRepository) FindByID(id string) *models.Message {
 	for _, message := range r.messages {
 		if message.ID == id {
 			// return a copy of message
-			d := message
+			d := copyMessage(message)
 			return &d
 		}
 	}
@@ -53,18 +183,122 @@ func (r *MessageRepository) FindByID(id string) *models.Message {
 	return nil
 }
 
+// Returns the IDs of the messages tagged with all of tags, or with any of
+// them if any is set
+func (r *MessageRepository) tagged(tags []string, any bool) map[string]bool {
+	ids := map[string]bool{}
+
+	for i, tag := range tags {
+		if any || i == 0 {
+			for id := range r.tags[tag] {
+				ids[id] = true
+			}
+			continue
+		}
+
+		for id := range ids {
+			if !r.tags[tag][id] {
+				delete(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// Returns the messages matching q, ordered by it. When q asks for tags, the
+// candidates are found in the tag index, so only the messages with the tags
+// are checked against the rest of q
+func (r *MessageRepository) FindByQuery(q search.Query) []models.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	candidates := r.messages
+
+	if len(q.Tags) > 0 {
+		// In the order they were added, as without tags
+		positions := []int{}
+		for id := range r.tagged(q.Tags, q.AnyTag) {
+			positions = append(positions, r.positions[id])
+		}
+		sort.Ints(positions)
+
+		candidates = make([]models.Message, 0, len(positions))
+		for _, i := range positions {
+			candidates = append(candidates, r.messages[i])
+		}
+	}
+
+	messages := []models.Message{}
+
+	for _, m := range candidates {
+		if q.Matches(m) {
+			messages = append(messages, copyMessage(m))
+		}
+	}
+
+	q.SortMessages(messages)
+
+	return messages
+}
+
+// Replaces the message with the same ID. The message keeps its position, so
+// the order of GetAll is stable across updates
 func (r *MessageRepository) Update(message models.Message) {
 	r.Lock()
 	defer r.Unlock()
-	r.deleteByIDWithoutLock(message.ID)
-	r.messages = append(r.messages, message)
+
+	for index := range r.messages {
+		if r.messages[index].ID == message.ID {
+			r.unindex(r.messages[index])
+			r.messages[index] = copyMessage(message)
+			r.index(message)
+			return
+		}
+	}
+
+	r.add(copyMessage(message))
 }
 
-func (r *MessageRepository) deleteByIDWithoutLock(id string) {
-	for index, message := range r.messages {
-		if message.ID == id {
-			r.messages = append(r.messages[:index], r.messages[index+1:]...)
+// Changes the message with id with change, if it is there and change returns
+// true, all while the repository is locked. Returns the changed message, or
+// nil if it wasn't changed
+func (r *MessageRepository) UpdateIf(id string, change func(m *models.Message) bool) *models.Message {
+	r.Lock()
+	defer r.Unlock()
+
+	for index := range r.messages {
+		if r.messages[index].ID != id {
+			continue
+		}
+
+		message := copyMessage(r.messages[index])
+		if !change(&message) {
+			return nil
 		}
+
+		r.unindex(r.messages[index])
+		r.messages[index] = copyMessage(message)
+		r.index(message)
+
+		return &message
+	}
+
+	return nil
+}
+
+func (r *MessageRepository) deleteByIDWithoutLock(id string) {
+	index, ok := r.positions[id]
+	if !ok {
+		return
+	}
+
+	r.unindex(r.messages[index])
+	r.messages = append(r.messages[:index], r.messages[index+1:]...)
+	delete(r.positions, id)
+
+	for i := index; i < len(r.messages); i++ {
+		r.positions[r.messages[i].ID] = i
 	}
 }
 
@@ -73,3 +307,19 @@ func (r *MessageRepository) DeleteByID(id string) {
 	defer r.Unlock()
 	r.deleteByIDWithoutLock(id)
 }
+
+// Deletes the message with id if condition holds for it, checking it while
+// the repository is locked. Returns whether it was deleted
+func (r *MessageRepository) DeleteByIDIf(id string, condition func(m models.Message) bool) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, message := range r.messages {
+		if message.ID == id && condition(copyMessage(message)) {
+			r.deleteByIDWithoutLock(id)
+			return true
+		}
+	}
+
+	return false
+}
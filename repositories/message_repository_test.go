@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/search"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestInsertingMessagesAssignsIDToThem(t *testing.T) {
@@ -51,6 +54,19 @@ func TestModifyingAMessage(t *testing.T) {
 	}
 }
 
+func TestModifyingAMessageKeepsOrder(t *testing.T) {
+	repo := MessageRepository{}
+
+	id := repo.Insert(models.Message{Body: "first"})
+	repo.Insert(models.Message{Body: "second"})
+
+	repo.Update(models.Message{ID: id, Body: "updated"})
+
+	if r := repo.GetAll(); r[0].ID != id {
+		t.Errorf("Expected updated message to stay first, got: %v", r)
+	}
+}
+
 func TestRemovingAMessage(t *testing.T) {
 	repo := MessageRepository{}
 
@@ -64,3 +80,163 @@ func TestRemovingAMessage(t *testing.T) {
 	}
 
 }
+
+func TestConditionalChanges(t *testing.T) {
+	repo := MessageRepository{}
+	id := repo.Insert(models.Message{Body: "first"})
+
+	isSecond := func(m models.Message) bool { return m.Body == "second" }
+
+	if repo.DeleteByIDIf(id, isSecond) || repo.FindByID(id) == nil {
+		t.Error("Expected the message not to be deleted")
+	}
+
+	changed := repo.UpdateIf(id, func(m *models.Message) bool {
+		m.Body = "second"
+		return true
+	})
+	if changed == nil || changed.Body != "second" || repo.FindByID(id).Body != "second" {
+		t.Errorf("Expected the message to be changed, got %v", changed)
+	}
+
+	if repo.UpdateIf(id, func(m *models.Message) bool { m.Body = "third"; return false }) != nil || repo.FindByID(id).Body != "second" {
+		t.Error("Expected the message not to be changed")
+	}
+
+	if !repo.DeleteByIDIf(id, isSecond) || repo.FindByID(id) != nil {
+		t.Error("Expected the message to be deleted")
+	}
+
+	if repo.UpdateIf(id, func(m *models.Message) bool { return true }) != nil {
+		t.Error("Expected deleted messages not to be updated")
+	}
+}
+
+func TestFoundMessagesAreCopies(t *testing.T) {
+	repo := MessageRepository{}
+	now := time.Now()
+	pinnedAt := now
+	id := repo.Insert(models.Message{CreatedAt: &now, PinnedAt: &pinnedAt})
+
+	found := repo.FindByID(id)
+	*found.PinnedAt = found.PinnedAt.Add(time.Hour)
+
+	if !repo.FindByID(id).PinnedAt.Equal(now) {
+		t.Error("Expected changing a found message not to change the repository")
+	}
+
+	reply := models.Message{ParentID: id, ChannelID: "general", Recipients: []string{"marianne"}, Tags: []string{"go"}}
+	repo.Insert(reply)
+
+	for _, found := range [][]models.Message{repo.GetAll(), repo.FindByParentID(id), repo.FindByChannelID("general"), repo.FindByRecipient("marianne")} {
+		m := found[len(found)-1]
+		m.Recipients[0] = "changed"
+		m.Tags[0] = "changed"
+	}
+
+	if m := repo.FindByParentID(id)[0]; m.Recipients[0] != "marianne" || m.Tags[0] != "go" {
+		t.Errorf("Expected changing found messages not to change the repository, got %+v", m)
+	}
+}
+
+func TestInsertingWithIDKeepsIt(t *testing.T) {
+	repo := MessageRepository{}
+
+	repo.InsertWithID(models.Message{ID: "7"})
+	repo.InsertWithID(models.Message{ID: "legacy-id"})
+
+	if m := repo.FindByID("legacy-id"); m == nil {
+		t.Error("Expected to find message by its original ID")
+	}
+
+	if n := repo.Insert(models.Message{}); n != "8" {
+		t.Errorf("Expected next ID to follow the inserted one, got %v", n)
+	}
+}
+
+func TestFindByRecipient(t *testing.T) {
+	repo := MessageRepository{}
+
+	repo.Insert(models.Message{Author: "dennis", ChannelID: models.DefaultChannelID})
+	id := repo.Insert(models.Message{Author: "dennis", Recipients: []string{"marianne"}})
+
+	if r := repo.FindByRecipient("marianne"); len(r) != 1 || r[0].ID != id {
+		t.Errorf("Expected only the direct message, got %v", r)
+	}
+
+	if r := repo.FindByRecipient("dennis"); len(r) != 0 {
+		t.Errorf("Expected no messages sent to the author, got %v", r)
+	}
+}
+
+func TestFindByQuery_Tags(t *testing.T) {
+	repo := MessageRepository{}
+
+	repo.Insert(models.Message{Tags: []string{"go"}})
+	repo.Insert(models.Message{Tags: []string{"go", "release"}})
+	repo.Insert(models.Message{Tags: []string{"release"}})
+
+	ids := func(messages []models.Message) string {
+		s := []string{}
+		for _, m := range messages {
+			s = append(s, m.ID)
+		}
+		return strings.Join(s, ",")
+	}
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"go", "release"}})); r != "2" {
+		t.Errorf("Expected the message with both tags, got %s", r)
+	}
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"go", "release"}, AnyTag: true})); r != "1,2,3" {
+		t.Errorf("Expected the messages with either tag, got %s", r)
+	}
+
+	// The index follows updates and deletes
+	repo.Update(models.Message{ID: "1", Tags: []string{"release"}})
+	repo.DeleteByID("3")
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"release"}})); r != "1,2" {
+		t.Errorf("Expected the index to be updated, got %s", r)
+	}
+
+	// Deleting a message moves those after it
+	repo.DeleteByID("1")
+	repo.Insert(models.Message{Tags: []string{"release"}})
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"release"}})); r != "2,4" {
+		t.Errorf("Expected the messages in the order they were added, got %s", r)
+	}
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"unknown"}, AnyTag: true})); r != "" {
+		t.Errorf("Expected no messages, got %s", r)
+	}
+
+	repo.FindByQuery(search.Query{Tags: []string{"go"}})[0].Tags[0] = "changed"
+
+	if r := ids(repo.FindByQuery(search.Query{Tags: []string{"go"}})); r != "2" {
+		t.Errorf("Expected changing a found message not to change the repository, got %s", r)
+	}
+}
+
+func TestFindByQuery_Sort(t *testing.T) {
+	repo := MessageRepository{}
+
+	repo.Insert(models.Message{Author: "marianne", Topic: "b"})
+	repo.Insert(models.Message{Author: "dennis", Topic: "b"})
+	repo.Insert(models.Message{Author: "dennis", Topic: "a"})
+
+	sorted := repo.FindByQuery(search.Query{
+		Authors: []string{"dennis", "marianne"},
+		Sort:    []search.SortField{{Name: "topic", Descending: true}, {Name: "author"}},
+	})
+
+	ids := []string{}
+	for _, m := range sorted {
+		ids = append(ids, m.ID)
+	}
+
+	if r := strings.Join(ids, ","); r != "2,1,3" {
+		t.Errorf("Expected the messages sorted by topic descending, then author, got %s", r)
+	}
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Remembers the notifications of each user, in the order they were
+// created. Notifications are only kept in memory
+type NotificationRepository struct {
+	notifications []models.Notification
+	sequence      uint64
+	sync.Mutex
+}
+
+// Adds notification with a new ID, which is returned
+func (r *NotificationRepository) Insert(notification models.Notification) string {
+	r.Lock()
+	defer r.Unlock()
+
+	r.sequence++
+	notification.ID = strconv.FormatUint(r.sequence, 10)
+	r.notifications = append(r.notifications, notification)
+
+	return notification.ID
+}
+
+func (r *NotificationRepository) FindByUsername(username string) []models.Notification {
+	r.Lock()
+	defer r.Unlock()
+
+	notifications := []models.Notification{}
+
+	for _, n := range r.notifications {
+		if n.Username == username {
+			notifications = append(notifications, n)
+		}
+	}
+
+	return notifications
+}
+
+// Tells if username has been notified about the message with id
+func (r *NotificationRepository) Exists(username, id string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, n := range r.notifications {
+		if n.Username == username && n.MessageID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Marks the notification of username with id as read. Returns whether
+// username has a notification with id
+func (r *NotificationRepository) MarkRead(username, id string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.notifications {
+		if n := &r.notifications[i]; n.Username == username && n.ID == id {
+			n.Read = true
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *NotificationRepository) MarkAllRead(username string) {
+	r.Lock()
+	defer r.Unlock()
+
+	for i := range r.notifications {
+		if r.notifications[i].Username == username {
+			r.notifications[i].Read = true
+		}
+	}
+}
+
+// Removes the notifications about the message with id
+func (r *NotificationRepository) DeleteByMessageID(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	kept := r.notifications[:0]
+
+	for _, n := range r.notifications {
+		if n.MessageID != id {
+			kept = append(kept, n)
+		}
+	}
+
+	r.notifications = kept
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func TestNotifications(t *testing.T) {
+	repo := NotificationRepository{}
+
+	first := repo.Insert(models.Notification{Username: "dennis", MessageID: "1", Author: "marianne"})
+	repo.Insert(models.Notification{Username: "dennis", MessageID: "2", Author: "marianne"})
+	repo.Insert(models.Notification{Username: "marianne", MessageID: "2", Author: "dennis"})
+
+	if n := repo.FindByUsername("dennis"); len(n) != 2 || n[0].ID != first || n[1].MessageID != "2" {
+		t.Errorf("Unexpected notifications %v", n)
+	}
+
+	if !repo.Exists("dennis", "1") || repo.Exists("marianne", "1") {
+		t.Error("Expected only dennis to be notified about 1")
+	}
+
+	if !repo.MarkRead("dennis", first) || repo.MarkRead("marianne", first) {
+		t.Error("Expected only dennis to mark their notification as read")
+	}
+
+	if n := repo.FindByUsername("dennis"); !n[0].Read || n[1].Read {
+		t.Errorf("Expected only the first notification to be read, got %v", n)
+	}
+
+	repo.MarkAllRead("marianne")
+	repo.DeleteByMessageID("1")
+
+	if n := repo.FindByUsername("dennis"); len(n) != 1 || n[0].MessageID != "2" || n[0].Read {
+		t.Errorf("Unexpected notifications after deleting %v", n)
+	}
+
+	if n := repo.FindByUsername("marianne"); len(n) != 1 || !n[0].Read {
+		t.Errorf("Expected marianne's notification to be read, got %v", n)
+	}
+}
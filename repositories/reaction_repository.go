@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"sync"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Remembers the reactions to each message, in the order they were added.
+// Reactions are only kept in memory
+type ReactionRepository struct {
+	reactions map[string][]models.Reaction
+	sync.Mutex
+}
+
+func (r *ReactionRepository) indexOf(reaction models.Reaction) int {
+	for i, existing := range r.reactions[reaction.MessageID] {
+		if existing == reaction {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Adds reaction, unless it is already there. Returns whether it was added
+func (r *ReactionRepository) Add(reaction models.Reaction) bool {
+	added, _ := r.AddIf(reaction, nil)
+	return added
+}
+
+// Adds reaction like Add, unless check returns an error for the reactions
+// already there, which is returned then. The check and the add happen while
+// the repository is locked, so concurrent reactions can't get past limits.
+// check may be nil
+func (r *ReactionRepository) AddIf(reaction models.Reaction, check func(existing []models.Reaction) error) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.reactions == nil {
+		r.reactions = map[string][]models.Reaction{}
+	}
+
+	if r.indexOf(reaction) >= 0 {
+		return false, nil
+	}
+
+	if check != nil {
+		if err := check(append([]models.Reaction{}, r.reactions[reaction.MessageID]...)); err != nil {
+			return false, err
+		}
+	}
+
+	r.reactions[reaction.MessageID] = append(r.reactions[reaction.MessageID], reaction)
+
+	return true, nil
+}
+
+// Removes reaction, if it is there. Returns whether it was removed
+func (r *ReactionRepository) Remove(reaction models.Reaction) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	i := r.indexOf(reaction)
+	if i < 0 {
+		return false
+	}
+
+	reactions := r.reactions[reaction.MessageID]
+	r.reactions[reaction.MessageID] = append(reactions[:i:i], reactions[i+1:]...)
+
+	return true
+}
+
+func (r *ReactionRepository) FindByMessageID(id string) []models.Reaction {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]models.Reaction{}, r.reactions[id]...)
+}
+
+func (r *ReactionRepository) DeleteByMessageID(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	delete(r.reactions, id)
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func TestAddingAndRemovingReactions(t *testing.T) {
+	repo := ReactionRepository{}
+	thumbs := models.Reaction{MessageID: "1", Username: "dennis", Emoji: "👍"}
+
+	if !repo.Add(thumbs) || repo.Add(thumbs) {
+		t.Error("Expected the reaction to be added once")
+	}
+
+	repo.Add(models.Reaction{MessageID: "1", Username: "marianne", Emoji: "🎉"})
+	repo.Add(models.Reaction{MessageID: "2", Username: "dennis", Emoji: "👍"})
+
+	if r := repo.FindByMessageID("1"); len(r) != 2 || r[0] != thumbs {
+		t.Errorf("Unexpected reactions %v", r)
+	}
+
+	if !repo.Remove(thumbs) || repo.Remove(thumbs) {
+		t.Error("Expected the reaction to be removed once")
+	}
+
+	if r := repo.FindByMessageID("1"); len(r) != 1 || r[0].Emoji != "🎉" {
+		t.Errorf("Unexpected reactions after removing %v", r)
+	}
+
+	repo.DeleteByMessageID("2")
+
+	if r := repo.FindByMessageID("2"); len(r) != 0 {
+		t.Errorf("Expected the reactions to be deleted, got %v", r)
+	}
+}
+
+func TestAddingReactionsIf(t *testing.T) {
+	repo := ReactionRepository{}
+	thumbs := models.Reaction{MessageID: "1", Username: "dennis", Emoji: "👍"}
+	tooMany := errors.New("too many")
+
+	onlyOne := func(existing []models.Reaction) error {
+		if len(existing) > 0 {
+			return tooMany
+		}
+		return nil
+	}
+
+	if added, err := repo.AddIf(thumbs, onlyOne); !added || err != nil {
+		t.Errorf("Expected the reaction to be added, got %v", err)
+	}
+
+	if added, err := repo.AddIf(thumbs, onlyOne); added || err != nil {
+		t.Errorf("Expected adding the reaction again to do nothing, got %v", err)
+	}
+
+	if added, err := repo.AddIf(models.Reaction{MessageID: "1", Username: "marianne", Emoji: "🎉"}, onlyOne); added || err != tooMany {
+		t.Errorf("Expected the error from the check, got %v", err)
+	}
+
+	if r := repo.FindByMessageID("1"); len(r) != 1 {
+		t.Errorf("Expected only the first reaction, got %v", r)
+	}
+}
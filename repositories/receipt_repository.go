@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"strconv"
+	"sync"
+)
+
+// The messages a single user has read
+type receipts struct {
+	// Every message with a numeric ID up to this is read. Moved by
+	// MarkReadUpTo, so marking everything as read doesn't need an entry per
+	// message
+	upTo uint64
+	// Other messages that are read
+	ids map[string]bool
+}
+
+func (r *receipts) isRead(id string) bool {
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil && n <= r.upTo {
+		return true
+	}
+
+	return r.ids[id]
+}
+
+// Remembers which messages each user has read. Receipts are only kept in
+// memory
+type ReceiptRepository struct {
+	receipts map[string]*receipts
+	sync.Mutex
+}
+
+func (r *ReceiptRepository) receiptsWithoutLock(username string) *receipts {
+	if r.receipts == nil {
+		r.receipts = map[string]*receipts{}
+	}
+
+	if r.receipts[username] == nil {
+		r.receipts[username] = &receipts{ids: map[string]bool{}}
+	}
+
+	return r.receipts[username]
+}
+
+func (r *ReceiptRepository) MarkRead(username string, ids ...string) {
+	r.Lock()
+	defer r.Unlock()
+
+	receipts := r.receiptsWithoutLock(username)
+
+	for _, id := range ids {
+		if !receipts.isRead(id) {
+			receipts.ids[id] = true
+		}
+	}
+}
+
+// Marks every message with a numeric ID up to upTo as read by username, and
+// the messages with ids. Messages with numeric IDs above upTo are left as
+// they are
+func (r *ReceiptRepository) MarkReadUpTo(username string, upTo uint64, ids ...string) {
+	r.Lock()
+	defer r.Unlock()
+
+	receipts := r.receiptsWithoutLock(username)
+
+	if upTo > receipts.upTo {
+		receipts.upTo = upTo
+
+		// Forget the messages covered by upTo now
+		for id := range receipts.ids {
+			if n, err := strconv.ParseUint(id, 10, 64); err == nil && n <= upTo {
+				delete(receipts.ids, id)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if !receipts.isRead(id) {
+			receipts.ids[id] = true
+		}
+	}
+}
+
+func (r *ReceiptRepository) IsRead(username, id string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	receipts, ok := r.receipts[username]
+
+	return ok && receipts.isRead(id)
+}
+
+// Forgets that anyone read the message with id, ie when it is purged
+func (r *ReceiptRepository) DeleteByMessageID(id string) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, receipts := range r.receipts {
+		delete(receipts.ids, id)
+	}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"testing"
+)
+
+func TestReceipts(t *testing.T) {
+	repo := ReceiptRepository{}
+
+	if repo.IsRead("marianne", "1") {
+		t.Error("Expected nothing to be read yet")
+	}
+
+	repo.MarkRead("marianne", "1")
+
+	if !repo.IsRead("marianne", "1") {
+		t.Error("Expected message 1 to be read by marianne")
+	}
+
+	if repo.IsRead("dennis", "1") || repo.IsRead("marianne", "2") {
+		t.Error("Expected receipts to be per user and message")
+	}
+}
+
+func TestReceipts_UpTo(t *testing.T) {
+	repo := ReceiptRepository{}
+
+	repo.MarkRead("marianne", "12", "legacy")
+	repo.MarkReadUpTo("marianne", 10, "imported")
+
+	for _, id := range []string{"1", "10", "12", "legacy", "imported"} {
+		if !repo.IsRead("marianne", id) {
+			t.Errorf("Expected message %s to be read", id)
+		}
+	}
+
+	if repo.IsRead("marianne", "11") || repo.IsRead("marianne", "other") {
+		t.Error("Expected messages after upTo to be unread")
+	}
+
+	// Moving backwards keeps what was read
+	repo.MarkReadUpTo("marianne", 5)
+
+	if !repo.IsRead("marianne", "7") {
+		t.Error("Expected message 7 to still be read")
+	}
+}
+
+func TestReceipts_DeleteByMessageID(t *testing.T) {
+	repo := ReceiptRepository{}
+
+	repo.MarkRead("marianne", "1", "2")
+	repo.MarkRead("dennis", "1")
+	repo.DeleteByMessageID("1")
+
+	if repo.IsRead("marianne", "1") || repo.IsRead("dennis", "1") || !repo.IsRead("marianne", "2") {
+		t.Error("Expected only the receipts for message 1 to be deleted")
+	}
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"github.com/dennis/hello_go/models"
+	"strings"
 	"sync"
 )
 
@@ -16,6 +17,19 @@ func (r *UserRepository) Insert(user models.User) {
 	r.users = append(r.users, user)
 }
 
+func (r *UserRepository) GetAll() []models.User {
+	r.Lock()
+	defer r.Unlock()
+
+	users := []models.User{}
+
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+
+	return users
+}
+
 func (r *UserRepository) FindByToken(token string) *models.User {
 	r.Lock()
 	defer r.Unlock()
@@ -27,3 +41,48 @@ func (r *UserRepository) FindByToken(token string) *models.User {
 
 	return nil
 }
+
+func (r *UserRepository) FindByUsername(username string) *models.User {
+	r.Lock()
+	defer r.Unlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			return &user
+		}
+	}
+
+	return nil
+}
+
+// Returns the user with username, ignoring case. A user with exactly
+// username is preferred over others differing in case
+func (r *UserRepository) FindByUsernameIgnoringCase(username string) *models.User {
+	if user := r.FindByUsername(username); user != nil {
+		return user
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return &user
+		}
+	}
+
+	return nil
+}
+
+// Replaces the user with the same Username
+func (r *UserRepository) Update(user models.User) {
+	r.Lock()
+	defer r.Unlock()
+
+	for index := range r.users {
+		if r.users[index].Username == user.Username {
+			r.users[index] = user
+			return
+		}
+	}
+
+	r.users = append(r.users, user)
+}
@@ -25,3 +25,38 @@ func TestAnInvalidToken(t *testing.T) {
 		t.Errorf("Expected to find no user, but got: %v", f)
 	}
 }
+
+func TestFindingAUserThroughUsername(t *testing.T) {
+	repo := UserRepository{}
+
+	repo.Insert(models.User{Username: "username", AuthToken: "token"})
+
+	if f := repo.FindByUsername("username"); f == nil || f.AuthToken != "token" {
+		t.Errorf("Expected to find user by username, got: %v", f)
+	}
+}
+
+func TestUpdatingAUser(t *testing.T) {
+	repo := UserRepository{}
+
+	repo.Insert(models.User{Username: "username", AuthToken: "token"})
+	repo.Update(models.User{Username: "username", AuthToken: "rotated"})
+
+	if all := repo.GetAll(); len(all) != 1 || all[0].AuthToken != "rotated" {
+		t.Errorf("Expected user to be updated, got: %v", all)
+	}
+}
+
+func TestFindingAUserIgnoringCase(t *testing.T) {
+	repo := UserRepository{}
+	repo.Insert(models.User{Username: "Marianne", AuthToken: "token1"})
+	repo.Insert(models.User{Username: "marianne", AuthToken: "token2"})
+
+	if f := repo.FindByUsernameIgnoringCase("MARIANNE"); f == nil || f.AuthToken != "token1" {
+		t.Errorf("Expected to find the first matching user, got: %v", f)
+	}
+
+	if f := repo.FindByUsernameIgnoringCase("marianne"); f == nil || f.AuthToken != "token2" {
+		t.Errorf("Expected to find the user matching exactly, got: %v", f)
+	}
+}
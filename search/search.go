@@ -0,0 +1,267 @@
+// Package search parses the query parameters for listing messages into a
+// typed Query. The query decides which messages match and how they are
+// ordered, so every repository backend answers it the same way.
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// A field to order by. Descending is written as a leading - in the sort
+// parameter, ie sort=-created_at,author
+type SortField struct {
+	Name       string
+	Descending bool
+}
+
+// Selects and orders messages. Zero values don't filter anything, so the
+// zero Query matches every message in the order they were added
+type Query struct {
+	// Messages by any of these authors
+	Authors []string
+	// Messages created or updated within these bounds. Both ends are
+	// exclusive. Messages without the time never match a bound
+	CreatedAfter, CreatedBefore *time.Time
+	UpdatedAfter, UpdatedBefore *time.Time
+	// Messages with all of Tags, or any of them if AnyTag is set. The tags
+	// are normalized, see models.NormalizeTags
+	Tags   []string
+	AnyTag bool
+	// Replies to this message. An empty ParentID selects the messages
+	// starting a thread
+	ParentID *string
+	// Messages with Text in their topic or body, ignoring case
+	Text string
+	// Fields to order by, the first one deciding first
+	Sort []SortField
+	// Puts pinned messages before the others, the one pinned last first,
+	// regardless of Sort
+	PinnedFirst bool
+}
+
+// The fields messages can be sorted by, and how they compare
+var sortFields = map[string]func(a, b *models.Message) int{
+	"id":         func(a, b *models.Message) int { return compareIDs(a.ID, b.ID) },
+	"author":     func(a, b *models.Message) int { return strings.Compare(a.Author, b.Author) },
+	"topic":      func(a, b *models.Message) int { return strings.Compare(a.Topic, b.Topic) },
+	"created_at": func(a, b *models.Message) int { return compareTimes(a.CreatedAt, b.CreatedAt) },
+	"updated_at": func(a, b *models.Message) int { return compareTimes(a.UpdatedAt, b.UpdatedAt) },
+}
+
+// The query parameters Parse understands
+var parameters = map[string]bool{
+	"author":         true,
+	"created_after":  true,
+	"created_before": true,
+	"updated_after":  true,
+	"updated_before": true,
+	"tag":            true,
+	"tag_match":      true,
+	"parent":         true,
+	"q":              true,
+	"sort":           true,
+}
+
+// Reads a Query from the query parameters of a request. Times are RFC 3339.
+// Unknown parameters are an error, unless they are in handled, which are the
+// parameters read by the caller (ie limit and offset). Pinned messages are
+// put first
+func Parse(values url.Values, handled ...string) (Query, error) {
+	q := Query{PinnedFirst: true}
+
+	for name := range values {
+		if !parameters[name] && !contains(handled, name) {
+			return q, fmt.Errorf("unknown query parameter %q", name)
+		}
+	}
+
+	q.Authors = values["author"]
+	q.Tags = models.NormalizeTags(values["tag"])
+	q.Text = values.Get("q")
+
+	if parent, ok := values["parent"]; ok {
+		q.ParentID = &parent[0]
+	}
+
+	switch match := values.Get("tag_match"); match {
+	case "", "all":
+	case "any":
+		q.AnyTag = true
+	default:
+		return q, fmt.Errorf("tag_match must be all or any, not %q", match)
+	}
+
+	times := map[string]**time.Time{
+		"created_after":  &q.CreatedAfter,
+		"created_before": &q.CreatedBefore,
+		"updated_after":  &q.UpdatedAfter,
+		"updated_before": &q.UpdatedBefore,
+	}
+
+	for name, value := range times {
+		if raw := values.Get(name); len(raw) > 0 {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 time, ie 2006-01-02T15:04:05Z", name)
+			}
+			*value = &t
+		}
+	}
+
+	for _, raw := range values["sort"] {
+		for _, name := range strings.Split(raw, ",") {
+			field := SortField{Name: strings.TrimPrefix(name, "-"), Descending: strings.HasPrefix(name, "-")}
+
+			if _, ok := sortFields[field.Name]; !ok {
+				return q, fmt.Errorf("unknown sort field %q", field.Name)
+			}
+
+			q.Sort = append(q.Sort, field)
+		}
+	}
+
+	return q, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tells if message is selected by the query. Tags are checked too, even if
+// a backend found the message through an index of them
+func (q Query) Matches(message models.Message) bool {
+	if len(q.Authors) > 0 && !contains(q.Authors, message.Author) {
+		return false
+	}
+
+	if !within(message.CreatedAt, q.CreatedAfter, q.CreatedBefore) || !within(message.UpdatedAt, q.UpdatedAfter, q.UpdatedBefore) {
+		return false
+	}
+
+	if q.ParentID != nil && message.ParentID != *q.ParentID {
+		return false
+	}
+
+	if len(q.Tags) > 0 && !q.matchesTags(message.Tags) {
+		return false
+	}
+
+	if len(q.Text) > 0 {
+		text := strings.ToLower(q.Text)
+
+		if !strings.Contains(strings.ToLower(message.Topic), text) && !strings.Contains(strings.ToLower(message.Body), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (q Query) matchesTags(tags []string) bool {
+	for _, tag := range q.Tags {
+		found := contains(tags, tag)
+
+		if found && q.AnyTag {
+			return true
+		}
+		if !found && !q.AnyTag {
+			return false
+		}
+	}
+
+	return !q.AnyTag
+}
+
+func within(t, after, before *time.Time) bool {
+	if after == nil && before == nil {
+		return true
+	}
+
+	return t != nil && (after == nil || t.After(*after)) && (before == nil || t.Before(*before))
+}
+
+// Orders messages by the sort fields, after the pinned ones if PinnedFirst
+// is set. Messages comparing equal keep their order, so messages are in the
+// order they were added unless sorted otherwise
+func (q Query) SortMessages(messages []models.Message) {
+	if len(q.Sort) == 0 && !q.PinnedFirst {
+		return
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if q.PinnedFirst {
+			// Messages that aren't pinned have no time, and come last
+			if c := compareTimes(messages[i].PinnedAt, messages[j].PinnedAt); c != 0 {
+				return c > 0
+			}
+		}
+
+		for _, field := range q.Sort {
+			c := sortFields[field.Name](&messages[i], &messages[j])
+
+			if field.Descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+
+		return false
+	})
+}
+
+// IDs are compared as numbers when they both are, so 10 comes after 9.
+// Imported messages may have other IDs, which are compared as strings, after
+// the numbers
+func compareIDs(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case errA == nil && errB == nil:
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+// Messages without the time come first
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.Before(*b):
+		return -1
+	case a.After(*b):
+		return 1
+	}
+
+	return 0
+}
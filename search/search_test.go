@@ -0,0 +1,123 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+func parse(t *testing.T, raw string) Query {
+	t.Helper()
+
+	values, _ := url.ParseQuery(raw)
+	q, err := Parse(values, "limit")
+	if err != nil {
+		t.Fatalf("Unexpected error for %s: %v", raw, err)
+	}
+
+	return q
+}
+
+func TestParse(t *testing.T) {
+	q := parse(t, "author=dennis&author=marianne&tag=Go&tag_match=any&parent=&q=Hello&created_after=2020-01-02T03:04:05Z&sort=-created_at,id&limit=1")
+
+	if len(q.Authors) != 2 || q.Tags[0] != "go" || !q.AnyTag || q.ParentID == nil || *q.ParentID != "" || q.Text != "Hello" {
+		t.Errorf("Unexpected query: %+v", q)
+	}
+
+	if q.CreatedAfter == nil || !q.CreatedAfter.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) || q.CreatedBefore != nil {
+		t.Errorf("Unexpected times: %v, %v", q.CreatedAfter, q.CreatedBefore)
+	}
+
+	if len(q.Sort) != 2 || q.Sort[0] != (SortField{"created_at", true}) || q.Sort[1] != (SortField{"id", false}) {
+		t.Errorf("Unexpected sort: %v", q.Sort)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, raw := range []string{"color=red", "offset=1", "sort=color", "sort=id,", "tag_match=some", "updated_before=yesterday"} {
+		values, _ := url.ParseQuery(raw)
+
+		if _, err := Parse(values, "limit"); err == nil {
+			t.Errorf("Expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	message := models.Message{ID: "2", Topic: "Hello", Body: "World", Author: "dennis", ParentID: "1", Tags: []string{"go"}, CreatedAt: &created}
+
+	cases := []struct {
+		raw     string
+		matches bool
+	}{
+		{"", true},
+		{"author=marianne&author=dennis", true},
+		{"author=marianne", false},
+		{"q=WORLD", true},
+		{"q=nope", false},
+		{"parent=1", true},
+		{"parent=", false},
+		{"tag=go&tag=release", false},
+		{"tag=go&tag=release&tag_match=any", true},
+		{"created_after=2019-12-31T00:00:00Z&created_before=2020-01-02T00:00:00Z", true},
+		{"created_after=2020-01-01T00:00:00Z", false},
+		// Messages without the time don't match bounds on it
+		{"updated_before=2030-01-01T00:00:00Z", false},
+	}
+
+	for _, c := range cases {
+		if matches := parse(t, c.raw).Matches(message); matches != c.matches {
+			t.Errorf("Expected %s to match: %v, got %v", c.raw, c.matches, matches)
+		}
+	}
+}
+
+func TestSortMessages(t *testing.T) {
+	earlier := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	messages := []models.Message{
+		{ID: "10", CreatedAt: &earlier},
+		{ID: "9", CreatedAt: &later},
+		{ID: "imported"},
+		{ID: "8", CreatedAt: &later},
+	}
+
+	parse(t, "sort=-created_at,id").SortMessages(messages)
+
+	ids := ""
+	for _, m := range messages {
+		ids += m.ID + " "
+	}
+
+	if ids != "8 9 10 imported " {
+		t.Errorf("Unexpected order: %s", ids)
+	}
+}
+
+func TestSortMessages_Pinned(t *testing.T) {
+	earlier := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	messages := []models.Message{
+		{ID: "1"},
+		{ID: "2", PinnedAt: &earlier},
+		{ID: "3"},
+		{ID: "4", PinnedAt: &later},
+	}
+
+	parse(t, "sort=-id").SortMessages(messages)
+
+	ids := ""
+	for _, m := range messages {
+		ids += m.ID + " "
+	}
+
+	if ids != "4 2 3 1 " {
+		t.Errorf("Unexpected order: %s", ids)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dennis/hello_go/blobs"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/validation"
+)
+
+// Attaches content as a file named name to the message with id. Only its
+// author may do this, unless its thread is locked. The content type is sniffed from the content, and
+// content already stored for another attachment is shared with it
+func (s *MessageService) AddAttachment(id, name string, content io.ReadSeeker, user models.User) (*models.Attachment, error) {
+	message := s.find(id)
+
+	if message == nil {
+		return nil, &NotFoundError{}
+	}
+
+	if message.Author != user.Username {
+		return nil, &NotOwnerError{}
+	}
+
+	if s.isLocked(*message) {
+		return nil, &LockedError{}
+	}
+
+	attachment, err := describe(content)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.Name = name
+	attachment.CreatedAt = s.now()
+
+	if errors := attachment.Validate(); len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	// releaseBlobs mustn't delete the content before the message refers to it
+	s.blobsMu.Lock()
+	defer s.blobsMu.Unlock()
+
+	if err := s.Blobs.Put(attachment.SHA256, content); err != nil {
+		return nil, err
+	}
+
+	// Storing the content takes a while, so the message is checked again as
+	// the attachment is added, not to undo what happened to it meanwhile
+	err = &NotFoundError{}
+	updated := s.MessageRepository.UpdateIf(id, func(m *models.Message) bool {
+		if m.IsDeleted() {
+			return false
+		}
+
+		if m.Author != user.Username {
+			err = &NotOwnerError{}
+			return false
+		}
+
+		attachment.ID = nextAttachmentID(m.Attachments)
+
+		ids := []string{attachment.ID}
+		for _, a := range m.Attachments {
+			ids = append(ids, a.ID)
+		}
+
+		v := validation.Validator{}
+		v.Each("attachments", ids, models.CurrentLimits.MaxAttachments)
+
+		if errors := v.Errors(); len(errors) > 0 {
+			err = &NotValidError{Errors: errors}
+			return false
+		}
+
+		m.Attachments = append(m.Attachments, attachment)
+		m.UpdatedAt = &attachment.CreatedAt
+		return true
+	})
+
+	if updated == nil {
+		// The content may be stored for nothing
+		if releaseErr := s.releaseBlobsWithoutLock([]models.Attachment{attachment}); releaseErr != nil {
+			return nil, releaseErr
+		}
+
+		return nil, err
+	}
+
+	s.Events.Publish(MessageEvent{Type: EventUpdated, Message: *updated})
+
+	return &attachment, nil
+}
+
+// Returns the size, digest and content type of content, which is left at
+// its start
+func describe(content io.ReadSeeker) (models.Attachment, error) {
+	attachment := models.Attachment{}
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return attachment, err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return attachment, err
+	}
+
+	// DetectContentType considers at most the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return attachment, err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return attachment, err
+	}
+
+	attachment.Size = size
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	attachment.ContentType = http.DetectContentType(head[:n])
+
+	return attachment, nil
+}
+
+// Attachment IDs count up from 1 within a message, and aren't reused
+func nextAttachmentID(attachments []models.Attachment) string {
+	next := 1
+
+	for _, a := range attachments {
+		if n, err := strconv.Atoi(a.ID); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+
+	return strconv.Itoa(next)
+}
+
+func findAttachment(message *models.Message, id string) int {
+	for i, a := range message.Attachments {
+		if a.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns the attachment with attachmentID of the message with id, and its
+// content, which the caller must close. user must be able to read the
+// message
+func (s *MessageService) GetAttachment(id, attachmentID string, user models.User) (*models.Attachment, io.ReadSeekCloser, error) {
+	message, err := s.GetMessage(id, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := findAttachment(message, attachmentID)
+	if i < 0 {
+		return nil, nil, &NotFoundError{}
+	}
+
+	content, err := s.Blobs.Open(message.Attachments[i].SHA256)
+	if errors.Is(err, blobs.ErrNotFound) {
+		return nil, nil, &NotFoundError{}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	return &message.Attachments[i], content, nil
+}
+
+// Removes the attachment with attachmentID from the message with id. Only
+// its author may do this, unless its thread is locked
+func (s *MessageService) DeleteAttachment(id, attachmentID string, user models.User) error {
+	message := s.find(id)
+
+	if message == nil {
+		return &NotFoundError{}
+	}
+
+	if message.Author != user.Username {
+		return &NotOwnerError{}
+	}
+
+	if s.isLocked(*message) {
+		return &LockedError{}
+	}
+
+	var removed models.Attachment
+	var err error = &NotFoundError{}
+
+	updated := s.MessageRepository.UpdateIf(id, func(m *models.Message) bool {
+		if m.IsDeleted() {
+			return false
+		}
+
+		if m.Author != user.Username {
+			err = &NotOwnerError{}
+			return false
+		}
+
+		i := findAttachment(m, attachmentID)
+		if i < 0 {
+			return false
+		}
+
+		removed = m.Attachments[i]
+		m.Attachments = append(m.Attachments[:i:i], m.Attachments[i+1:]...)
+		now := s.now()
+		m.UpdatedAt = &now
+		return true
+	})
+
+	if updated == nil {
+		return err
+	}
+
+	s.Events.Publish(MessageEvent{Type: EventUpdated, Message: *updated})
+
+	return s.releaseBlobs([]models.Attachment{removed})
+}
+
+// Deletes the blobs of attachments that no message refers to any more,
+// including those in the trash
+func (s *MessageService) releaseBlobs(attachments []models.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	s.blobsMu.Lock()
+	defer s.blobsMu.Unlock()
+
+	return s.releaseBlobsWithoutLock(attachments)
+}
+
+func (s *MessageService) releaseBlobsWithoutLock(attachments []models.Attachment) error {
+	used := map[string]bool{}
+
+	for _, m := range s.MessageRepository.GetAll() {
+		for _, a := range m.Attachments {
+			used[a.SHA256] = true
+		}
+	}
+
+	for _, a := range attachments {
+		if !used[a.SHA256] {
+			if err := s.Blobs.Delete(a.SHA256); err != nil {
+				return err
+			}
+
+			used[a.SHA256] = true
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/validation"
+)
+
+// Someone the message would reach has blocked its author, so it can't be
+// sent to or reply to them
+type BlockedError struct{}
+
+func (e *BlockedError) Error() string { return "Blocked" }
+
+// Tells if user has muted or blocked the author of message, in which case it
+// is left out of the lists, searches and streams user sees
+func (s *MessageService) IsHidden(message models.Message, user models.User) bool {
+	return s.BlockRepository.IsMuted(user.Username, message.Author) || s.BlockRepository.IsBlocked(user.Username, message.Author)
+}
+
+// Tells if any of usernames has blocked user
+func (s *MessageService) blockedBy(usernames []string, user models.User) bool {
+	for _, username := range usernames {
+		if s.BlockRepository.IsBlocked(username, user.Username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns errors for the users mentioned in message who have blocked user
+func (s *MessageService) blockedMentions(message models.Message, user models.User) []validation.Error {
+	errors := []validation.Error{}
+
+	for _, mention := range message.Mentions() {
+		mentioned := s.UserRepository.FindByUsernameIgnoringCase(mention)
+
+		if mentioned != nil && s.BlockRepository.IsBlocked(mentioned.Username, user.Username) {
+			errors = append(errors, validation.Error{
+				Field:   "body",
+				Code:    validation.CodeNotAllowed,
+				Message: "Mentioned user has blocked you",
+				Params:  map[string]interface{}{"username": mentioned.Username},
+			})
+		}
+	}
+
+	return errors
+}
+
+// Returns an error unless user may mute or block the user with username,
+// who must exist and be someone else
+func (s *MessageService) checkBlockable(username string, user models.User) error {
+	if s.UserRepository.FindByUsername(username) == nil {
+		return &NotFoundError{}
+	}
+
+	if username == user.Username {
+		return &NotValidError{Errors: []validation.Error{{
+			Field:   "username",
+			Code:    validation.CodeNotAllowed,
+			Message: "You can't mute or block yourself",
+		}}}
+	}
+
+	return nil
+}
+
+// Returns the users user has muted, ordered by username
+func (s *MessageService) GetMuted(user models.User) []string {
+	return s.BlockRepository.FindMuted(user.Username)
+}
+
+// Mutes the user with username for user, hiding their messages from user.
+// Muting someone twice does nothing. Returns the users user has muted
+// afterwards
+func (s *MessageService) Mute(username string, user models.User) ([]string, error) {
+	if err := s.checkBlockable(username, user); err != nil {
+		return nil, err
+	}
+
+	s.BlockRepository.Mute(user.Username, username)
+
+	return s.GetMuted(user), nil
+}
+
+// Unmutes the user with username for user, if they are muted. Returns the
+// users user has muted afterwards
+func (s *MessageService) Unmute(username string, user models.User) []string {
+	s.BlockRepository.Unmute(user.Username, username)
+
+	return s.GetMuted(user)
+}
+
+// Returns the users user has blocked, ordered by username
+func (s *MessageService) GetBlocked(user models.User) []string {
+	return s.BlockRepository.FindBlocked(user.Username)
+}
+
+// Blocks the user with username for user. Their messages are hidden from
+// user like when muted, and they can't reply to messages by user, mention
+// them or send them direct messages. Blocking someone twice does nothing.
+// Returns the users user has blocked afterwards
+func (s *MessageService) Block(username string, user models.User) ([]string, error) {
+	if err := s.checkBlockable(username, user); err != nil {
+		return nil, err
+	}
+
+	s.BlockRepository.Block(user.Username, username)
+
+	return s.GetBlocked(user), nil
+}
+
+// Unblocks the user with username for user, if they are blocked. Returns the
+// users user has blocked afterwards
+func (s *MessageService) Unblock(username string, user models.User) []string {
+	s.BlockRepository.Unblock(user.Username, username)
+
+	return s.GetBlocked(user)
+}
@@ -0,0 +1,519 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/dennis/hello_go/bulk"
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/validation"
+)
+
+// What to do when an imported record already exists
+type ConflictStrategy string
+
+const (
+	// Keep the existing record, and ignore the imported
+	ConflictSkip ConflictStrategy = "skip"
+	// Replace the existing record with the imported
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// Import nothing at all
+	ConflictFail ConflictStrategy = "fail"
+)
+
+func ParseConflictStrategy(name string) (ConflictStrategy, error) {
+	switch s := ConflictStrategy(name); s {
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+		return s, nil
+	case "":
+		return ConflictFail, nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q, use skip, overwrite or fail", name)
+	}
+}
+
+type ImportOptions struct {
+	// Only report what would happen
+	DryRun bool
+	// Keep the IDs of imported messages. Otherwise they get new IDs, and
+	// can't conflict with existing messages
+	PreserveIDs bool
+	OnConflict  ConflictStrategy
+}
+
+// Outcome of a single record
+const (
+	ImportStatusImported    = "imported"
+	ImportStatusOverwritten = "overwritten"
+	ImportStatusSkipped     = "skipped"
+	ImportStatusInvalid     = "invalid"
+	ImportStatusConflict    = "conflict"
+)
+
+type ImportLine struct {
+	Line int `json:"line"`
+	// ID of the message (or username) after importing. Empty for messages
+	// getting a new ID in a dry run
+	ID string `json:"id,omitempty"`
+	// ID of the message in the import, if it was remapped
+	OriginalID string             `json:"original_id,omitempty"`
+	Status     string             `json:"status"`
+	Errors     []validation.Error `json:"errors,omitempty"`
+	// What an overwritten message keeps of the message it replaces, as the
+	// import doesn't have it: "attachments" and "reactions"
+	Kept []string `json:"kept,omitempty"`
+}
+
+// Describes the outcome of an import, line by line. Invalid lines are never
+// imported, but doesn't prevent valid lines from being imported. If a
+// conflict is found with ConflictFail, nothing is imported and Aborted is set
+type ImportReport struct {
+	DryRun      bool         `json:"dry_run"`
+	Aborted     bool         `json:"aborted"`
+	Imported    int          `json:"imported"`
+	Overwritten int          `json:"overwritten"`
+	Skipped     int          `json:"skipped"`
+	Invalid     int          `json:"invalid"`
+	Lines       []ImportLine `json:"lines"`
+}
+
+func (r *ImportReport) add(line ImportLine) {
+	switch line.Status {
+	case ImportStatusImported:
+		r.Imported++
+	case ImportStatusOverwritten:
+		r.Overwritten++
+	case ImportStatusSkipped:
+		r.Skipped++
+	case ImportStatusInvalid:
+		r.Invalid++
+	case ImportStatusConflict:
+		r.Aborted = true
+	}
+
+	r.Lines = append(r.Lines, line)
+}
+
+// True if every line was imported (or would have been in a dry run)
+func (r *ImportReport) Clean() bool {
+	return !r.Aborted && r.Invalid == 0
+}
+
+func malformedRecord(err error) []validation.Error {
+	return []validation.Error{{
+		Code:    validation.CodeMalformed,
+		Message: fmt.Sprintf("The record could not be parsed: %v", err),
+		Params:  map[string]interface{}{"error": err.Error()},
+	}}
+}
+
+// Imports and exports messages, users and channels in bulk. The report is computed
+// before anything is written, so a dry run reports exactly what a real
+// import would do
+type BulkService struct {
+	MessageRepository *repositories.MessageRepository
+	UserRepository    *repositories.UserRepository
+	ChannelRepository *repositories.ChannelRepository
+	// The reactions overwritten messages keep. May be nil
+	ReactionRepository *repositories.ReactionRepository
+}
+
+// Keeps what message, overwriting the existing message with its ID, can't
+// bring along: the attachments, as imports don't have their content, unless
+// message has attachments of its own, and the reactions. Returns what was
+// kept, for the report
+func (s *BulkService) keep(message *models.Message) []string {
+	existing := s.MessageRepository.FindByID(message.ID)
+	if existing == nil {
+		return nil
+	}
+
+	kept := []string{}
+
+	if len(message.Attachments) == 0 && len(existing.Attachments) > 0 {
+		message.Attachments = existing.Attachments
+		kept = append(kept, "attachments")
+	}
+
+	if s.ReactionRepository != nil && len(s.ReactionRepository.FindByMessageID(message.ID)) > 0 {
+		kept = append(kept, "reactions")
+	}
+
+	return kept
+}
+
+// Checks that the author, channel and recipients of message exist, and that
+// the author may post in the channel, as when creating a message
+func (s *BulkService) references(message models.Message) []validation.Error {
+	errors := []validation.Error{}
+
+	if s.UserRepository.FindByUsername(message.Author) == nil {
+		errors = append(errors, validation.Error{
+			Field:   "author",
+			Code:    validation.CodeNotFound,
+			Message: "Author does not exist",
+			Params:  map[string]interface{}{"username": message.Author},
+		})
+	}
+
+	if message.IsDirect() {
+		if len(message.ChannelID) > 0 {
+			errors = append(errors, validation.Error{
+				Field:   "channel_id",
+				Code:    validation.CodeNotAllowed,
+				Message: "Channel is not allowed for direct messages",
+			})
+		}
+
+		for _, recipient := range message.Recipients {
+			if s.UserRepository.FindByUsername(recipient) == nil {
+				errors = append(errors, validation.Error{
+					Field:   "recipients",
+					Code:    validation.CodeNotFound,
+					Message: "Recipient does not exist",
+					Params:  map[string]interface{}{"username": recipient},
+				})
+			}
+		}
+
+		return errors
+	}
+
+	if channel := s.ChannelRepository.FindByID(message.ChannelID); channel == nil {
+		errors = append(errors, validation.Error{
+			Field:   "channel_id",
+			Code:    validation.CodeNotFound,
+			Message: "Channel does not exist",
+		})
+	} else if !channel.IsMember(message.Author) {
+		errors = append(errors, validation.Error{
+			Field:   "author",
+			Code:    validation.CodeNotAllowed,
+			Message: "Author is not a member of the channel",
+			Params:  map[string]interface{}{"username": message.Author},
+		})
+	}
+
+	return errors
+}
+
+// Returns the errors of each record in records but those about its parent
+func (s *BulkService) validateMessages(records []bulk.MessageRecord, messages []models.Message) [][]validation.Error {
+	errors := make([][]validation.Error, len(records))
+
+	for i, record := range records {
+		if record.Err != nil {
+			errors[i] = malformedRecord(record.Err)
+			continue
+		}
+
+		errors[i] = messages[i].Validate()
+
+		if s.UserRepository != nil {
+			errors[i] = append(errors[i], s.references(messages[i])...)
+		}
+	}
+
+	return errors
+}
+
+// Finds the messages replying to a message which is neither imported along
+// with them nor, when the IDs are kept, already there. A remapped reply
+// would otherwise keep the ID of a message that may be another by now
+func (s *BulkService) validateParents(messages []models.Message, errors [][]validation.Error, options ImportOptions) {
+	imported := map[string]bool{}
+
+	for i, message := range messages {
+		if len(errors[i]) == 0 && len(message.ID) > 0 {
+			imported[message.ID] = true
+		}
+	}
+
+	// Until no more replies are found invalid, as their replies in turn
+	// are left without a parent
+	for changed := true; changed; {
+		changed = false
+
+		for i, message := range messages {
+			if len(errors[i]) > 0 || len(message.ParentID) == 0 || imported[message.ParentID] {
+				continue
+			}
+
+			if options.PreserveIDs && (s.UserRepository == nil || s.MessageRepository.FindByID(message.ParentID) != nil) {
+				continue
+			}
+
+			errors[i] = []validation.Error{{
+				Field:   "parent_id",
+				Code:    validation.CodeNotFound,
+				Message: "Parent does not exist",
+			}}
+			delete(imported, message.ID)
+			changed = true
+		}
+	}
+}
+
+// Messages are checked like when they are created: their author, channel,
+// recipients and parent must exist, and the author must be a member of the
+// channel. Without a UserRepository, as when the app loads the messages it
+// wrote itself, only the messages themselves are checked. When IDs aren't
+// kept, replies must be imported along with their parent
+func (s *BulkService) ImportMessages(records []bulk.MessageRecord, options ImportOptions) *ImportReport {
+	report := &ImportReport{DryRun: options.DryRun, Lines: []ImportLine{}}
+	planned := []models.Message{}
+	seen := map[string]bool{}
+	messages := []models.Message{}
+
+	for _, record := range records {
+		message := record.Message
+		message.Tags = models.NormalizeTags(message.Tags)
+
+		// Messages from before channels existed. Direct messages aren't in
+		// any channel
+		if len(message.ChannelID) == 0 && !message.IsDirect() {
+			message.ChannelID = models.DefaultChannelID
+		}
+
+		messages = append(messages, message)
+	}
+
+	invalid := s.validateMessages(records, messages)
+	s.validateParents(messages, invalid, options)
+
+	for i, record := range records {
+		line := ImportLine{Line: record.Line, ID: record.Message.ID}
+		message := messages[i]
+
+		if len(invalid[i]) > 0 {
+			line.Status = ImportStatusInvalid
+			line.Errors = invalid[i]
+		} else if !options.PreserveIDs || len(message.ID) == 0 {
+			line.Status = ImportStatusImported
+			line.OriginalID = message.ID
+			line.ID = ""
+			message.ID = ""
+		} else if seen[message.ID] || s.MessageRepository.FindByID(message.ID) != nil {
+			switch options.OnConflict {
+			case ConflictSkip:
+				line.Status = ImportStatusSkipped
+			case ConflictOverwrite:
+				line.Status = ImportStatusOverwritten
+				line.Kept = s.keep(&message)
+			default:
+				line.Status = ImportStatusConflict
+			}
+		} else {
+			line.Status = ImportStatusImported
+		}
+
+		if line.Status == ImportStatusImported || line.Status == ImportStatusOverwritten {
+			planned = append(planned, message)
+
+			if len(message.ID) > 0 {
+				seen[message.ID] = true
+			}
+		} else {
+			planned = append(planned, models.Message{})
+		}
+
+		report.add(line)
+	}
+
+	if report.Aborted || options.DryRun {
+		return report
+	}
+
+	// Replies to messages getting new IDs must follow them. Replies coming
+	// before their parent in the import are changed once it has its ID
+	remapped := map[string]string{}
+	pending := []int{}
+
+	for i, message := range planned {
+		line := &report.Lines[i]
+
+		if id, ok := remapped[message.ParentID]; ok {
+			message.ParentID = id
+		} else if len(message.ParentID) > 0 {
+			pending = append(pending, i)
+		}
+
+		switch {
+		case line.Status == ImportStatusOverwritten:
+			s.MessageRepository.Update(message)
+		case line.Status == ImportStatusImported && len(message.ID) == 0:
+			line.ID = s.MessageRepository.Insert(message)
+
+			if len(line.OriginalID) > 0 {
+				remapped[line.OriginalID] = line.ID
+			}
+		case line.Status == ImportStatusImported:
+			s.MessageRepository.InsertWithID(message)
+		}
+	}
+
+	for _, i := range pending {
+		id, ok := remapped[planned[i].ParentID]
+		if !ok || len(report.Lines[i].ID) == 0 {
+			continue
+		}
+
+		s.MessageRepository.UpdateIf(report.Lines[i].ID, func(m *models.Message) bool {
+			m.ParentID = id
+			return true
+		})
+	}
+
+	return report
+}
+
+// Users are identified by their username. Users without a token gets one
+// generated. Users with the token of another user, existing or imported
+// before them, are invalid
+func (s *BulkService) ImportUsers(records []bulk.UserRecord, options ImportOptions) *ImportReport {
+	report := &ImportReport{DryRun: options.DryRun, Lines: []ImportLine{}}
+	planned := []models.User{}
+	seen := map[string]bool{}
+	// The username of the user getting each token in the import
+	tokens := map[string]string{}
+
+	for _, record := range records {
+		line := ImportLine{Line: record.Line, ID: record.User.Username}
+		user := record.User
+
+		if len(user.AuthToken) == 0 {
+			user.AuthToken = generateToken()
+		}
+
+		if record.Err != nil {
+			line.Status = ImportStatusInvalid
+			line.Errors = malformedRecord(record.Err)
+		} else if errors := user.Validate(); len(errors) > 0 {
+			line.Status = ImportStatusInvalid
+			line.Errors = errors
+		} else if seen[user.Username] || s.UserRepository.FindByUsername(user.Username) != nil {
+			switch options.OnConflict {
+			case ConflictSkip:
+				line.Status = ImportStatusSkipped
+			case ConflictOverwrite:
+				line.Status = ImportStatusOverwritten
+			default:
+				line.Status = ImportStatusConflict
+			}
+		} else {
+			line.Status = ImportStatusImported
+		}
+
+		if line.Status == ImportStatusImported || line.Status == ImportStatusOverwritten {
+			if s.tokenTaken(user, tokens) {
+				line.Status = ImportStatusInvalid
+				line.Errors = []validation.Error{tokenTaken}
+			} else {
+				seen[user.Username] = true
+				tokens[user.AuthToken] = user.Username
+			}
+		}
+
+		planned = append(planned, user)
+		report.add(line)
+	}
+
+	if report.Aborted || options.DryRun {
+		return report
+	}
+
+	for i, user := range planned {
+		switch report.Lines[i].Status {
+		case ImportStatusImported:
+			s.UserRepository.Insert(user)
+		case ImportStatusOverwritten:
+			s.UserRepository.Update(user)
+		}
+	}
+
+	return report
+}
+
+// Whether another user than user has its token, in the repository or
+// earlier in the import, where tokens has the username of each
+func (s *BulkService) tokenTaken(user models.User, tokens map[string]string) bool {
+	if username, ok := tokens[user.AuthToken]; ok {
+		return username != user.Username
+	}
+
+	existing := s.UserRepository.FindByToken(user.AuthToken)
+
+	return existing != nil && existing.Username != user.Username
+}
+
+// Channels keep their IDs, like the messages in them refer to. Channels
+// without an ID gets a new one
+func (s *BulkService) ImportChannels(records []bulk.ChannelRecord, options ImportOptions) *ImportReport {
+	report := &ImportReport{DryRun: options.DryRun, Lines: []ImportLine{}}
+	planned := []models.Channel{}
+	seen := map[string]bool{}
+
+	for _, record := range records {
+		line := ImportLine{Line: record.Line, ID: record.Channel.ID}
+		channel := record.Channel
+
+		if record.Err != nil {
+			line.Status = ImportStatusInvalid
+			line.Errors = malformedRecord(record.Err)
+		} else if errors := channel.Validate(); len(errors) > 0 {
+			line.Status = ImportStatusInvalid
+			line.Errors = errors
+		} else if len(channel.ID) == 0 {
+			line.Status = ImportStatusImported
+		} else if seen[channel.ID] || s.ChannelRepository.FindByID(channel.ID) != nil {
+			switch options.OnConflict {
+			case ConflictSkip:
+				line.Status = ImportStatusSkipped
+			case ConflictOverwrite:
+				line.Status = ImportStatusOverwritten
+			default:
+				line.Status = ImportStatusConflict
+			}
+		} else {
+			line.Status = ImportStatusImported
+		}
+
+		if line.Status == ImportStatusImported || line.Status == ImportStatusOverwritten {
+			seen[channel.ID] = true
+		}
+
+		planned = append(planned, channel)
+		report.add(line)
+	}
+
+	if report.Aborted || options.DryRun {
+		return report
+	}
+
+	for i, channel := range planned {
+		line := &report.Lines[i]
+
+		switch {
+		case line.Status == ImportStatusOverwritten:
+			s.ChannelRepository.Update(channel)
+		case line.Status == ImportStatusImported && len(channel.ID) == 0:
+			line.ID = s.ChannelRepository.Insert(channel)
+		case line.Status == ImportStatusImported:
+			s.ChannelRepository.InsertWithID(channel)
+		}
+	}
+
+	return report
+}
+
+func (s *BulkService) ExportMessages() []models.Message {
+	return s.MessageRepository.GetAll()
+}
+
+func (s *BulkService) ExportUsers() []models.User {
+	return s.UserRepository.GetAll()
+}
+
+func (s *BulkService) ExportChannels() []models.Channel {
+	return s.ChannelRepository.GetAll()
+}
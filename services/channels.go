@@ -0,0 +1,136 @@
+package services
+
+import (
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/validation"
+)
+
+type ChannelService struct {
+	ChannelRepository *repositories.ChannelRepository
+	UserRepository    *repositories.UserRepository
+}
+
+// Returns the channels user is a member of
+func (s *ChannelService) GetChannels(user models.User) []models.Channel {
+	channels := []models.Channel{}
+
+	for _, c := range s.ChannelRepository.GetAll() {
+		if c.IsMember(user.Username) {
+			channels = append(channels, c)
+		}
+	}
+
+	return channels
+}
+
+// Returns the channel with id. Only members may see it
+func (s *ChannelService) GetChannel(id string, user models.User) (*models.Channel, error) {
+	channel := s.ChannelRepository.FindByID(id)
+
+	if channel == nil {
+		return nil, &NotFoundError{}
+	}
+
+	if !channel.IsMember(user.Username) {
+		return nil, &ForbiddenError{}
+	}
+
+	return channel, nil
+}
+
+// Creates a channel owned by user. The owner is always a member, and the
+// other members must exist
+func (s *ChannelService) CreateChannel(channel models.Channel, user models.User) (*models.Channel, error) {
+	errors := channel.Validate()
+
+	members := []string{user.Username}
+	seen := map[string]bool{user.Username: true}
+
+	for _, member := range channel.Members {
+		if seen[member] {
+			continue
+		}
+		seen[member] = true
+
+		if s.UserRepository.FindByUsername(member) == nil {
+			errors = append(errors, validation.Error{
+				Field:   "members",
+				Code:    validation.CodeNotFound,
+				Message: "Member does not exist",
+				Params:  map[string]interface{}{"username": member},
+			})
+			continue
+		}
+
+		members = append(members, member)
+	}
+
+	if len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	channel.Owner = user.Username
+	channel.Members = members
+
+	id := s.ChannelRepository.Insert(channel)
+
+	return s.ChannelRepository.FindByID(id), nil
+}
+
+// Makes username a member of the channel with id. Only the owner may add
+// members
+func (s *ChannelService) AddMember(id, username string, user models.User) (*models.Channel, error) {
+	channel := s.ChannelRepository.FindByID(id)
+
+	if channel == nil || s.UserRepository.FindByUsername(username) == nil {
+		return nil, &NotFoundError{}
+	}
+
+	if channel.Owner != user.Username {
+		return nil, &NotOwnerError{}
+	}
+
+	for _, member := range channel.Members {
+		if member == username {
+			return channel, nil
+		}
+	}
+
+	channel.Members = append(channel.Members, username)
+	s.ChannelRepository.Update(*channel)
+
+	return channel, nil
+}
+
+// Removes username from the members of the channel with id. The owner may
+// remove anyone but themselves, and members may leave
+func (s *ChannelService) RemoveMember(id, username string, user models.User) (*models.Channel, error) {
+	channel := s.ChannelRepository.FindByID(id)
+
+	if channel == nil {
+		return nil, &NotFoundError{}
+	}
+
+	if channel.Owner != user.Username && username != user.Username {
+		return nil, &NotOwnerError{}
+	}
+
+	// A channel without its owner couldn't be managed by anyone
+	if username == channel.Owner {
+		return nil, &ForbiddenError{}
+	}
+
+	members := []string{}
+
+	for _, member := range channel.Members {
+		if member != username {
+			members = append(members, member)
+		}
+	}
+
+	channel.Members = members
+	s.ChannelRepository.Update(*channel)
+
+	return channel, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"time"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/validation"
+)
+
+// Drafts are validated like messages, see models.Draft.Validate, and
+// scheduled drafts must not be due before now
+func validateDraft(draft models.Draft, now time.Time) []validation.Error {
+	v := validation.Validator{}
+
+	if draft.IsScheduled() {
+		v.NotBefore("publish_at", *draft.PublishAt, now)
+	}
+
+	return append(draft.Validate(), v.Errors()...)
+}
+
+// Saves a draft authored by user. Like when creating messages, attachments
+// are ignored, and tags are normalized. If PublishAt is set, the draft is
+// published then, see PublishDueDrafts
+func (s *MessageService) CreateDraft(draft models.Draft, user models.User) (*models.Draft, error) {
+	now := s.now()
+
+	draft.Author = user.Username
+	draft.Tags = models.NormalizeTags(draft.Tags)
+	draft.Attachments = nil
+	draft.CreatedAt = &now
+	draft.UpdatedAt = &now
+	draft.DeletedAt = nil
+	draft.PinnedAt = nil
+	draft.Locked = false
+	draft.PublishError = ""
+
+	if errors := validateDraft(draft, now); len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	id := s.DraftRepository.Insert(draft)
+
+	return s.DraftRepository.FindByID(id), nil
+}
+
+// Returns the drafts of user within page, and the total number of them
+func (s *MessageService) GetDrafts(page Page, user models.User) ([]models.Draft, int) {
+	drafts := s.DraftRepository.FindByAuthor(user.Username)
+	start, end := page.bounds(len(drafts))
+
+	return drafts[start:end], len(drafts)
+}
+
+// Returns the draft with id. Drafts can only be seen by their author, to
+// others they don't exist
+func (s *MessageService) GetDraft(id string, user models.User) (*models.Draft, error) {
+	draft := s.DraftRepository.FindByID(id)
+
+	if draft == nil || draft.Author != user.Username {
+		return nil, &NotFoundError{}
+	}
+
+	return draft, nil
+}
+
+// Replaces the content and schedule of a draft of user, until it is
+// published. Leaving out PublishAt stops it from being published
+func (s *MessageService) UpdateDraft(draft models.Draft, user models.User) (*models.Draft, error) {
+	stored, err := s.GetDraft(draft.ID, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+
+	draft.Author = stored.Author
+	draft.Tags = models.NormalizeTags(draft.Tags)
+	draft.Attachments = nil
+	draft.CreatedAt = stored.CreatedAt
+	draft.UpdatedAt = &now
+	draft.DeletedAt = nil
+	draft.PinnedAt = nil
+	draft.Locked = false
+	draft.PublishError = ""
+
+	if errors := validateDraft(draft, now); len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	// It may have been published meanwhile
+	if !s.DraftRepository.Update(draft) {
+		return nil, &NotFoundError{}
+	}
+
+	return s.DraftRepository.FindByID(draft.ID), nil
+}
+
+// Deletes a draft of user, cancelling it if it is scheduled
+func (s *MessageService) DeleteDraft(id string, user models.User) error {
+	if _, err := s.GetDraft(id, user); err != nil {
+		return err
+	}
+
+	if !s.DraftRepository.DeleteByID(id) {
+		return &NotFoundError{}
+	}
+
+	return nil
+}
+
+// Publishes a draft of user now, whether it is scheduled or not, and
+// returns the message. If it can't be published (ie it is missing a topic),
+// the draft is kept
+func (s *MessageService) PublishDraft(id string, user models.User) (*models.Message, error) {
+	draft, err := s.GetDraft(id, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing the draft first makes sure it is only published once, even
+	// if the scheduler is publishing it too
+	if !s.DraftRepository.DeleteByID(id) {
+		return nil, &NotFoundError{}
+	}
+
+	return s.publish(*draft, user)
+}
+
+// Publishes the scheduled drafts that are due as messages by their authors.
+// This fires the usual events, and notifies those mentioned. Drafts that
+// can't be published (ie because their channel is gone) are kept, but no
+// longer scheduled, and PublishError tells why. Returns how many were
+// published
+func (s *MessageService) PublishDueDrafts() int {
+	published := 0
+
+	for _, draft := range s.DraftRepository.FindDue(s.now()) {
+		// Cancelled or published since it was found
+		if !s.DraftRepository.DeleteByID(draft.ID) {
+			continue
+		}
+
+		problem := ""
+
+		if author := s.UserRepository.FindByUsername(draft.Author); author == nil {
+			s.DraftRepository.InsertWithID(draft)
+			problem = "The author no longer exists"
+		} else if _, err := s.publish(draft, *author); err != nil {
+			// Held messages are published once approved
+			if _, held := err.(*HeldError); !held {
+				problem = publishProblem(err)
+			}
+		}
+
+		if len(problem) > 0 {
+			draft.PublishAt = nil
+			draft.PublishError = problem
+			s.DraftRepository.Update(draft)
+			continue
+		}
+
+		published++
+	}
+
+	return published
+}
+
+// Describes why a draft couldn't be published by the scheduler. Only the
+// author will see it, later
+func publishProblem(err error) string {
+	switch err.(type) {
+	case *NotValidError, *RejectedError:
+		return err.Error()
+	case *ForbiddenError:
+		return "The author is no longer a member of the channel"
+	case *LockedError:
+		return "The thread has been locked"
+	case *BlockedError:
+		return "Someone the message would reach has blocked the author"
+	}
+
+	return "The draft could not be published"
+}
+
+// Creates a message from draft, which has been removed from the
+// repository. If that fails, the draft is put back, unless the message was
+// held for review
+func (s *MessageService) publish(draft models.Draft, author models.User) (*models.Message, error) {
+	message := draft.Message
+	message.ID = ""
+
+	created, err := s.CreateMessage(message, author)
+
+	if err != nil {
+		if _, held := err.(*HeldError); !held {
+			s.DraftRepository.InsertWithID(draft)
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/dennis/hello_go/models"
+)
+
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+	// The message was taken out of the trash
+	EventRestored EventType = "restored"
+	// Someone reacted to the message, or took their reaction back
+	EventReactionAdded   EventType = "reaction_added"
+	EventReactionRemoved EventType = "reaction_removed"
+)
+
+// A change to a message. For deletes, Message is the message as it was. For
+// reaction events, Reaction is the reaction added or removed
+type MessageEvent struct {
+	Type     EventType
+	Message  models.Message
+	Reaction models.Reaction
+}
+
+// Delivers message events to subscribers, ie the gRPC WatchMessages stream.
+// Publishing never blocks: a subscriber whose buffer is full is dropped and
+// its channel closed, so it can tell that it missed events. A nil EventBus
+// drops everything, so services work without one
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan MessageEvent]bool
+}
+
+// Returns a channel receiving every event published from now on, and a
+// function to call when no longer interested. buffer is how many events may
+// be pending before the subscriber is dropped
+func (b *EventBus) Subscribe(buffer int) (<-chan MessageEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = map[chan MessageEvent]bool{}
+	}
+
+	ch := make(chan MessageEvent, buffer)
+	b.subscribers[ch] = true
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.subscribers[ch] {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *EventBus) Publish(event MessageEvent) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
+	"github.com/dennis/hello_go/services/servicestest"
+)
+
+func TestEventBus_DeliversToSubscribers(t *testing.T) {
+	bus := &services.EventBus{}
+	events, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	bus.Publish(services.MessageEvent{Type: services.EventCreated, Message: models.Message{ID: "1"}})
+
+	if e := <-events; e.Type != services.EventCreated || e.Message.ID != "1" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
+
+func TestEventBus_DropsSlowSubscribers(t *testing.T) {
+	bus := &services.EventBus{}
+	events, unsubscribe := bus.Subscribe(1)
+
+	bus.Publish(services.MessageEvent{Type: services.EventCreated})
+	bus.Publish(services.MessageEvent{Type: services.EventUpdated})
+
+	<-events
+	if _, ok := <-events; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+
+	// Must be safe after being dropped
+	unsubscribe()
+}
+
+func TestEventBus_Nil(t *testing.T) {
+	var bus *services.EventBus
+
+	bus.Publish(services.MessageEvent{Type: services.EventCreated})
+}
+
+func TestMessageService_PublishesEvents(t *testing.T) {
+	bus := &services.EventBus{}
+	events, unsubscribe := bus.Subscribe(5)
+	defer unsubscribe()
+
+	channels := &repositories.ChannelRepository{}
+	channels.InsertWithID(models.DefaultChannel)
+
+	s := servicestest.NewMessageService(nil, channels, nil)
+	s.Events = bus
+	user := models.User{Username: "dennis"}
+
+	created, _ := s.CreateMessage(models.Message{Topic: "t", Body: "b"}, user)
+	s.UpdateMessage(models.Message{ID: created.ID, Topic: "t2", Body: "b"}, user)
+	s.AddReaction(created.ID, "👍", user)
+	s.AddReaction(created.ID, "👍", user)
+	s.RemoveReaction(created.ID, "👍", user)
+	s.DeleteMessage(created.ID, user)
+
+	for _, expected := range []services.EventType{services.EventCreated, services.EventUpdated, services.EventReactionAdded, services.EventReactionRemoved, services.EventDeleted} {
+		if e := <-events; e.Type != expected || e.Message.ID != created.ID {
+			t.Errorf("Expected %s event, got %+v", expected, e)
+		}
+	}
+}
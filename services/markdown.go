@@ -0,0 +1,9 @@
+package services
+
+import "github.com/dennis/hello_go/models"
+
+// Returns the body of message, which is Markdown, rendered as sanitized HTML.
+// It is only rendered again when the body has changed
+func (s *MessageService) BodyHTML(message models.Message) string {
+	return s.Markdown.ToHTML(message.ID, message.Body)
+}
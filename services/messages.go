@@ -2,9 +2,16 @@ package services
 
 import (
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/dennis/hello_go/blobs"
+	"github.com/dennis/hello_go/markdown"
 	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
 	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/search"
+	"github.com/dennis/hello_go/validation"
 )
 
 type NotFoundError struct{}
@@ -12,49 +19,446 @@ type NotFoundError struct{}
 func (e *NotFoundError) Error() string { return "Not found" }
 
 type NotValidError struct {
-	Errors []string
+	Errors []validation.Error
 }
 
-func (e *NotValidError) Error() string { return strings.Join(e.Errors, ". ") }
+func (e *NotValidError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+
+	for _, err := range e.Errors {
+		messages = append(messages, err.Message)
+	}
+
+	return strings.Join(messages, ". ")
+}
 
 type NotOwnerError struct{}
 
 func (e *NotOwnerError) Error() string { return "Not owner" }
 
+// Selects part of a list. A Limit of 0 means no limit
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// Returns the part of a list of length total covered by the page, as
+// indexes suitable for slicing
+func (p Page) bounds(total int) (int, int) {
+	start := p.Offset
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if p.Limit > 0 && start+p.Limit < total {
+		end = start + p.Limit
+	}
+
+	return start, end
+}
+
+// The user isn't allowed to do this at all, regardless of the resource
+type ForbiddenError struct{}
+
+func (e *ForbiddenError) Error() string { return "Forbidden" }
+
+// The message, or the thread it is in, has been locked by a moderator, so it
+// can't be replied to or updated
+type LockedError struct{}
+
+func (e *LockedError) Error() string { return "Locked" }
+
 type MessageService struct {
 	MessageRepository *repositories.MessageRepository
+	// Messages can only be read by members of their channel
+	ChannelRepository *repositories.ChannelRepository
+	// Recipients of direct messages must exist
+	UserRepository *repositories.UserRepository
+	// Remembers which messages each user has read
+	ReceiptRepository *repositories.ReceiptRepository
+	// Remembers who reacted to each message, and with what
+	ReactionRepository *repositories.ReactionRepository
+	// Keeps the notifications of users mentioned in messages
+	NotificationRepository *repositories.NotificationRepository
+	// Keeps the messages not published yet
+	DraftRepository *repositories.DraftRepository
+	// Decides which messages are published, held for review or rejected.
+	// May be nil
+	Moderation moderation.Pipeline
+	// The queue of messages held for review
+	HeldMessageRepository *repositories.HeldMessageRepository
+	// Remembers whom each user has muted and blocked
+	BlockRepository *repositories.BlockRepository
+	// Keeps the content of attachments
+	Blobs blobs.Store
+	// Held while a blob is stored or deleted along with the check whether
+	// any message refers to it, so a new attachment can't lose its content
+	blobsMu sync.Mutex
+	// Remembers the bodies rendered as HTML. May be nil
+	Markdown *markdown.Cache
+	// Receives an event for every change. May be nil
+	Events *EventBus
+	// Tells the time messages are created, updated and deleted. Defaults to
+	// time.Now
+	Clock func() time.Time
 }
 
-func (s *MessageService) GetMessages() ([]models.Message, error) {
-	return s.MessageRepository.GetAll(), nil
+func (s *MessageService) now() time.Time {
+	if s.Clock == nil {
+		return time.Now()
+	}
+
+	return s.Clock()
 }
 
-func (s *MessageService) GetMessage(id string) (*models.Message, error) {
+// Returns the message with id, unless it doesn't exist or is in the trash
+func (s *MessageService) find(id string) *models.Message {
 	message := s.MessageRepository.FindByID(id)
 
+	if message == nil || message.IsDeleted() {
+		return nil
+	}
+
+	return message
+}
+
+// Tells if user may read message, ie is a member of its channel. Direct
+// messages can only be read by their author and recipients
+func (s *MessageService) CanRead(message models.Message, user models.User) bool {
+	if message.IsDirect() {
+		return message.Author == user.Username || message.IsRecipient(user.Username)
+	}
+
+	if len(message.ChannelID) == 0 {
+		message.ChannelID = models.DefaultChannelID
+	}
+
+	channel := s.ChannelRepository.FindByID(message.ChannelID)
+
+	return channel != nil && channel.IsMember(user.Username)
+}
+
+// Returns the page of the messages user may read, and the total number of
+// them. Deleted messages, and those by authors user has muted or blocked,
+// are left out
+func (s *MessageService) readable(messages []models.Message, page Page, user models.User) ([]models.Message, int, error) {
+	members := map[string]bool{}
+	readable := []models.Message{}
+
+	for _, m := range messages {
+		if m.IsDeleted() || s.IsHidden(m, user) {
+			continue
+		}
+
+		if m.IsDirect() {
+			if s.CanRead(m, user) {
+				readable = append(readable, m)
+			}
+			continue
+		}
+
+		member, ok := members[m.ChannelID]
+		if !ok {
+			member = s.CanRead(m, user)
+			members[m.ChannelID] = member
+		}
+
+		if member {
+			readable = append(readable, m)
+		}
+	}
+
+	start, end := page.bounds(len(readable))
+
+	return readable[start:end], len(readable), nil
+}
+
+// Returns the messages within page, and the total number of messages, in
+// the channels user is a member of, along with the direct messages user has
+// sent or received. Pinned messages come first
+func (s *MessageService) GetMessages(page Page, user models.User) ([]models.Message, int, error) {
+	return s.readable(s.MessageRepository.FindByQuery(search.Query{PinnedFirst: true}), page, user)
+}
+
+// Returns the messages matching q within page, of those user may read like
+// GetMessages, the total number of them, and how many of those user hasn't
+// read
+func (s *MessageService) FindMessages(q search.Query, page Page, user models.User) ([]models.Message, int, int) {
+	found := s.MessageRepository.FindByQuery(q)
+	messages, total, _ := s.readable(found, page, user)
+
+	return messages, total, s.countUnread(found, user)
+}
+
+// Returns the messages in the channel with id within page, and the total
+// number of messages in it. Only members may read them
+func (s *MessageService) GetChannelMessages(id string, page Page, user models.User) ([]models.Message, int, error) {
+	channel := s.ChannelRepository.FindByID(id)
+
+	if channel == nil {
+		return nil, 0, &NotFoundError{}
+	}
+
+	if !channel.IsMember(user.Username) {
+		return nil, 0, &ForbiddenError{}
+	}
+
+	return s.readable(s.MessageRepository.FindByChannelID(id), page, user)
+}
+
+func (s *MessageService) GetMessage(id string, user models.User) (*models.Message, error) {
+	message := s.find(id)
+
 	if message == nil {
 		return nil, &NotFoundError{}
 	}
 
+	if !s.CanRead(*message, user) {
+		return nil, &ForbiddenError{}
+	}
+
 	return message, nil
 }
 
+// Returns the direct messages user has received from others within page,
+// with the number of unread messages in total and from each sender. Senders
+// user has muted or blocked are left out
+func (s *MessageService) GetInbox(page Page, user models.User) models.Inbox {
+	inbox := models.Inbox{Senders: []models.InboxSender{}}
+	messages := []models.ListedMessage{}
+	senders := map[string]int{}
+
+	for _, m := range s.MessageRepository.FindByRecipient(user.Username) {
+		if m.Author == user.Username || m.IsDeleted() || s.IsHidden(m, user) {
+			continue
+		}
+
+		listed := s.View(m, user)
+		messages = append(messages, listed)
+
+		if !listed.Unread {
+			continue
+		}
+
+		inbox.Unread++
+
+		if index, ok := senders[m.Author]; ok {
+			inbox.Senders[index].Unread++
+		} else {
+			senders[m.Author] = len(inbox.Senders)
+			inbox.Senders = append(inbox.Senders, models.InboxSender{Username: m.Author, Unread: 1})
+		}
+	}
+
+	start, end := page.bounds(len(messages))
+
+	inbox.Total = len(messages)
+	inbox.Messages = messages[start:end]
+
+	return inbox
+}
+
+// Returns the messages starting a thread within page, and the total number of
+// threads
+func (s *MessageService) GetThreads(page Page, user models.User) ([]models.Message, int, error) {
+	return s.GetReplies("", page, user)
+}
+
+// Returns the messages written by author within page, and the total number of
+// messages by author
+func (s *MessageService) GetMessagesByAuthor(author string, page Page, user models.User) ([]models.Message, int, error) {
+	messages := []models.Message{}
+
+	for _, m := range s.MessageRepository.GetAll() {
+		if m.Author == author {
+			messages = append(messages, m)
+		}
+	}
+
+	return s.readable(messages, page, user)
+}
+
+// Returns a page of the replies to the message with id, and the total number
+// of replies
+func (s *MessageService) GetReplies(id string, page Page, user models.User) ([]models.Message, int, error) {
+	return s.readable(s.MessageRepository.FindByParentID(id), page, user)
+}
+
+// Creates message authored by user, in ChannelID or the default channel if
+// that is empty. Only members of the channel may post in it. If Recipients
+// is set, it is a direct message to them instead, which isn't posted in any
+// channel. If ParentID is set, it is a reply to that message, which must
+// exist, and it is posted in the channel of the parent, or sent to everyone
+// else taking part in a direct message. Tags are normalized, see
+// models.NormalizeTags. Users mentioned in the body are notified. Replies
+// to locked threads are refused, as are replies to, mentions of and direct
+// messages to users who have blocked user. Valid messages are then
+// moderated, and may be rejected or held for review, see RejectedError and
+// HeldError
 func (s *MessageService) CreateMessage(message models.Message, user models.User) (*models.Message, error) {
-	errors := message.Validate()
+	return s.createMessage(message, user, true)
+}
+
+// Creates message like CreateMessage, moderating it if moderate is set
+func (s *MessageService) createMessage(message models.Message, user models.User, moderate bool) (*models.Message, error) {
+	message.ID = ""
+	message.Tags = models.NormalizeTags(message.Tags)
+	message.Attachments = nil
+	message.PinnedAt = nil
+	message.Locked = false
+	message.DeletedAt = nil
+	errors := append(message.Validate(), s.blockedMentions(message, user)...)
+	locked := false
+	blocked := false
+
+	if len(message.ParentID) > 0 {
+		parent := s.find(message.ParentID)
+		locked = parent != nil && s.isLocked(*parent)
+		blocked = parent != nil && s.blockedBy([]string{parent.Author}, user)
+
+		// The parent must be in the channel asked for, if any
+		if parent != nil && s.CanRead(*parent, user) && (len(message.ChannelID) == 0 || message.ChannelID == parent.ChannelID) {
+			message.ChannelID = parent.ChannelID
+			message.Recipients = replyRecipients(*parent, user)
+		} else {
+			errors = append(errors, validation.Error{
+				Field:   "parent_id",
+				Code:    validation.CodeNotFound,
+				Message: "Parent does not exist",
+			})
+		}
+	} else if message.IsDirect() {
+		if len(message.ChannelID) > 0 {
+			errors = append(errors, validation.Error{
+				Field:   "channel_id",
+				Code:    validation.CodeNotAllowed,
+				Message: "Channel is not allowed for direct messages",
+			})
+		}
+
+		recipients, recipientErrors := s.recipients(message.Recipients)
+		message.Recipients = recipients
+		errors = append(errors, recipientErrors...)
+	}
+
+	var channel *models.Channel
+
+	if !message.IsDirect() {
+		if len(message.ChannelID) == 0 {
+			message.ChannelID = models.DefaultChannelID
+		}
+
+		if channel = s.ChannelRepository.FindByID(message.ChannelID); channel == nil {
+			errors = append(errors, validation.Error{
+				Field:   "channel_id",
+				Code:    validation.CodeNotFound,
+				Message: "Channel does not exist",
+			})
+		}
+	}
 
 	if len(errors) > 0 {
 		return nil, &NotValidError{Errors: errors}
 	}
 
+	if channel != nil && !channel.IsMember(user.Username) {
+		return nil, &ForbiddenError{}
+	}
+
+	if blocked || s.blockedBy(message.Recipients, user) {
+		return nil, &BlockedError{}
+	}
+
+	if locked {
+		return nil, &LockedError{}
+	}
+
 	message.Author = user.Username
+	now := s.now()
+	message.CreatedAt = &now
+	message.UpdatedAt = &now
+
+	if moderate {
+		if err := s.screen(message); err != nil {
+			return nil, err
+		}
+	}
 
 	id := s.MessageRepository.Insert(message)
+	created := s.MessageRepository.FindByID(id)
+
+	s.Events.Publish(MessageEvent{Type: EventCreated, Message: *created})
+	s.notifyMentioned(*created)
 
-	return s.MessageRepository.FindByID(id), nil
+	return created, nil
 }
 
+// Returns recipients without duplicates, and errors for those that don't
+// exist
+func (s *MessageService) recipients(recipients []string) ([]string, []validation.Error) {
+	unique := []string{}
+	errors := []validation.Error{}
+	seen := map[string]bool{}
+
+	for _, recipient := range recipients {
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+
+		if s.UserRepository.FindByUsername(recipient) == nil {
+			errors = append(errors, validation.Error{
+				Field:   "recipients",
+				Code:    validation.CodeNotFound,
+				Message: "Recipient does not exist",
+				Params:  map[string]interface{}{"username": recipient},
+			})
+			continue
+		}
+
+		unique = append(unique, recipient)
+	}
+
+	return unique, errors
+}
+
+// Replies to a direct message are sent to the others taking part in it.
+// Replies to other messages have no recipients
+func replyRecipients(parent models.Message, user models.User) []string {
+	if !parent.IsDirect() {
+		return nil
+	}
+
+	recipients := []string{}
+	seen := map[string]bool{user.Username: true}
+
+	for _, username := range append([]string{parent.Author}, parent.Recipients...) {
+		if !seen[username] {
+			seen[username] = true
+			recipients = append(recipients, username)
+		}
+	}
+
+	// Replying to a message to yourself
+	if len(recipients) == 0 {
+		recipients = append(recipients, user.Username)
+	}
+
+	return recipients
+}
+
+// Updates message, which only its author may do, unless its thread is
+// locked. The update is moderated like when creating messages. Users
+// mentioned in the body are notified, unless they have been about the
+// message before. Users who have blocked user can't be mentioned
 func (s *MessageService) UpdateMessage(message models.Message, user models.User) (*models.Message, error) {
-	storedMessage := s.MessageRepository.FindByID(message.ID)
+	return s.updateMessage(message, user, true)
+}
+
+// Updates message like UpdateMessage, moderating it if moderate is set
+func (s *MessageService) updateMessage(message models.Message, user models.User, moderate bool) (*models.Message, error) {
+	storedMessage := s.find(message.ID)
 
 	if storedMessage == nil {
 		return nil, &NotFoundError{}
@@ -64,19 +468,54 @@ func (s *MessageService) UpdateMessage(message models.Message, user models.User)
 		return nil, &NotOwnerError{}
 	}
 
+	if s.isLocked(*storedMessage) {
+		return nil, &LockedError{}
+	}
+
 	message.Author = user.Username
+	// Messages can't be moved to another thread or channel, or be sent to
+	// others
+	message.ParentID = storedMessage.ParentID
+	message.ChannelID = storedMessage.ChannelID
+	message.Recipients = storedMessage.Recipients
+	message.Attachments = storedMessage.Attachments
+	message.CreatedAt = storedMessage.CreatedAt
+	message.PinnedAt = storedMessage.PinnedAt
+	message.Locked = storedMessage.Locked
+	message.DeletedAt = storedMessage.DeletedAt
+	now := s.now()
+	message.UpdatedAt = &now
+
+	// Tags are kept unless new ones are given. An empty list clears them
+	if message.Tags == nil {
+		message.Tags = storedMessage.Tags
+	} else {
+		message.Tags = models.NormalizeTags(message.Tags)
+	}
+
+	if errors := append(message.Validate(), s.blockedMentions(message, user)...); len(errors) == 0 {
+		if moderate {
+			if err := s.screen(message); err != nil {
+				return nil, err
+			}
+		}
 
-	if errors := message.Validate(); len(errors) == 0 {
 		s.MessageRepository.Update(message)
+		updated := s.MessageRepository.FindByID(message.ID)
 
-		return s.MessageRepository.FindByID(message.ID), nil
+		s.Events.Publish(MessageEvent{Type: EventUpdated, Message: *updated})
+		s.notifyMentioned(*updated)
+
+		return updated, nil
 	} else {
 		return nil, &NotValidError{Errors: errors}
 	}
 }
 
+// Moves the message with id to the trash of its author, from where it can
+// be restored until it is purged, see RestoreMessage and PurgeTrash
 func (s *MessageService) DeleteMessage(id string, user models.User) error {
-	message := s.MessageRepository.FindByID(id)
+	message := s.find(id)
 
 	if message == nil {
 		return &NotFoundError{}
@@ -86,7 +525,11 @@ func (s *MessageService) DeleteMessage(id string, user models.User) error {
 		return &NotOwnerError{}
 	}
 
-	s.MessageRepository.DeleteByID(id)
+	deletedAt := s.now()
+	message.DeletedAt = &deletedAt
+	s.MessageRepository.Update(*message)
+
+	s.Events.Publish(MessageEvent{Type: EventDeleted, Message: *message})
 
 	return nil
 }
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/moderation"
+)
+
+// The message was rejected when moderating it. Reason tells the author why,
+// and Code and Params identify it for translating, see moderation.Decision
+type RejectedError struct {
+	Reason string
+	Code   string
+	Params map[string]interface{}
+}
+
+func (e *RejectedError) Error() string { return e.Reason }
+
+// The message, or the edit, was held for review by a moderator instead of
+// being published. Held is the message in the queue
+type HeldError struct {
+	Held models.HeldMessage
+}
+
+func (e *HeldError) Error() string { return "Held for review" }
+
+// Runs message through Moderation before it is created or updated. Messages
+// to hold are put in the queue
+func (s *MessageService) screen(message models.Message) error {
+	decision := s.Moderation.Check(message)
+
+	switch decision.Action {
+	case moderation.Reject:
+		return &RejectedError{Reason: decision.Reason, Code: decision.Code, Params: decision.Params}
+	case moderation.Hold:
+		held := models.HeldMessage{Message: message, Reason: decision.Reason, HeldAt: s.now()}
+		held.ID = s.HeldMessageRepository.Insert(held)
+
+		return &HeldError{Held: held}
+	}
+
+	return nil
+}
+
+// Returns the messages held for review within page, oldest first, and the
+// total number of them. Only moderators may see them, including held
+// direct messages
+func (s *MessageService) GetHeldMessages(page Page, user models.User) ([]models.HeldMessage, int, error) {
+	if !user.IsModerator() {
+		return nil, 0, &ForbiddenError{}
+	}
+
+	held := s.HeldMessageRepository.GetAll()
+	start, end := page.bounds(len(held))
+
+	return held[start:end], len(held), nil
+}
+
+// Publishes the held message with id as its author, without moderating it
+// again. Held edits replace any edits made to the message since. If
+// publishing fails (ie because the parent was deleted meanwhile), it stays
+// in the queue. Only moderators may approve messages
+func (s *MessageService) ApproveHeldMessage(id string, user models.User) (*models.Message, error) {
+	if !user.IsModerator() {
+		return nil, &ForbiddenError{}
+	}
+
+	held := s.HeldMessageRepository.FindByID(id)
+
+	// Approved or rejected by another moderator since
+	if held == nil || !s.HeldMessageRepository.DeleteByID(id) {
+		return nil, &NotFoundError{}
+	}
+
+	author := models.User{Username: held.Message.Author}
+	if u := s.UserRepository.FindByUsername(author.Username); u != nil {
+		author = *u
+	}
+
+	var message *models.Message
+	var err error
+
+	if held.IsEdit() {
+		message, err = s.updateMessage(held.Message, author, false)
+	} else {
+		message, err = s.createMessage(held.Message, author, false)
+	}
+
+	if err != nil {
+		s.HeldMessageRepository.InsertWithID(*held)
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Removes the held message with id from the queue without publishing it.
+// Only moderators may reject messages
+func (s *MessageService) RejectHeldMessage(id string, user models.User) error {
+	if !user.IsModerator() {
+		return &ForbiddenError{}
+	}
+
+	if !s.HeldMessageRepository.DeleteByID(id) {
+		return &NotFoundError{}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import "github.com/dennis/hello_go/models"
+
+// Notifies the users mentioned in message who can read it, unless they have
+// been notified about it before, ie when it is updated. Mentions are
+// resolved ignoring case, and authors aren't notified about themselves
+func (s *MessageService) notifyMentioned(message models.Message) {
+	for _, mention := range message.Mentions() {
+		user := s.UserRepository.FindByUsernameIgnoringCase(mention)
+
+		if user == nil || user.Username == message.Author || !s.CanRead(message, *user) {
+			continue
+		}
+
+		if s.NotificationRepository.Exists(user.Username, message.ID) {
+			continue
+		}
+
+		s.NotificationRepository.Insert(models.Notification{
+			Username:  user.Username,
+			MessageID: message.ID,
+			Author:    message.Author,
+			CreatedAt: s.now(),
+		})
+	}
+}
+
+// Returns the notifications of user within page, newest first, with the
+// number of notifications in total and unread. Notifications about messages
+// that are deleted, that user can no longer read, or by authors user has
+// muted or blocked, are left out
+func (s *MessageService) GetNotifications(page Page, user models.User) models.Notifications {
+	result := models.Notifications{}
+	notifications := []models.Notification{}
+
+	all := s.NotificationRepository.FindByUsername(user.Username)
+
+	for i := len(all) - 1; i >= 0; i-- {
+		message := s.find(all[i].MessageID)
+
+		if message == nil || !s.CanRead(*message, user) || s.IsHidden(*message, user) {
+			continue
+		}
+
+		notifications = append(notifications, all[i])
+
+		if !all[i].Read {
+			result.Unread++
+		}
+	}
+
+	start, end := page.bounds(len(notifications))
+
+	result.Total = len(notifications)
+	result.Notifications = notifications[start:end]
+
+	return result
+}
+
+// Marks the notification of user with id as read
+func (s *MessageService) MarkNotificationRead(id string, user models.User) error {
+	if !s.NotificationRepository.MarkRead(user.Username, id) {
+		return &NotFoundError{}
+	}
+
+	return nil
+}
+
+// Marks all the notifications of user as read
+func (s *MessageService) MarkAllNotificationsRead(user models.User) {
+	s.NotificationRepository.MarkAllRead(user.Username)
+}
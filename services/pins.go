@@ -0,0 +1,94 @@
+package services
+
+import (
+	"github.com/dennis/hello_go/models"
+)
+
+// Applies change to the message with id on behalf of user, who must be a
+// moderator able to read it. The message keeps its UpdatedAt, as its content
+// is the same, but subscribers are told it was updated
+func (s *MessageService) moderate(id string, user models.User, change func(message *models.Message)) (*models.Message, error) {
+	message := s.find(id)
+
+	if message == nil {
+		return nil, &NotFoundError{}
+	}
+
+	if !user.IsModerator() || !s.CanRead(*message, user) {
+		return nil, &ForbiddenError{}
+	}
+
+	// Changed as it is now, as it may have been edited, deleted or purged
+	// since it was looked up
+	message = s.MessageRepository.UpdateIf(id, func(m *models.Message) bool {
+		if m.IsDeleted() {
+			return false
+		}
+
+		change(m)
+		return true
+	})
+
+	if message == nil {
+		return nil, &NotFoundError{}
+	}
+
+	s.Events.Publish(MessageEvent{Type: EventUpdated, Message: *message})
+
+	return message, nil
+}
+
+// Pins the message with id to the top of the list of messages, above those
+// pinned before. Pinning it again moves it to the top
+func (s *MessageService) PinMessage(id string, user models.User) (*models.Message, error) {
+	return s.moderate(id, user, func(message *models.Message) {
+		now := s.now()
+		message.PinnedAt = &now
+	})
+}
+
+func (s *MessageService) UnpinMessage(id string, user models.User) (*models.Message, error) {
+	return s.moderate(id, user, func(message *models.Message) {
+		message.PinnedAt = nil
+	})
+}
+
+// Locks the message with id, so it and the replies below it can no longer
+// be replied to or updated, see LockedError
+func (s *MessageService) LockMessage(id string, user models.User) (*models.Message, error) {
+	return s.moderate(id, user, func(message *models.Message) {
+		message.Locked = true
+	})
+}
+
+// Unlocks the message with id. Replies below it stay locked if a message
+// further up the thread is locked
+func (s *MessageService) UnlockMessage(id string, user models.User) (*models.Message, error) {
+	return s.moderate(id, user, func(message *models.Message) {
+		message.Locked = false
+	})
+}
+
+// Tells if message is locked, or is below a locked message in its thread.
+// Messages in the trash still lock the replies below them, and purged ones
+// leave their replies locked, see forget
+func (s *MessageService) isLocked(message models.Message) bool {
+	seen := map[string]bool{}
+
+	for !message.Locked {
+		// Imported messages could form a loop
+		if len(message.ParentID) == 0 || seen[message.ParentID] {
+			return false
+		}
+		seen[message.ParentID] = true
+
+		parent := s.MessageRepository.FindByID(message.ParentID)
+		if parent == nil {
+			return false
+		}
+
+		message = *parent
+	}
+
+	return true
+}
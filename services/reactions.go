@@ -0,0 +1,114 @@
+package services
+
+import (
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/validation"
+)
+
+// Adds a reaction with emoji by user to the message with id, which user must
+// be able to read. Reacting twice with the same emoji does nothing. Returns
+// the reactions to the message afterwards
+func (s *MessageService) AddReaction(id, emoji string, user models.User) ([]models.ReactionCount, error) {
+	message, err := s.GetMessage(id, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := models.Reaction{MessageID: id, Username: user.Username, Emoji: emoji}
+
+	if errors := reaction.Validate(); len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	added, err := s.ReactionRepository.AddIf(reaction, func(existing []models.Reaction) error {
+		return checkReactionLimits(existing, reaction)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if added {
+		s.Events.Publish(MessageEvent{Type: EventReactionAdded, Message: *message, Reaction: reaction})
+	}
+
+	return s.Reactions(*message, user), nil
+}
+
+// Returns an error if adding reaction to the existing reactions to its
+// message would go over the limits on emoji per message or reactions per user
+func checkReactionLimits(existing []models.Reaction, reaction models.Reaction) error {
+	emojis := []string{}
+	mine := []string{}
+
+	for _, r := range append(existing, reaction) {
+		if !contains(emojis, r.Emoji) {
+			emojis = append(emojis, r.Emoji)
+		}
+
+		if r.Username == reaction.Username && !contains(mine, r.Emoji) {
+			mine = append(mine, r.Emoji)
+		}
+	}
+
+	v := validation.Validator{}
+	v.Each("emoji", emojis, models.CurrentLimits.MaxReactionEmoji)
+	v.Each("reactions", mine, models.CurrentLimits.MaxReactionsPerUser)
+
+	if errors := v.Errors(); len(errors) > 0 {
+		return &NotValidError{Errors: errors}
+	}
+
+	return nil
+}
+
+// Removes the reaction with emoji by user from the message with id, if there
+// is one. Returns the reactions to the message afterwards
+func (s *MessageService) RemoveReaction(id, emoji string, user models.User) ([]models.ReactionCount, error) {
+	message, err := s.GetMessage(id, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := models.Reaction{MessageID: id, Username: user.Username, Emoji: emoji}
+
+	if s.ReactionRepository.Remove(reaction) {
+		s.Events.Publish(MessageEvent{Type: EventReactionRemoved, Message: *message, Reaction: reaction})
+	}
+
+	return s.Reactions(*message, user), nil
+}
+
+// Returns the reactions to message counted by emoji, ordered by the earliest
+// reaction with each, and whether user is among those reacting with it
+func (s *MessageService) Reactions(message models.Message, user models.User) []models.ReactionCount {
+	counts := []models.ReactionCount{}
+	index := map[string]int{}
+
+	for _, r := range s.ReactionRepository.FindByMessageID(message.ID) {
+		i, ok := index[r.Emoji]
+
+		if !ok {
+			i = len(counts)
+			index[r.Emoji] = i
+			counts = append(counts, models.ReactionCount{Emoji: r.Emoji})
+		}
+
+		counts[i].Count++
+		counts[i].Me = counts[i].Me || r.Username == user.Username
+	}
+
+	return counts
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Tells if user hasn't read message yet. Users have always read their own
+// messages
+func (s *MessageService) IsUnread(message models.Message, user models.User) bool {
+	return message.Author != user.Username && !s.ReceiptRepository.IsRead(user.Username, message.ID)
+}
+
+// Returns message as seen by user: whether they have read it, the reactions
+// to it, and its body rendered as HTML
+func (s *MessageService) View(message models.Message, user models.User) models.ListedMessage {
+	return models.ListedMessage{Message: message, Unread: s.IsUnread(message, user), Reactions: s.Reactions(message, user), BodyHTML: s.BodyHTML(message)}
+}
+
+// Returns messages as seen by user, see View
+func (s *MessageService) Views(messages []models.Message, user models.User) []models.ListedMessage {
+	listed := make([]models.ListedMessage, 0, len(messages))
+
+	for _, m := range messages {
+		listed = append(listed, s.View(m, user))
+	}
+
+	return listed
+}
+
+// Returns the number of messages in the channel with id that user hasn't
+// read. Only members may count them. If id is empty, all the messages user
+// may read are counted, like GetMessages
+func (s *MessageService) CountUnread(id string, user models.User) (int, error) {
+	var messages []models.Message
+
+	if len(id) == 0 {
+		messages = s.MessageRepository.GetAll()
+	} else {
+		channel := s.ChannelRepository.FindByID(id)
+
+		if channel == nil {
+			return 0, &NotFoundError{}
+		}
+
+		if !channel.IsMember(user.Username) {
+			return 0, &ForbiddenError{}
+		}
+
+		messages = s.MessageRepository.FindByChannelID(id)
+	}
+
+	return s.countUnread(messages, user), nil
+}
+
+// Returns the number of messages user may read but hasn't
+func (s *MessageService) countUnread(messages []models.Message, user models.User) int {
+	readable, _, _ := s.readable(messages, Page{}, user)
+	unread := 0
+
+	for _, m := range readable {
+		if s.IsUnread(m, user) {
+			unread++
+		}
+	}
+
+	return unread
+}
+
+// Returns the message with id like GetMessage, and marks it as read by user
+func (s *MessageService) ReadMessage(id string, user models.User) (*models.Message, error) {
+	message, err := s.GetMessage(id, user)
+
+	if err == nil {
+		s.ReceiptRepository.MarkRead(user.Username, id)
+	}
+
+	return message, err
+}
+
+// Marks the message with id as read by user, who must be able to read it
+func (s *MessageService) MarkRead(id string, user models.User) error {
+	_, err := s.ReadMessage(id, user)
+
+	return err
+}
+
+// Marks the message with id, and all the replies to it and their replies, as
+// read by user. Replies user can't read are left as they are
+func (s *MessageService) MarkThreadRead(id string, user models.User) error {
+	if _, err := s.GetMessage(id, user); err != nil {
+		return err
+	}
+
+	replies := map[string][]models.Message{}
+
+	for _, m := range s.MessageRepository.GetAll() {
+		if len(m.ParentID) > 0 {
+			replies[m.ParentID] = append(replies[m.ParentID], m)
+		}
+	}
+
+	ids := []string{id}
+
+	for i := 0; i < len(ids); i++ {
+		readable, _, _ := s.readable(replies[ids[i]], Page{}, user)
+
+		for _, m := range readable {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	s.ReceiptRepository.MarkRead(user.Username, ids...)
+
+	return nil
+}
+
+// Marks every message user may read as read by them. To keep a single receipt
+// for them, messages user can't read now (ie in channels they join later) are
+// marked too, if they are older than the newest message user may read
+func (s *MessageService) MarkAllRead(user models.User) {
+	readable, _, _ := s.readable(s.MessageRepository.GetAll(), Page{}, user)
+
+	var upTo uint64
+	ids := []string{}
+
+	// Messages from imports may have IDs that aren't numbers, so they can't
+	// be covered by upTo
+	for _, m := range readable {
+		if n, err := strconv.ParseUint(m.ID, 10, 64); err == nil {
+			if n > upTo {
+				upTo = n
+			}
+		} else {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	s.ReceiptRepository.MarkReadUpTo(user.Username, upTo, ids...)
+}
@@ -0,0 +1,36 @@
+// Package servicestest builds services for tests, so the tests of the other
+// packages don't each have to know every repository a service needs.
+package servicestest
+
+import (
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/services"
+)
+
+// Returns a MessageService with messages, channels and users, and empty
+// in-memory repositories for everything else. Any of the three may be nil
+// for an empty one. Moderation, Blobs and Markdown are left unset
+func NewMessageService(messages *repositories.MessageRepository, channels *repositories.ChannelRepository, users *repositories.UserRepository) services.MessageService {
+	if messages == nil {
+		messages = &repositories.MessageRepository{}
+	}
+	if channels == nil {
+		channels = &repositories.ChannelRepository{}
+	}
+	if users == nil {
+		users = &repositories.UserRepository{}
+	}
+
+	return services.MessageService{
+		MessageRepository:      messages,
+		ChannelRepository:      channels,
+		UserRepository:         users,
+		ReceiptRepository:      &repositories.ReceiptRepository{},
+		ReactionRepository:     &repositories.ReactionRepository{},
+		NotificationRepository: &repositories.NotificationRepository{},
+		DraftRepository:        &repositories.DraftRepository{},
+		HeldMessageRepository:  &repositories.HeldMessageRepository{},
+		BlockRepository:        &repositories.BlockRepository{},
+		Events:                 &services.EventBus{},
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Returns the tags of the messages user may read, with the number of
+// messages using each. The most used tags come first
+func (s *MessageService) GetTags(user models.User) []models.TagCount {
+	readable, _, _ := s.readable(s.MessageRepository.GetAll(), Page{}, user)
+	counts := map[string]int{}
+
+	for _, m := range readable {
+		for _, tag := range m.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]models.TagCount, 0, len(counts))
+
+	for name, count := range counts {
+		tags = append(tags, models.TagCount{Name: name, Count: count})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags
+}
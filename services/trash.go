@@ -0,0 +1,144 @@
+package services
+
+import (
+	"time"
+
+	"github.com/dennis/hello_go/models"
+)
+
+// Returns the messages user has deleted within page, and the total number of
+// them
+func (s *MessageService) GetTrash(page Page, user models.User) ([]models.Message, int) {
+	trash := []models.Message{}
+
+	for _, m := range s.MessageRepository.GetAll() {
+		if m.IsDeleted() && m.Author == user.Username {
+			trash = append(trash, m)
+		}
+	}
+
+	start, end := page.bounds(len(trash))
+
+	return trash[start:end], len(trash)
+}
+
+// Takes the message with id out of the trash again. Only its author may do
+// this, and only while it is in the trash
+func (s *MessageService) RestoreMessage(id string, user models.User) (*models.Message, error) {
+	message := s.MessageRepository.FindByID(id)
+
+	if message == nil || !message.IsDeleted() {
+		return nil, &NotFoundError{}
+	}
+
+	if message.Author != user.Username {
+		return nil, &NotOwnerError{}
+	}
+
+	// It may be purged or restored meanwhile
+	message = s.MessageRepository.UpdateIf(id, func(m *models.Message) bool {
+		if !m.IsDeleted() {
+			return false
+		}
+
+		m.DeletedAt = nil
+		return true
+	})
+
+	if message == nil {
+		return nil, &NotFoundError{}
+	}
+
+	s.Events.Publish(MessageEvent{Type: EventRestored, Message: *message})
+
+	return message, nil
+}
+
+// Removes what is kept about message besides the message itself, once it
+// is purged. Its replies are locked if it was, so they stay locked without
+// it
+func (s *MessageService) forget(message models.Message) {
+	s.ReceiptRepository.DeleteByMessageID(message.ID)
+	s.ReactionRepository.DeleteByMessageID(message.ID)
+	s.NotificationRepository.DeleteByMessageID(message.ID)
+	s.HeldMessageRepository.DeleteByMessageID(message.ID)
+
+	if !s.isLocked(message) {
+		return
+	}
+
+	for _, reply := range s.MessageRepository.FindByParentID(message.ID) {
+		locked := s.MessageRepository.UpdateIf(reply.ID, func(m *models.Message) bool {
+			changed := !m.Locked
+			m.Locked = true
+			return changed
+		})
+
+		if locked != nil {
+			s.Events.Publish(MessageEvent{Type: EventUpdated, Message: *locked})
+		}
+	}
+}
+
+// Removes the message with id for good, whether it is in the trash or not,
+// along with the content of its attachments. This is meant for admins, so it
+// doesn't check who user is
+func (s *MessageService) PurgeMessage(id string) error {
+	var message models.Message
+
+	// Takes the message as it is when deleted, as it may be restored until
+	// then
+	deleted := s.MessageRepository.DeleteByIDIf(id, func(m models.Message) bool {
+		message = m
+		return true
+	})
+
+	if !deleted {
+		return &NotFoundError{}
+	}
+
+	s.forget(message)
+
+	// Subscribers were told when it went to the trash
+	if !message.IsDeleted() {
+		s.Events.Publish(MessageEvent{Type: EventDeleted, Message: message})
+	}
+
+	return s.releaseBlobs(message.Attachments)
+}
+
+// Removes the messages that have been in the trash for longer than retention
+// for good, like PurgeMessage. Returns how many were removed, and the error
+// deleting the content of their attachments, if any. Messages are purged
+// even then
+func (s *MessageService) PurgeTrash(retention time.Duration) (int, error) {
+	before := s.now().Add(-retention)
+	purged := 0
+	attachments := []models.Attachment{}
+
+	expired := func(m models.Message) bool {
+		return m.IsDeleted() && m.DeletedAt.Before(before)
+	}
+
+	for _, m := range s.MessageRepository.GetAll() {
+		if !expired(m) {
+			continue
+		}
+
+		// Checked again as it is deleted, as it may have been restored since
+		deleted := s.MessageRepository.DeleteByIDIf(m.ID, func(current models.Message) bool {
+			m = current
+			return expired(current)
+		})
+
+		if deleted {
+			s.forget(m)
+			attachments = append(attachments, m.Attachments...)
+			purged++
+		}
+	}
+
+	// Blobs that can't be deleted now are left behind. They take up space,
+	// but do no harm
+	return purged, s.releaseBlobs(attachments)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/dennis/hello_go/models"
+	"github.com/dennis/hello_go/repositories"
+	"github.com/dennis/hello_go/validation"
+)
+
+type UserService struct {
+	UserRepository *repositories.UserRepository
+}
+
+// Returns a random token. 16 bytes is plenty to make them unguessable
+func generateToken() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func (s *UserService) GetUsers() []models.User {
+	return s.UserRepository.GetAll()
+}
+
+func (s *UserService) GetUser(username string) (*models.User, error) {
+	user := s.UserRepository.FindByUsername(username)
+
+	if user == nil {
+		return nil, &NotFoundError{}
+	}
+
+	return user, nil
+}
+
+// Users are found by their token, so no two users may have the same
+var tokenTaken = validation.Error{
+	Field:   "auth_token",
+	Code:    validation.CodeTaken,
+	Message: "Auth token is already taken",
+}
+
+// Creates a user. A token is generated, if the user doesn't have one
+func (s *UserService) CreateUser(user models.User) (*models.User, error) {
+	if len(user.AuthToken) == 0 {
+		user.AuthToken = generateToken()
+	}
+
+	errors := user.Validate()
+
+	if s.UserRepository.FindByUsername(user.Username) != nil {
+		errors = append(errors, validation.Error{
+			Field:   "username",
+			Code:    validation.CodeTaken,
+			Message: "Username is already taken",
+		})
+	}
+
+	if s.UserRepository.FindByToken(user.AuthToken) != nil {
+		errors = append(errors, tokenTaken)
+	}
+
+	if len(errors) > 0 {
+		return nil, &NotValidError{Errors: errors}
+	}
+
+	s.UserRepository.Insert(user)
+
+	return s.UserRepository.FindByUsername(user.Username), nil
+}
+
+// Replaces the token of a user, so the old one stops working
+func (s *UserService) RotateToken(username string) (*models.User, error) {
+	user := s.UserRepository.FindByUsername(username)
+
+	if user == nil {
+		return nil, &NotFoundError{}
+	}
+
+	user.AuthToken = generateToken()
+	s.UserRepository.Update(*user)
+
+	return user, nil
+}
@@ -0,0 +1,237 @@
+// Package validation provides reusable rules for validating fields of our
+// models, producing structured errors that can be reported to clients.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Codes identifying which rule a field failed. Clients can rely on these
+// not to change, unlike the message
+const (
+	CodeRequired            = "required"
+	CodeMinLength           = "min_length"
+	CodeMaxLength           = "max_length"
+	CodeForbiddenCharacters = "forbidden_characters"
+	CodeInvalidUTF8         = "invalid_utf8"
+	CodeInvalidFormat       = "invalid_format"
+	CodeMaxItems            = "max_items"
+	CodeMaxSize             = "max_size"
+	CodeTooEarly            = "too_early"
+	// Not used by any rule, but by services checking for uniqueness
+	CodeTaken = "taken"
+	// Not used by any rule, but by services checking that a reference (ie
+	// to a parent message) exists
+	CodeNotFound = "not_found"
+	// Not used by any rule, but by services rejecting a field that doesn't
+	// apply (ie a channel for a direct message)
+	CodeNotAllowed = "not_allowed"
+	// Not used by any rule, but for records in imports that couldn't be
+	// parsed at all
+	CodeMalformed = "malformed"
+)
+
+// Describes why a single field didn't pass validation. Params contains the
+// values used by the rule (ie the maximum length), so clients can build their
+// own messages from the code
+type Error struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// A Rule checks a single value of the field named field. It returns nil if
+// the value is valid
+type Rule func(field, value string) *Error
+
+// Collects errors for all the fields of a model
+type Validator struct {
+	errors []Error
+}
+
+// Runs rules against value. Only the first failing rule is reported, as
+// later rules rarely make sense for a value that already failed (ie a
+// missing value is always too short)
+func (v *Validator) Field(field, value string, rules ...Rule) {
+	for _, rule := range rules {
+		if err := rule(field, value); err != nil {
+			v.errors = append(v.errors, *err)
+			return
+		}
+	}
+}
+
+// Runs rules against each of values, like Field, and fails if there are
+// more than max of them. A max of zero or less allows any number. The params
+// of errors for single values include the value
+func (v *Validator) Each(field string, values []string, max int, rules ...Rule) {
+	if max > 0 && len(values) > max {
+		v.errors = append(v.errors, Error{
+			Field:   field,
+			Code:    CodeMaxItems,
+			Message: fmt.Sprintf("%s can be given at most %d times", label(field), max),
+			Params:  map[string]interface{}{"max": max},
+		})
+		return
+	}
+
+	for _, value := range values {
+		for _, rule := range rules {
+			if err := rule(field, value); err != nil {
+				if err.Params == nil {
+					err.Params = map[string]interface{}{}
+				}
+				err.Params["value"] = value
+
+				v.errors = append(v.errors, *err)
+				break
+			}
+		}
+	}
+}
+
+// Fails if size (ie of a file) is more than max bytes. A max of zero or less
+// allows any size
+func (v *Validator) Size(field string, size, max int64) {
+	if max > 0 && size > max {
+		v.errors = append(v.errors, Error{
+			Field:   field,
+			Code:    CodeMaxSize,
+			Message: fmt.Sprintf("%s must be at most %d bytes", label(field), max),
+			Params:  map[string]interface{}{"max": max},
+		})
+	}
+}
+
+// Fails if value (ie when to do something) is before min
+func (v *Validator) NotBefore(field string, value, min time.Time) {
+	if value.Before(min) {
+		v.errors = append(v.errors, Error{
+			Field:   field,
+			Code:    CodeTooEarly,
+			Message: fmt.Sprintf("%s must not be before %s", label(field), min.Format(time.RFC3339)),
+			Params:  map[string]interface{}{"min": min.Format(time.RFC3339)},
+		})
+	}
+}
+
+// Returns the errors found so far. Never returns nil
+func (v *Validator) Errors() []Error {
+	if v.errors == nil {
+		return []Error{}
+	}
+
+	return v.errors
+}
+
+// Turns "topic" or "auth_token" into "Topic" and "Auth token"
+func label(field string) string {
+	if len(field) == 0 {
+		return field
+	}
+
+	s := strings.Replace(field, "_", " ", -1)
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Fails if value is empty or only contains whitespace
+func Required() Rule {
+	return func(field, value string) *Error {
+		if len(strings.TrimSpace(value)) > 0 {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeRequired,
+			Message: fmt.Sprintf("%s is mandatory", label(field)),
+		}
+	}
+}
+
+// Fails if value contains fewer than min characters
+func MinLength(min int) Rule {
+	return func(field, value string) *Error {
+		if utf8.RuneCountInString(value) >= min {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeMinLength,
+			Message: fmt.Sprintf("%s must be at least %d characters", label(field), min),
+			Params:  map[string]interface{}{"min": min},
+		}
+	}
+}
+
+// Fails if value contains more than max characters. A max of zero or less
+// disables the rule
+func MaxLength(max int) Rule {
+	return func(field, value string) *Error {
+		if max <= 0 || utf8.RuneCountInString(value) <= max {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeMaxLength,
+			Message: fmt.Sprintf("%s must be at most %d characters", label(field), max),
+			Params:  map[string]interface{}{"max": max},
+		}
+	}
+}
+
+// Fails if value contains any of the characters in chars
+func ForbiddenCharacters(chars string) Rule {
+	return func(field, value string) *Error {
+		if len(chars) == 0 || !strings.ContainsAny(value, chars) {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeForbiddenCharacters,
+			Message: fmt.Sprintf("%s contains forbidden characters", label(field)),
+			Params:  map[string]interface{}{"characters": chars},
+		}
+	}
+}
+
+// Fails if value isn't valid UTF-8. Values decoded from JSON always are, but
+// values imported from other sources might not be
+func ValidUTF8() Rule {
+	return func(field, value string) *Error {
+		if utf8.ValidString(value) {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeInvalidUTF8,
+			Message: fmt.Sprintf("%s must be valid UTF-8", label(field)),
+		}
+	}
+}
+
+// Fails if value doesn't match pattern
+func Matches(pattern *regexp.Regexp) Rule {
+	return func(field, value string) *Error {
+		if pattern.MatchString(value) {
+			return nil
+		}
+
+		return &Error{
+			Field:   field,
+			Code:    CodeInvalidFormat,
+			Message: fmt.Sprintf("%s has an invalid format", label(field)),
+			Params:  map[string]interface{}{"pattern": pattern.String()},
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func assertCode(t *testing.T, err *Error, expected string) {
+	if err == nil {
+		t.Errorf("Expected error with code '%s', but value was valid", expected)
+	} else if err.Code != expected {
+		t.Errorf("Expected error with code '%s', but got '%s'", expected, err.Code)
+	}
+}
+
+func assertValid(t *testing.T, err *Error) {
+	if err != nil {
+		t.Errorf("Expected value to be valid, but got %v", err)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	assertValid(t, Required()("topic", "x"))
+	assertCode(t, Required()("topic", ""), CodeRequired)
+	assertCode(t, Required()("topic", " \t\n"), CodeRequired)
+}
+
+func TestRequiredMessage(t *testing.T) {
+	err := Required()("auth_token", "")
+
+	if err.Message != "Auth token is mandatory" || err.Field != "auth_token" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMinLength(t *testing.T) {
+	assertValid(t, MinLength(3)("f", "abc"))
+	assertCode(t, MinLength(3)("f", "ab"), CodeMinLength)
+}
+
+func TestMaxLengthCountsCharacters(t *testing.T) {
+	assertValid(t, MaxLength(3)("f", "æøå"))
+	assertCode(t, MaxLength(3)("f", "abcd"), CodeMaxLength)
+
+	if err := MaxLength(3)("f", "abcd"); err.Params["max"] != 3 {
+		t.Errorf("Expected max param, got %v", err.Params)
+	}
+}
+
+func TestMaxLengthDisabled(t *testing.T) {
+	assertValid(t, MaxLength(0)("f", "abcd"))
+}
+
+func TestForbiddenCharacters(t *testing.T) {
+	assertValid(t, ForbiddenCharacters("<>")("f", "abc"))
+	assertCode(t, ForbiddenCharacters("<>")("f", "a<b"), CodeForbiddenCharacters)
+}
+
+func TestValidUTF8(t *testing.T) {
+	assertValid(t, ValidUTF8()("f", "æøå"))
+	assertCode(t, ValidUTF8()("f", "a\xffb"), CodeInvalidUTF8)
+}
+
+func TestMatches(t *testing.T) {
+	rule := Matches(regexp.MustCompile(`^[a-z]+$`))
+
+	assertValid(t, rule("f", "abc"))
+	assertCode(t, rule("f", "ab c"), CodeInvalidFormat)
+}
+
+func TestValidatorReportsFirstFailingRulePerField(t *testing.T) {
+	v := Validator{}
+
+	v.Field("a", "", Required(), MinLength(3))
+	v.Field("b", "toolong", Required(), MaxLength(3))
+	v.Field("c", "ok", Required())
+
+	errors := v.Errors()
+
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errors)
+	}
+
+	assertCode(t, &errors[0], CodeRequired)
+	assertCode(t, &errors[1], CodeMaxLength)
+}
+
+func TestValidatorWithoutErrors(t *testing.T) {
+	v := Validator{}
+
+	if errors := v.Errors(); errors == nil || len(errors) != 0 {
+		t.Errorf("Expected empty errors, got %v", errors)
+	}
+}
+
+func TestValidatorEach(t *testing.T) {
+	v := Validator{}
+
+	v.Each("a", []string{"ok", "", "toolong"}, 3, Required(), MaxLength(3))
+	v.Each("b", []string{"x", "y"}, 1, Required())
+
+	errors := v.Errors()
+
+	if len(errors) != 3 {
+		t.Fatalf("Expected 3 errors, got %v", errors)
+	}
+
+	assertCode(t, &errors[0], CodeRequired)
+	assertCode(t, &errors[1], CodeMaxLength)
+	assertCode(t, &errors[2], CodeMaxItems)
+
+	if errors[1].Params["value"] != "toolong" {
+		t.Errorf("Expected the value in the params, got %v", errors[1].Params)
+	}
+}
+
+func TestValidatorSize(t *testing.T) {
+	v := Validator{}
+
+	v.Size("a", 10, 10)
+	v.Size("b", 11, 10)
+	v.Size("c", 11, 0)
+
+	errors := v.Errors()
+
+	if len(errors) != 1 || errors[0].Field != "b" {
+		t.Fatalf("Expected b to be too large, got %v", errors)
+	}
+
+	assertCode(t, &errors[0], CodeMaxSize)
+}
+
+func TestValidatorNotBefore(t *testing.T) {
+	v := Validator{}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	v.NotBefore("a", now, now)
+	v.NotBefore("b", now.Add(-time.Second), now)
+
+	errors := v.Errors()
+
+	if len(errors) != 1 || errors[0].Field != "b" || errors[0].Params["min"] != "2020-01-01T12:00:00Z" {
+		t.Fatalf("Expected b to be too early, got %v", errors)
+	}
+
+	assertCode(t, &errors[0], CodeTooEarly)
+}